@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OS install stages reported in BareMetalServerlOSStatus.OSStatus, in the order
+// a reinstall goes through them
+const (
+	OSStatusShuttingDown     = "shutting_down"
+	OSStatusACReset          = "ac_reset"
+	OSStatusBIOSReset        = "bios_reset"
+	OSStatusBootingInstaller = "booting_installer"
+	OSStatusInstallingOS     = "installing_os"
+	OSStatusFinalizingOS     = "finalizing_os"
+	OSStatusFirstBoot        = "first_boot"
+	OSStatusFirstBootTasks   = "first_boot_tasks"
+	OSStatusInstalled        = "installed"
+	OSStatusFailed           = "failed"
+)
+
+// OSInstallStages lists the install stages in order, ending with OSStatusInstalled
+var OSInstallStages = []string{
+	OSStatusShuttingDown,
+	OSStatusACReset,
+	OSStatusBIOSReset,
+	OSStatusBootingInstaller,
+	OSStatusInstallingOS,
+	OSStatusFinalizingOS,
+	OSStatusFirstBoot,
+	OSStatusFirstBootTasks,
+	OSStatusInstalled,
+}
+
+// ErrOSInstallFailed is returned by WaitForInstall when the install ends in OSStatusFailed
+var ErrOSInstallFailed = errors.New("OS install failed")
+
+// OSInstallStageIndex returns the position of status in OSInstallStages, or -1
+// if it is not a known stage
+func OSInstallStageIndex(status string) int {
+	for i, stage := range OSInstallStages {
+		if stage == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// WaitForInstall polls a server until its OS install reaches OSStatusInstalled
+// or OSStatusFailed. When since is not zero, such as the time a reinstall was
+// started, a final stage left from an earlier install is ignored: it only ends
+// the wait once an install stage was polled, or if it was reported after
+// since. onPoll, if not nil, is called with every polled server that is not
+// ignored.
+func (s *BareMetalService) WaitForInstall(ctx context.Context, teamHandle, serverName string, since time.Time, cfg WaitConfig, onPoll func(*BareMetalServerDetails)) (*BareMetalServerDetails, error) {
+	inProgress := false
+	server, err := Wait(ctx, cfg, func(ctx context.Context) (*BareMetalServerDetails, error) {
+		return s.Get(ctx, teamHandle, serverName)
+	}, func(server *BareMetalServerDetails) (bool, error) {
+		status := server.OSStatus
+		final := status != nil && (status.OSStatus == OSStatusInstalled || status.OSStatus == OSStatusFailed)
+		if final && !since.IsZero() && !inProgress && !status.LastImagingUpdate.After(since) {
+			return false, nil
+		}
+		if onPoll != nil {
+			onPoll(server)
+		}
+		if status == nil {
+			return false, nil
+		}
+		switch status.OSStatus {
+		case OSStatusInstalled:
+			return true, nil
+		case OSStatusFailed:
+			return true, fmt.Errorf("%w on server %s", ErrOSInstallFailed, serverName)
+		}
+		inProgress = true
+		return false, nil
+	})
+	if errors.Is(err, ErrWaitTimeout) && server != nil && server.OSStatus != nil {
+		return server, fmt.Errorf("%w: server %s is %s", err, serverName, server.OSStatus.OSStatus)
+	}
+	return server, err
+}
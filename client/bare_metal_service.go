@@ -1,8 +1,11 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
 package client
 
 import (
 	"context"
 	"net/http"
+	"net/url"
 )
 
 // BareMetalService handles bare metal server-related API operations
@@ -15,7 +18,9 @@ func (c *Client) BareMetal() *BareMetalService {
 	return &BareMetalService{client: c}
 }
 
-// List retrieves all bare metal servers for a team
+// List returns a list of bare metal servers associated with the team.
+//
+// Requires team role: any
 func (s *BareMetalService) List(ctx context.Context, teamHandle string) ([]BareMetalServerDetails, error) {
 	path := buildPath("/teams/{team}/bare_metal/", map[string]string{
 		"team": teamHandle,
@@ -25,7 +30,9 @@ func (s *BareMetalService) List(ctx context.Context, teamHandle string) ([]BareM
 	return result, err
 }
 
-// Get retrieves detailed information about a specific bare metal server
+// Get returns detailed information about a specific bare metal server.
+//
+// Requires team role: any
 func (s *BareMetalService) Get(ctx context.Context, teamHandle, serverName string) (*BareMetalServerDetails, error) {
 	path := buildPath("/teams/{team}/bare_metal/{server}/", map[string]string{
 		"team":   teamHandle,
@@ -36,7 +43,9 @@ func (s *BareMetalService) Get(ctx context.Context, teamHandle, serverName strin
 	return &result, err
 }
 
-// Reserve reserves a bare metal server for the team
+// Reserve reserves a bare metal server for the team, including validation of tenant limits and balance, and submits 8-hour minimum usage to Stripe.
+//
+// Requires team role: operator
 func (s *BareMetalService) Reserve(ctx context.Context, teamHandle string, req BareMetalServerReservation) (*BareMetalServerReservationResponse, error) {
 	path := buildPath("/teams/{team}/bare_metal/", map[string]string{
 		"team": teamHandle,
@@ -46,7 +55,9 @@ func (s *BareMetalService) Reserve(ctx context.Context, teamHandle string, req B
 	return &result, err
 }
 
-// Update updates a bare metal server's description
+// Update updates a bare metal server's description.
+//
+// Requires team role: operator
 func (s *BareMetalService) Update(ctx context.Context, teamHandle, serverName string, update BareMetalServerUpdate) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/", map[string]string{
 		"team":   teamHandle,
@@ -55,7 +66,12 @@ func (s *BareMetalService) Update(ctx context.Context, teamHandle, serverName st
 	return s.client.doRequest(ctx, http.MethodPatch, path, update, nil)
 }
 
-// Delete releases a bare metal server back to the available pool
+// Delete releases a bare metal server back to the available pool.
+// When using force=true, the server can be released even if the minimum reservation time has not been met. However, the minimum charge for the reservation period will NOT be refunded.
+//
+// Use DeleteWithForce to send the query parameters.
+//
+// Requires team role: operator
 func (s *BareMetalService) Delete(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/", map[string]string{
 		"team":   teamHandle,
@@ -64,7 +80,25 @@ func (s *BareMetalService) Delete(ctx context.Context, teamHandle, serverName st
 	return s.client.doRequest(ctx, http.MethodDelete, path, nil, nil)
 }
 
-// GetAvailable retrieves available bare metal server types
+// DeleteWithForce releases a bare metal server back to the available pool.
+// When using force=true, the server can be released even if the minimum reservation time has not been met. However, the minimum charge for the reservation period will NOT be refunded.
+//
+// Requires team role: operator
+func (s *BareMetalService) DeleteWithForce(ctx context.Context, teamHandle, serverName string, force bool) error {
+	path := buildPath("/teams/{team}/bare_metal/{server}/", map[string]string{
+		"team":   teamHandle,
+		"server": serverName,
+	})
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return s.client.doRequest(ctx, http.MethodDelete, withQuery(path, query), nil, nil)
+}
+
+// GetAvailable returns a list of available bare metal server types that can be reserved, including pricing information.
+//
+// Requires team role: any
 func (s *BareMetalService) GetAvailable(ctx context.Context, teamHandle string) ([]AvailableBareMetalTypes, error) {
 	path := buildPath("/teams/{team}/bare_metal/available/", map[string]string{
 		"team": teamHandle,
@@ -74,7 +108,9 @@ func (s *BareMetalService) GetAvailable(ctx context.Context, teamHandle string)
 	return result, err
 }
 
-// GetPowerState retrieves the current power state of a server
+// GetPowerState returns the current power state of the server.
+//
+// Requires team role: any
 func (s *BareMetalService) GetPowerState(ctx context.Context, teamHandle, serverName string) (*BareMetalServerPowerState, error) {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/", map[string]string{
 		"team":   teamHandle,
@@ -85,7 +121,9 @@ func (s *BareMetalService) GetPowerState(ctx context.Context, teamHandle, server
 	return &result, err
 }
 
-// PowerOn turns on a server
+// PowerOn turns on the server if it is currently off.
+//
+// Requires team role: operator
 func (s *BareMetalService) PowerOn(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/power_on/", map[string]string{
 		"team":   teamHandle,
@@ -94,7 +132,9 @@ func (s *BareMetalService) PowerOn(ctx context.Context, teamHandle, serverName s
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// GracefulShutdown sends an ACPI signal to initiate a clean shutdown
+// GracefulShutdown sends an ACPI signal to the OS to initiate a clean shutdown.
+//
+// Requires team role: operator
 func (s *BareMetalService) GracefulShutdown(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/graceful_shutdown/", map[string]string{
 		"team":   teamHandle,
@@ -103,7 +143,9 @@ func (s *BareMetalService) GracefulShutdown(ctx context.Context, teamHandle, ser
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// ForceShutdown immediately powers off the server
+// ForceShutdown immediately powers off the server without a clean shutdown. May cause data loss.
+//
+// Requires team role: operator
 func (s *BareMetalService) ForceShutdown(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/force_shutdown/", map[string]string{
 		"team":   teamHandle,
@@ -112,7 +154,9 @@ func (s *BareMetalService) ForceShutdown(ctx context.Context, teamHandle, server
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// WarmReboot reboots the system without turning the power off completely
+// WarmReboot reboots the system without turning the power off completely.
+//
+// Requires team role: operator
 func (s *BareMetalService) WarmReboot(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/warm_reboot/", map[string]string{
 		"team":   teamHandle,
@@ -121,7 +165,9 @@ func (s *BareMetalService) WarmReboot(ctx context.Context, teamHandle, serverNam
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// ColdReboot turns off and then reboots the system
+// ColdReboot turns off and then reboots the system.
+//
+// Requires team role: operator
 func (s *BareMetalService) ColdReboot(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/cold_reboot/", map[string]string{
 		"team":   teamHandle,
@@ -130,7 +176,9 @@ func (s *BareMetalService) ColdReboot(ctx context.Context, teamHandle, serverNam
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// ACReset performs a complete AC reset of the server
+// ACReset performs a complete AC reset of the server. The server must already be in the off state for this to succeed. BMC will also reset during an AC reset, so getting the power state or any other BMC operations will fail for several minutes while this is in progress.
+//
+// Requires team role: operator
 func (s *BareMetalService) ACReset(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/power/ac_reset/", map[string]string{
 		"team":   teamHandle,
@@ -139,7 +187,20 @@ func (s *BareMetalService) ACReset(ctx context.Context, teamHandle, serverName s
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// Reinstall resets BIOS settings, wipes all disks, and reinstalls the OS
+// Reinstall resets BIOS settings, wipes all disks, and reinstalls the operating system. The reinstall process goes through several stages which can be monitored via the server details endpoint.
+// 1. A graceful shutdown of the server will be triggered and the status will be updated to `shutting_down`. If the server takes more than 5 minutes to shut down, a force shutdown will occur.
+// 2. The server will perform a full ac reset and put the server in the `ac_reset` state. This will take several minutes.
+// 3. When the server comes back, a reset of bios settings will be triggred and the server will be in `bios_reset` state. This will take several minutes.
+// 4. After the bios reset is complete, the server will boot into the installer environment and will be in the `booting_installer` state.
+// 5. When the installation starts the server will be in the `installing_os` state.
+// 6. Once the base install is complete, the server will install additional packges and will be in the `finalizing_os` state.
+// 7. When it completes these tasks and reboots from the installer environment the server will be in the `first_boot` state.
+// 8. Upon first boot, some final taks will run and the server will be in the `first_boot_tasks` state.
+// If everything completes successfully the server will be in the `installed` state.
+// If any action fails, the server will be in the `failed` state.
+// The `last_imaging_update` will indicate the timestamp of the last update to the install progress.
+//
+// Requires team role: operator
 func (s *BareMetalService) Reinstall(ctx context.Context, teamHandle, serverName string) (*BareMetalServerDetails, error) {
 	path := buildPath("/teams/{team}/bare_metal/{server}/reinstall/", map[string]string{
 		"team":   teamHandle,
@@ -150,7 +211,9 @@ func (s *BareMetalService) Reinstall(ctx context.Context, teamHandle, serverName
 	return &result, err
 }
 
-// GetConsoleURL generates a URL for bare metal console access
+// GetConsoleURL returns a one time use URL for local console access
+//
+// Requires team role: operator
 func (s *BareMetalService) GetConsoleURL(ctx context.Context, teamHandle, serverName string) (*BareMetalServerConsoleURL, error) {
 	path := buildPath("/teams/{team}/bare_metal/{server}/console/", map[string]string{
 		"team":   teamHandle,
@@ -161,7 +224,9 @@ func (s *BareMetalService) GetConsoleURL(ctx context.Context, teamHandle, server
 	return &result, err
 }
 
-// EnableSupportAccess enables Hot Aisle support staff to access the server
+// EnableSupportAccess enables Hot Aisle support staff to access this server for troubleshooting.
+//
+// Requires team role: operator
 func (s *BareMetalService) EnableSupportAccess(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/support_access_enable/", map[string]string{
 		"team":   teamHandle,
@@ -170,7 +235,9 @@ func (s *BareMetalService) EnableSupportAccess(ctx context.Context, teamHandle,
 	return s.client.doRequest(ctx, http.MethodPut, path, nil, nil)
 }
 
-// DisableSupportAccess revokes Hot Aisle support staff access to the server
+// DisableSupportAccess revokes Hot Aisle support staff access to this server.
+//
+// Requires team role: operator
 func (s *BareMetalService) DisableSupportAccess(ctx context.Context, teamHandle, serverName string) error {
 	path := buildPath("/teams/{team}/bare_metal/{server}/support_access_enable/", map[string]string{
 		"team":   teamHandle,
@@ -1,15 +1,21 @@
-// Package client_gen provides a generated HTTPS client for the HotAisle API
+// Package client is an HTTPS client for the HotAisle API. The models and the
+// services are generated from swagger.json by internal/gen/clientgen.
 package client
 
+//go:generate go run ../internal/gen/clientgen -spec ../swagger.json -out .
+
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +24,9 @@ const (
 	DefaultBaseURL = "https://admin.hotaisle.app/api"
 	// DefaultTimeout is the default HTTP client timeout
 	DefaultTimeout = 30 * time.Second
+	// DefaultRequestTimeout is how long a request may take by default, its
+	// retries included
+	DefaultRequestTimeout = 120 * time.Second
 )
 
 // Client is an HTTPS client for the HotAisle API
@@ -25,7 +34,17 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	token      string
+	tokenFunc  func() string
+	tokenOnce  sync.Once
 	userAgent  string
+	retry      RetryPolicy
+	logger     *slog.Logger
+	dryRunOut  io.Writer
+
+	// timeout, proxy and tlsConfig configure the default HTTP client
+	timeout   time.Duration
+	proxy     *url.URL
+	tlsConfig *tls.Config
 }
 
 // Option is a function that configures a Client
@@ -45,6 +64,15 @@ func WithToken(token string) Option {
 	}
 }
 
+// WithTokenFunc sets a function returning the authentication token, called
+// once when the first request is made, e.g. to read the token from a store
+// that asks for a passphrase only if a request is made
+func WithTokenFunc(tokenFunc func() string) Option {
+	return func(c *Client) {
+		c.tokenFunc = tokenFunc
+	}
+}
+
 // WithBaseURL sets a custom base URL
 func WithBaseURL(baseURL string) Option {
 	return func(c *Client) {
@@ -62,74 +90,102 @@ func WithUserAgent(ua string) Option {
 // NewClient creates a new HotAisle API client
 func NewClient(opts ...Option) *Client {
 	c := &Client{
-		baseURL: DefaultBaseURL,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        20,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     30 * time.Second,
-			},
-		},
+		baseURL:   DefaultBaseURL,
 		userAgent: "hotaisle/1.0",
+		retry:     DefaultRetryPolicy(),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	if c.httpClient == nil {
+		c.httpClient = c.newHTTPClient()
+	}
+	if c.dryRunOut != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = &DryRunTransport{Out: c.dryRunOut, Next: httpClient.Transport}
+		c.httpClient = &httpClient
+	}
+	if c.logger != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = &LoggingTransport{Logger: c.logger, Next: httpClient.Transport}
+		c.httpClient = &httpClient
+	}
+
 	return c
 }
 
 // SetToken updates the authentication token
 func (c *Client) SetToken(token string) {
 	c.token = token
+	c.tokenFunc = nil
 }
 
-// doRequest executes an HTTP request
+// authToken returns the authentication token, calling the token function the first time
+func (c *Client) authToken() string {
+	if c.tokenFunc != nil {
+		c.tokenOnce.Do(func() { c.token = c.tokenFunc() })
+	}
+	return c.token
+}
+
+// doRequest executes an HTTP request, retrying transient failures according to the retry policy
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	var bodyReader io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	// hardcode a long timeout just to be safe, nothing should really take this long
-	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	fullURL := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	var (
+		resp     *http.Response
+		respBody []byte
+		err      error
+	)
+	for attempt := 1; ; attempt++ {
+		var req *http.Request
+		req, err = c.newRequest(ctx, method, fullURL, jsonBody)
+		if err != nil {
+			return err
+		}
+		resp, respBody, err = c.send(req)
+		if attempt >= c.retry.MaxAttempts {
+			break
+		}
+		reason, ok := c.retry.retryReason(method, resp, err)
+		if !ok {
+			break
+		}
+		delay, ok := c.retry.delay(attempt, resp)
+		if !ok {
+			slog.Debug("Not retrying request, server asked to wait too long", "method", method, "path", path, "retry-after", delay)
+			break
+		}
+		slog.Debug("Retrying request", "method", method, "path", path, "reason", reason,
+			"attempt", attempt+1, "max-attempts", c.retry.MaxAttempts, "delay", delay)
 
-	// Set headers
-	if c.token != "" {
-		req.Header.Set("Authorization", c.token)
-	}
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	req.Header.Set("Accept", "application/json")
-	if c.userAgent != "" {
-		req.Header.Set("User-Agent", c.userAgent)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("request failed: %w", ctx.Err())
+		case <-timer.C:
+		}
 	}
-
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return err
 	}
 
 	// Handle error responses
@@ -137,6 +193,8 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		return &APIError{
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
+			Method:     method,
+			Path:       path,
 		}
 	}
 
@@ -155,10 +213,58 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	return nil
 }
 
-// APIError represents an API error response
+// newRequest builds a request with the client headers. A new request is built
+// for every attempt so the body can be read again.
+func (c *Client) newRequest(ctx context.Context, method, fullURL string, jsonBody []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if jsonBody != nil {
+		bodyReader = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	if token := c.authToken(); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	if jsonBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return req, nil
+}
+
+// send makes a single attempt of a request and reads the whole response body
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return resp, respBody, nil
+}
+
+// APIError represents an API error response. Use errors.Is with ErrNotFound,
+// ErrForbidden and the other status errors to tell failures apart.
 type APIError struct {
 	StatusCode int
 	Message    string
+	// Method and Path identify the failed request. Path is relative to the base URL.
+	Method string
+	Path   string
 }
 
 // Error implements the error interface
@@ -166,6 +272,11 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
 }
 
+// Unwrap returns the status error matching the status code, or nil
+func (e *APIError) Unwrap() error {
+	return statusErrors[e.StatusCode]
+}
+
 // buildPath constructs a URL path with path parameters
 func buildPath(template string, params map[string]string) string {
 	if len(params) == 0 {
@@ -177,3 +288,11 @@ func buildPath(template string, params map[string]string) string {
 	}
 	return strings.NewReplacer(replacements...).Replace(template)
 }
+
+// withQuery appends query parameters to a path
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
@@ -1,6 +1,14 @@
 package client
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"hotaisle-cli/test"
+)
 
 func TestBuildPath(t *testing.T) {
 	tests := []struct {
@@ -121,6 +129,15 @@ func TestBuildPath(t *testing.T) {
 	}
 }
 
+func TestWithQuery(t *testing.T) {
+	if got := withQuery("/teams/my-team/", url.Values{}); got != "/teams/my-team/" {
+		t.Errorf("withQuery() = %q, want no query", got)
+	}
+	if got := withQuery("/teams/my-team/", url.Values{"force": {"true"}}); got != "/teams/my-team/?force=true" {
+		t.Errorf("withQuery() = %q, want /teams/my-team/?force=true", got)
+	}
+}
+
 // Benchmark to compare performance
 func BenchmarkBuildPath(b *testing.B) {
 	params := map[string]string{
@@ -150,3 +167,64 @@ func BenchmarkBuildPathManyParams(b *testing.B) {
 		buildPath(template, params)
 	}
 }
+
+func TestWithTokenFunc(t *testing.T) {
+	calls := 0
+	var got []string
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		got = append(got, req.Header.Get("Authorization"))
+		return test.NewJSONResponse(t, http.StatusOK, User{}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithTokenFunc(func() string {
+		calls++
+		return "lazy-token"
+	}))
+	if calls != 0 {
+		t.Fatalf("token function called %d times before any request", calls)
+	}
+
+	for range 2 {
+		if _, err := c.User().Get(context.Background()); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if calls != 1 || len(got) != 2 || got[0] != "lazy-token" || got[1] != "lazy-token" {
+		t.Errorf("token function called %d times, sent %v, want 1 call and lazy-token twice", calls, got)
+	}
+
+	c.SetToken("set-token")
+	if _, err := c.User().Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got[2] != "set-token" {
+		t.Errorf("sent %q after SetToken, want set-token", got[2])
+	}
+}
+
+func TestDeleteWithForce(t *testing.T) {
+	var got []string
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		got = append(got, req.URL.RequestURI())
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithToken("token"))
+
+	ctx := context.Background()
+	if err := c.VirtualMachines().Delete(ctx, "my-team", "vm-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := c.VirtualMachines().DeleteWithForce(ctx, "my-team", "vm-1", true); err != nil {
+		t.Fatalf("DeleteWithForce() error = %v", err)
+	}
+	if err := c.BareMetal().DeleteWithForce(ctx, "my-team", "s-1", false); err != nil {
+		t.Fatalf("DeleteWithForce() error = %v", err)
+	}
+	want := []string{
+		"/teams/my-team/virtual_machines/vm-1/",
+		"/teams/my-team/virtual_machines/vm-1/?force=true",
+		"/teams/my-team/bare_metal/s-1/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+}
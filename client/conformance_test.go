@@ -0,0 +1,354 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"hotaisle-cli/test"
+)
+
+// swaggerSpec is the part of swagger.json the conformance tests check against
+type swaggerSpec struct {
+	Definitions map[string]*swaggerSchema               `json:"definitions"`
+	Paths       map[string]map[string]*swaggerOperation `json:"paths"`
+}
+
+type swaggerSchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Format     string                    `json:"format"`
+	Properties map[string]*swaggerSchema `json:"properties"`
+	Items      *swaggerSchema            `json:"items"`
+	AllOf      []*swaggerSchema          `json:"allOf"`
+	Example    any                       `json:"example"`
+}
+
+type swaggerOperation struct {
+	OperationID string `json:"operationId"`
+	Parameters  []struct {
+		In     string         `json:"in"`
+		Schema *swaggerSchema `json:"schema"`
+	} `json:"parameters"`
+	Responses map[string]struct {
+		Schema *swaggerSchema `json:"schema"`
+	} `json:"responses"`
+
+	method, path string
+}
+
+func (op *swaggerOperation) body() *swaggerSchema {
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+// response returns the schema of the first successful response with a body
+func (op *swaggerOperation) response() *swaggerSchema {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") && op.Responses[code].Schema != nil {
+			return op.Responses[code].Schema
+		}
+	}
+	return nil
+}
+
+func loadSwagger(t *testing.T) *swaggerSpec {
+	t.Helper()
+	data, err := os.ReadFile("../swagger.json")
+	if err != nil {
+		t.Fatalf("read swagger.json: %v", err)
+	}
+	var spec swaggerSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("parse swagger.json: %v", err)
+	}
+	for path, methods := range spec.Paths {
+		for method, op := range methods {
+			op.method, op.path = strings.ToUpper(method), path
+		}
+	}
+	return &spec
+}
+
+// apiCall is a call of a service method, and the request it sent
+type apiCall struct {
+	name   string
+	fn     reflect.Value
+	method string
+	path   string
+	body   []byte
+}
+
+// callServiceMethods calls every method of every service of the client with
+// placeholder arguments, and records the request each one sends. The string
+// arguments, which are the path parameters, are p0, p1 and so on.
+func callServiceMethods(t *testing.T) []*apiCall {
+	t.Helper()
+	var current *apiCall
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		current.method = req.Method
+		current.path = strings.TrimPrefix(req.URL.EscapedPath(), "/api")
+		if req.Body != nil {
+			current.body, _ = io.ReadAll(req.Body)
+		}
+		return test.NewEmptyResponse(http.StatusNoContent), nil
+	})
+	c := NewClient(WithBaseURL("http://api.test/api"), WithHTTPClient(httpClient), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+
+	var calls []*apiCall
+	clientValue := reflect.ValueOf(c)
+	for i := 0; i < clientValue.NumMethod(); i++ {
+		accessor := clientValue.Type().Method(i)
+		if accessor.Type.NumIn() != 1 || accessor.Type.NumOut() != 1 || !strings.HasSuffix(accessor.Type.Out(0).String(), "Service") {
+			continue
+		}
+		service := clientValue.Method(i).Call(nil)[0]
+		for j := 0; j < service.NumMethod(); j++ {
+			fn := service.Method(j)
+			args, ok := placeholderArgs(fn.Type())
+			if !ok {
+				// Helpers like the waiters take callbacks and call other methods
+				continue
+			}
+			current = &apiCall{name: accessor.Name + "()." + service.Type().Method(j).Name, fn: fn}
+			fn.Call(args)
+			if current.method == "" {
+				t.Errorf("%s did not send a request", current.name)
+				continue
+			}
+			calls = append(calls, current)
+		}
+	}
+	return calls
+}
+
+func placeholderArgs(fn reflect.Type) ([]reflect.Value, bool) {
+	if fn.NumIn() == 0 || fn.In(0) != reflect.TypeFor[context.Context]() {
+		return nil, false
+	}
+	args := []reflect.Value{reflect.ValueOf(context.Background())}
+	strings := 0
+	for i := 1; i < fn.NumIn(); i++ {
+		switch in := fn.In(i); in.Kind() {
+		case reflect.String:
+			args = append(args, reflect.ValueOf(fmt.Sprintf("p%d", strings)))
+			strings++
+		case reflect.Bool, reflect.Struct:
+			args = append(args, reflect.Zero(in))
+		default:
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// findOperation returns the operation whose method and path template match a
+// request, preferring templates with more literal segments
+func findOperation(spec *swaggerSpec, method, path string) *swaggerOperation {
+	var (
+		best         *swaggerOperation
+		bestLiterals = -1
+	)
+	for template, methods := range spec.Paths {
+		op, ok := methods[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		if literals, ok := matchPathTemplate(template, path); ok && literals > bestLiterals {
+			best, bestLiterals = op, literals
+		}
+	}
+	return best
+}
+
+// TestServicesMatchSwagger checks that every operation of swagger.json has a
+// client method sending its method and path, with the path parameters in place
+func TestServicesMatchSwagger(t *testing.T) {
+	spec := loadSwagger(t)
+	covered := map[string]string{}
+	for _, call := range callServiceMethods(t) {
+		op := findOperation(spec, call.method, call.path)
+		if op == nil {
+			t.Errorf("%s sends %s %s, which is not in swagger.json", call.name, call.method, call.path)
+			continue
+		}
+		// Delete and DeleteWithForce call the same operation, with and
+		// without its query parameters
+		if other, ok := covered[op.OperationID]; ok && !strings.HasPrefix(call.name, other+"With") {
+			t.Errorf("%s and %s both call %s", other, call.name, op.OperationID)
+		}
+		covered[op.OperationID] = call.name
+
+		// The path parameters are passed in the order of the path
+		param := 0
+		templateParts := strings.Split(strings.Trim(op.path, "/"), "/")
+		pathParts := strings.Split(strings.Trim(call.path, "/"), "/")
+		for i, part := range templateParts {
+			if !strings.HasPrefix(part, "{") {
+				continue
+			}
+			if want := fmt.Sprintf("p%d", param); pathParts[i] != want {
+				t.Errorf("%s puts %s in %s of %s, want argument %d", call.name, pathParts[i], part, op.OperationID, param+1)
+			}
+			param++
+		}
+		if hasBody := len(call.body) > 0; hasBody != (op.body() != nil) {
+			t.Errorf("%s sends a body: %t, but %s has a body parameter: %t", call.name, hasBody, op.OperationID, !hasBody)
+		}
+	}
+
+	for _, methods := range spec.Paths {
+		for _, op := range methods {
+			if _, ok := covered[op.OperationID]; !ok {
+				t.Errorf("%s (%s %s) has no client method", op.OperationID, op.method, op.path)
+			}
+		}
+	}
+}
+
+// TestModelsRoundTripSwaggerExamples decodes an example of every request and
+// response schema into the type of the client method, and checks that encoding
+// it again gives back the same JSON: no property is lost to a missing or
+// misspelled field, and no field is sent that the schema does not have
+func TestModelsRoundTripSwaggerExamples(t *testing.T) {
+	spec := loadSwagger(t)
+	for _, call := range callServiceMethods(t) {
+		op := findOperation(spec, call.method, call.path)
+		if op == nil {
+			continue
+		}
+		fnType := call.fn.Type()
+		if body := op.body(); body != nil {
+			for i := 1; i < fnType.NumIn(); i++ {
+				if fnType.In(i).Kind() == reflect.Struct {
+					checkRoundTrip(t, call.name+" request", fnType.In(i), exampleOf(spec, body))
+				}
+			}
+		}
+		if response := op.response(); response != nil && fnType.NumOut() == 2 {
+			typ := fnType.Out(0)
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			checkRoundTrip(t, call.name+" response", typ, exampleOf(spec, response))
+		}
+	}
+}
+
+func checkRoundTrip(t *testing.T, name string, typ reflect.Type, example any) {
+	t.Helper()
+	data, err := json.Marshal(example)
+	if err != nil {
+		t.Fatalf("%s: marshal example: %v", name, err)
+	}
+	value := reflect.New(typ)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		t.Errorf("%s: the example does not decode into %s: %v", name, typ, err)
+		return
+	}
+	encoded, err := json.Marshal(value.Interface())
+	if err != nil {
+		t.Fatalf("%s: marshal %s: %v", name, typ, err)
+	}
+	var want, got any
+	_ = json.Unmarshal(data, &want)
+	_ = json.Unmarshal(encoded, &got)
+	for _, diff := range jsonDiff("", want, got) {
+		t.Errorf("%s (%s): %s", name, typ, diff)
+	}
+}
+
+// exampleOf builds a payload of a schema with every property set, from the
+// examples of swagger.json or placeholder values
+func exampleOf(spec *swaggerSpec, s *swaggerSchema) any {
+	if s.Ref != "" {
+		return exampleOf(spec, spec.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")])
+	}
+	if s.Example != nil && !reflect.ValueOf(s.Example).IsZero() {
+		return s.Example
+	}
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, part := range s.AllOf {
+			for k, v := range exampleOf(spec, part).(map[string]any) {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	switch s.Type {
+	case "array":
+		return []any{exampleOf(spec, s.Items)}
+	case "string":
+		if s.Format == "date-time" {
+			return "2024-01-02T03:04:05Z"
+		}
+		return "example"
+	case "integer", "number":
+		return 7
+	case "boolean":
+		return true
+	}
+	object := map[string]any{}
+	for name, p := range s.Properties {
+		object[name] = exampleOf(spec, p)
+	}
+	return object
+}
+
+// jsonDiff describes how two decoded JSON values differ
+func jsonDiff(path string, want, got any) []string {
+	wantObject, wantIsObject := want.(map[string]any)
+	gotObject, gotIsObject := got.(map[string]any)
+	if wantIsObject && gotIsObject {
+		var diffs []string
+		for k, v := range wantObject {
+			if g, ok := gotObject[k]; ok {
+				diffs = append(diffs, jsonDiff(path+"."+k, v, g)...)
+			} else {
+				diffs = append(diffs, fmt.Sprintf("%s.%s is lost", path, k))
+			}
+		}
+		for k := range gotObject {
+			if _, ok := wantObject[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s.%s is not in the schema", path, k))
+			}
+		}
+		sort.Strings(diffs)
+		return diffs
+	}
+	wantArray, wantIsArray := want.([]any)
+	gotArray, gotIsArray := got.([]any)
+	if wantIsArray && gotIsArray && len(wantArray) == len(gotArray) {
+		var diffs []string
+		for i := range wantArray {
+			diffs = append(diffs, jsonDiff(fmt.Sprintf("%s[%d]", path, i), wantArray[i], gotArray[i])...)
+		}
+		return diffs
+	}
+	if !reflect.DeepEqual(want, got) {
+		wantJSON, _ := json.Marshal(want)
+		gotJSON, _ := json.Marshal(got)
+		if !bytes.Equal(wantJSON, gotJSON) {
+			return []string{fmt.Sprintf("%s is %s, want %s", path, gotJSON, wantJSON)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// WithTimeout sets how long a request may take, its retries included. It also
+// limits every attempt of the default HTTP client, instead of DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithProxy sends the requests of the default HTTP client through a proxy,
+// instead of the one of the HTTPS_PROXY and NO_PROXY environment variables
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxy = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default HTTP client, such
+// as one made by LoadTLSConfig
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config
+	}
+}
+
+// LoadTLSConfig returns a TLS configuration that trusts the certificates of a
+// PEM CA bundle on top of the system ones, and presents a client certificate
+// for mutual TLS. Empty paths leave out the bundle or the client certificate,
+// whose certificate and key files must be given together.
+func LoadTLSConfig(caBundle, clientCert, clientKey string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundle)
+		}
+		config.RootCAs = pool
+	}
+
+	if (clientCert == "") != (clientKey == "") {
+		return nil, errors.New("a client certificate and its key must be given together")
+	}
+	if clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// newHTTPClient returns the default HTTP client, with the timeout, proxy and
+// TLS configuration of the options
+func (c *Client) newHTTPClient() *http.Client {
+	timeout := DefaultTimeout
+	if c.timeout > 0 {
+		timeout = c.timeout
+	}
+	proxy := http.ProxyFromEnvironment
+	if c.proxy != nil {
+		proxy = http.ProxyURL(c.proxy)
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               proxy,
+			TLSClientConfig:     c.tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        20,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     30 * time.Second,
+		},
+	}
+}
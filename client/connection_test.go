@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePEM writes PEM blocks to a file of the test directory
+func writePEM(t *testing.T, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	var b strings.Builder
+	for _, block := range blocks {
+		if err := pem.Encode(&b, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newClientCert creates a self-signed client certificate and returns the
+// paths of its certificate and key files
+func newClientCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hotaisle-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "client.crt", &pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		writePEM(t, "client.key", &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	var clientCerts int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCerts = len(r.TLS.PeerCertificates)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caBundle := writePEM(t, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	certFile, keyFile := newClientCert(t)
+
+	// The server certificate is not trusted without the bundle
+	c := NewClient(WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err := c.doRequest(context.Background(), http.MethodGet, "/", nil, nil); err == nil {
+		t.Fatal("expected an unknown authority error without the CA bundle")
+	}
+
+	tlsConfig, err := LoadTLSConfig(caBundle, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig: %v", err)
+	}
+	c = NewClient(WithBaseURL(srv.URL), WithTLSConfig(tlsConfig))
+	if err := c.doRequest(context.Background(), http.MethodGet, "/", nil, nil); err != nil {
+		t.Fatalf("request with the CA bundle failed: %v", err)
+	}
+	if clientCerts != 1 {
+		t.Errorf("server got %d client certificates, want 1", clientCerts)
+	}
+}
+
+func TestLoadTLSConfigErrors(t *testing.T) {
+	certFile, keyFile := newClientCert(t)
+	notPEM := filepath.Join(t.TempDir(), "bundle.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                 string
+		ca, cert, key, error string
+	}{
+		{name: "missing bundle", ca: filepath.Join(t.TempDir(), "missing.pem"), error: "failed to read CA bundle"},
+		{name: "bundle without certificates", ca: notPEM, error: "no certificates found"},
+		{name: "certificate without key", cert: certFile, error: "must be given together"},
+		{name: "key without certificate", key: keyFile, error: "must be given together"},
+		{name: "key of the wrong file", cert: certFile, key: certFile, error: "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTLSConfig(tt.ca, tt.cert, tt.key)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("LoadTLSConfig() error = %v, want %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	c := NewClient(WithBaseURL("http://api.invalid/api"), WithProxy(proxyURL))
+	if err := c.doRequest(context.Background(), http.MethodGet, "/user/", nil, nil); err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	if proxied != "http://api.invalid/api/user/" {
+		t.Errorf("proxy got %q, want the absolute URL of the request", proxied)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL), WithTimeout(50*time.Millisecond))
+	if c.httpClient.Timeout != 50*time.Millisecond {
+		t.Errorf("HTTP client timeout = %v, want 50ms", c.httpClient.Timeout)
+	}
+	start := time.Now()
+	if err := c.doRequest(context.Background(), http.MethodGet, "/", nil, nil); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, longer than its timeout and retries", elapsed)
+	}
+
+	if c := NewClient(); c.httpClient.Timeout != DefaultTimeout {
+		t.Errorf("default HTTP client timeout = %v, want %v", c.httpClient.Timeout, DefaultTimeout)
+	}
+}
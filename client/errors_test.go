@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"hotaisle-cli/test"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{status: http.StatusUnauthorized, want: ErrUnauthorized},
+		{status: http.StatusForbidden, want: ErrForbidden},
+		{status: http.StatusNotFound, want: ErrNotFound},
+		{status: http.StatusConflict, want: ErrConflict},
+		{status: http.StatusUnprocessableEntity, want: ErrUnprocessable},
+		{status: http.StatusTooManyRequests, want: ErrRateLimited},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: tt.status})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: errors.Is(err, %v) = false", tt.status, tt.want)
+		}
+		if errors.Is(err, ErrConflict) != (tt.want == ErrConflict) {
+			t.Errorf("status %d should only match its own error", tt.status)
+		}
+	}
+
+	if errors.Unwrap(&APIError{StatusCode: http.StatusInternalServerError}) != nil {
+		t.Error("a 500 should not wrap a status error")
+	}
+}
+
+func TestAPIErrorRecordsRequest(t *testing.T) {
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		return test.NewJSONResponse(t, http.StatusForbidden, "Forbidden: Permission denied"), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	err := c.VirtualMachines().Start(context.Background(), "my-team", "vm-1")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Start() error = %v, want *APIError", err)
+	}
+	if apiErr.Method != http.MethodPost || apiErr.Path != "/teams/my-team/virtual_machines/vm-1/start/" {
+		t.Errorf("APIError request = %s %s", apiErr.Method, apiErr.Path)
+	}
+	if !errors.Is(err, ErrForbidden) {
+		t.Error("Start() error should match ErrForbidden")
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"hotaisle-cli/internal/log"
+)
+
+// sensitiveFields are the JSON fields whose values are never logged, such as
+// the token of a new API key
+var sensitiveFields = []string{"token"}
+
+// LoggingTransport is an http.RoundTripper that logs every request. At the
+// debug level it logs the method, path, status and latency, and at the trace
+// level it adds the headers and bodies, with secrets redacted. Install it with
+// WithLogger.
+type LoggingTransport struct {
+	// Logger receives the log records
+	Logger *slog.Logger
+	// Next sends the requests, or http.DefaultTransport if nil
+	Next http.RoundTripper
+}
+
+// WithLogger logs the requests of the client with a LoggingTransport wrapped
+// around the transport of its HTTP client
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ctx := req.Context()
+	if !t.Logger.Enabled(ctx, log.LevelDebug) {
+		return next.RoundTrip(req)
+	}
+	trace := t.Logger.Enabled(ctx, log.LevelTrace)
+
+	var attrs []any
+	if trace {
+		body, err := requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, "request-headers", redactHeader(req.Header), "request-body", redactBody(body))
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	latency := time.Since(start)
+	attrs = append([]any{"method", req.Method, "path", req.URL.Path}, attrs...)
+	if err != nil {
+		t.Logger.Log(ctx, log.LevelDebug, "HTTP request failed", append(attrs, "latency", latency, "error", err)...)
+		return nil, err
+	}
+	attrs = append(attrs, "status", resp.StatusCode, "latency", latency)
+
+	if !trace {
+		t.Logger.Log(ctx, log.LevelDebug, "HTTP request", attrs...)
+		return resp, nil
+	}
+	attrs = append(attrs, "response-headers", redactHeader(resp.Header))
+	// The body of an upgraded connection, such as the WebSocket of a console,
+	// is the connection itself: reading it would block until it is closed
+	if resp.StatusCode == http.StatusSwitchingProtocols || req.Header.Get("Upgrade") != "" {
+		t.Logger.Log(ctx, log.LevelTrace, "HTTP request", attrs...)
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		t.Logger.Log(ctx, log.LevelDebug, "HTTP request failed", append(attrs, "error", err)...)
+		return nil, err
+	}
+	attrs = append(attrs, "response-body", redactBody(body))
+	t.Logger.Log(ctx, log.LevelTrace, "HTTP request", attrs...)
+	return resp, nil
+}
+
+// requestBody returns the body of a request without consuming it
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return body, err
+	}
+	r, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
+
+// redactBody returns a body for logging, with the sensitive fields of JSON
+// bodies redacted
+func redactBody(body []byte) string {
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return string(body)
+	}
+	if !redactFields(v) {
+		return string(body)
+	}
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return redacted
+	}
+	return string(redactedBody)
+}
+
+// redactFields replaces the values of the sensitive fields at any depth of a
+// decoded JSON value, reporting whether it found any
+func redactFields(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if slices.Contains(sensitiveFields, key) {
+				if value != nil && value != "" {
+					v[key] = redacted
+					found = true
+				}
+				continue
+			}
+			found = redactFields(value) || found
+		}
+	case []any:
+		for _, value := range v {
+			found = redactFields(value) || found
+		}
+	}
+	return found
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"hotaisle-cli/internal/log"
+	"hotaisle-cli/test"
+)
+
+// newLoggedClient returns a client logging at a level into a buffer, whose API
+// answers every request with a new API key
+func newLoggedClient(t *testing.T, level slog.Level) (*Client, *strings.Builder) {
+	t.Helper()
+	var out strings.Builder
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: level}))
+	httpClient := test.NewMockClient(test.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := test.NewJSONResponse(t, http.StatusCreated, UserAPIKeyWithToken{
+			UserAPIKey: UserAPIKey{Prefix: "abc"},
+			Token:      "abc.secret-api-key",
+		})
+		resp.Header.Set("Set-Cookie", "session=secret-cookie")
+		return resp, nil
+	}))
+	c := NewClient(WithHTTPClient(httpClient), WithToken("secret-token"), WithLogger(logger))
+	return c, &out
+}
+
+func TestLoggingTransport_Debug(t *testing.T) {
+	c, out := newLoggedClient(t, log.LevelDebug)
+
+	key, err := c.User().CreateAPIKey(context.Background(), UserAPIKeyRequest{Label: "ci"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if key.Token != "abc.secret-api-key" {
+		t.Errorf("Token = %q, the response body was not passed on", key.Token)
+	}
+
+	got := out.String()
+	for _, want := range []string{`msg="HTTP request"`, "method=POST", "path=/api/user/api_keys/", "status=201", "latency="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "request-headers") || strings.Contains(got, "response-body") {
+		t.Errorf("log %q has trace details at the debug level", got)
+	}
+}
+
+func TestLoggingTransport_Trace(t *testing.T) {
+	c, out := newLoggedClient(t, log.LevelTrace)
+
+	if _, err := c.User().CreateAPIKey(context.Background(), UserAPIKeyRequest{Label: "ci"}); err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"Authorization:[REDACTED]", `request-body="{\"label\":\"ci\"`, `\"token\":\"REDACTED\"`, `\"prefix\":\"abc\"`, "Set-Cookie:[REDACTED]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+	for _, secret := range []string{"secret-token", "secret-api-key", "secret-cookie"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("log %q contains the secret %q", got, secret)
+		}
+	}
+}
+
+func TestLoggingTransport_Disabled(t *testing.T) {
+	c, out := newLoggedClient(t, log.LevelInfo)
+
+	if _, err := c.User().CreateAPIKey(context.Background(), UserAPIKeyRequest{Label: "ci"}); err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("logged %q at the info level", out.String())
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", ""},
+		{"not JSON", "bad gateway", "bad gateway"},
+		{"no token", `{"name":"ci"}`, `{"name":"ci"}`},
+		{"empty token", `{"token":""}`, `{"token":""}`},
+		{"nested", `[{"prefix":"a","token":"a.1"},{"key":{"token":"b.2"}}]`, `[{"prefix":"a","token":"REDACTED"},{"key":{"token":"REDACTED"}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("redactBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,318 +1,495 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
 package client
 
 import "time"
 
-// User represents a registered user in the system
-type User struct {
-	Name    string    `json:"name"`
-	Email   string    `json:"email"`
-	Created time.Time `json:"created"`
+// APIKeyTeam represents a team that the API Key has access to
+type APIKeyTeam struct {
+	Team
+	// The complete list of roles this API Key has on the team
+	Roles []string `json:"roles"`
 }
 
-// UserUpdate represents the updateable fields for a user
-type UserUpdate struct {
+// AvailableBareMetalTypes is how many bare metal servers of a given type are available to be reserved
+type AvailableBareMetalTypes struct {
+	// Quantity of this server type available for reservation
+	Quantity int64 `json:"Quantity"`
+	// Minimum reservation time in minutes
+	MinimumReservationMinutes int64 `json:"MinimumReservationMinutes"`
+	// Price per hour for on-demand usage in US Cents
+	OnDemandPrice int64                `json:"OnDemandPrice,omitempty"`
+	Specs         BareMetalServerSpecs `json:"Specs,omitempty"`
+}
+
+// AvailableVirtualMachineTypes is how many virtual machines of a given type are available to be deployed
+type AvailableVirtualMachineTypes struct {
+	// Quantity of this VM Type available for deployment
+	Quantity int64 `json:"Quantity"`
+	// Minimum reservation time in minutes
+	MinimumReservationMinutes int64 `json:"MinimumReservationMinutes"`
+	// Price per hour for on-demand usage in US Cents
+	OnDemandPrice int64               `json:"OnDemandPrice,omitempty"`
+	Specs         VirtualMachineSpecs `json:"Specs,omitempty"`
+}
+
+// BalanceInfo contains balance and estimated time until depletion
+type BalanceInfo struct {
+	// Available balance in US cents (100 = $1.00)
+	AvailableBalance int64 `json:"available_balance"`
+	// Total hourly cost of all active resources in US cents (100 = $1.00/hour)
+	HourlyRate int64 `json:"hourly_rate"`
+	// Number of active virtual machines consuming balance
+	VirtualMachineCount int64 `json:"virtual_machine_count"`
+	// Number of active bare metal servers consuming balance
+	BareMetalServerCount int64 `json:"bare_metal_server_count"`
+	// Estimated time when balance will be completely depleted based on current resource usage
+	EstimatedRunoutTime *time.Time `json:"estimated_runout_time,omitempty"`
+	// Minimum balance threshold in US cents (100 = $1.00). Only included when non-zero.
+	MinimumBalance int64 `json:"minimum_balance,omitempty"`
+}
+
+// BareMetalServer represents a bare metal server
+type BareMetalServer struct {
+	// Server Name
 	Name string `json:"name"`
+	// Server's primary IP address
+	IPAddress string `json:"ip_address"`
+	// Server manufacturer
+	Manufacturer string `json:"manufacturer"`
+	// Server model
+	Model string `json:"model"`
+	// Server description
+	Description string           `json:"description,omitempty"`
+	SSHAccess   *ExternalService `json:"ssh_access,omitempty"`
+	// Indicates if Hot Aisle Support is permitted to login to this server
+	SupportAccessEnabled bool `json:"support_access_enabled,omitempty"`
 }
 
-// GetUserResponse represents information about the authenticated user and their teams
-type GetUserResponse struct {
-	User  User       `json:"user"`
-	Teams []UserTeam `json:"teams"`
+// BareMetalServerConsoleURL has a temporary URL that can be used to access a local console
+type BareMetalServerConsoleURL struct {
+	// URL for console access
+	URL string `json:"url"`
 }
 
-// Team represents a team
-type Team struct {
-	Handle                  string `json:"handle"`
-	Name                    string `json:"name"`
-	Description             string `json:"description,omitempty"`
-	MaximumVirtualMachines  int64  `json:"maximum_virtual_machines,omitempty"`
-	MaximumBareMetalServers int64  `json:"maximum_bare_metal_servers,omitempty"`
+// BareMetalServerDetails represents a bare metal server with detailed hardware specifications
+type BareMetalServerDetails struct {
+	BareMetalServer
+	BareMetalServerSpecs
+	OSStatus *BareMetalServerlOSStatus `json:"os_status,omitempty"`
 }
 
-// TeamUpdate represents a team update request
-type TeamUpdate struct {
-	Handle      string `json:"handle"`
-	Name        string `json:"name"`
+// BareMetalServerPowerState represents the current power status of a server
+type BareMetalServerPowerState struct {
+	// The current power state of the server reported by iDrac.
+	// Values can be "On" or "Off".
+	State string `json:"state"`
+}
+
+// BareMetalServerReservation represents a request to reserve a bare metal server
+type BareMetalServerReservation struct {
+	Specs BareMetalServerSpecs `json:"specs"`
+	// Server description
 	Description string `json:"description,omitempty"`
 }
 
-// UserTeam represents a team that the user belongs to
-type UserTeam struct {
-	Team
-	Roles          []string `json:"roles"`
-	EffectiveRoles []string `json:"effective_roles"`
-	Invitation     bool     `json:"invitation,omitempty"`
+// BareMetalServerReservationResponse represents the response after successfully reserving a server
+type BareMetalServerReservationResponse struct {
+	BareMetalServer
+	// Total CPU Cores
+	CPUCores uint64 `json:"cpu_cores"`
+	// Total RAM in bytes
+	RAMCapacity uint64 `json:"ram_capacity"`
+	// Total Disk in bytes
+	DiskCapacity uint64 `json:"disk_capacity"`
+	// Detailed CPU information
+	CPUs []CPUs `json:"cpus,omitempty"`
+	// Detailed disk information
+	Disks []Disks `json:"disks,omitempty"`
+	// Detailed GPU information if present
+	GPUs []GPUs `json:"gpus,omitempty"`
+	// Detailed memory module information
+	MemoryModules []MemoryModules           `json:"memory_modules,omitempty"`
+	OSStatus      *BareMetalServerlOSStatus `json:"os_status,omitempty"`
 }
 
-// UserTeamWithMembers represents a team with detailed member information
-type UserTeamWithMembers struct {
-	UserTeam
-	Members []TeamMember `json:"members,omitempty"`
+// BareMetalServerSpecs contains the hardware specifications of a server
+type BareMetalServerSpecs struct {
+	// Total CPU Cores
+	CPUCores uint64 `json:"cpu_cores"`
+	// Total RAM in bytes
+	RAMCapacity uint64 `json:"ram_capacity"`
+	// Total Disk in bytes
+	DiskCapacity uint64 `json:"disk_capacity"`
+	// Detailed CPU information
+	CPUs []CPUs `json:"cpus,omitempty"`
+	// Detailed disk information
+	Disks []Disks `json:"disks,omitempty"`
+	// Detailed GPU information if present
+	GPUs []GPUs `json:"gpus,omitempty"`
+	// Detailed memory module information
+	MemoryModules []MemoryModules `json:"memory_modules,omitempty"`
 }
 
-// UserTeamDetails represents a team with detailed information including members and resources
-type UserTeamDetails struct {
-	UserTeamWithMembers
-	BareMetalServers []BareMetalServer `json:"bare_metal_servers,omitempty"`
-	VirtualMachines  []VirtualMachine  `json:"virtual_machines,omitempty"`
+// BareMetalServerUpdate represents a bare metal server
+type BareMetalServerUpdate struct {
+	// Bare Metal Server description
+	Description string `json:"description,omitempty"`
 }
 
-// TeamMember represents a team member or pending invitation
-type TeamMember struct {
-	Name       string    `json:"name"`
-	Email      string    `json:"email"`
-	Created    time.Time `json:"created"`
-	Roles      []string  `json:"roles"`
-	Invitation bool      `json:"invitation,omitempty"`
+// BareMetalServerlOSStatus represents the current state of OS installation on a server
+type BareMetalServerlOSStatus struct {
+	// OS Selection
+	OSSelection string `json:"os_selection"`
+	// OS Install Status
+	//
+	// The current stage in the OS installation process.
+	// Progresses through various states from "shutting_down" through "installing_os" to "installed" or "failed" if an error occurs
+	OSStatus string `json:"os_install_status"`
+	// Last Imaging Update Timestamp
+	//
+	// The date and time when the OS installation status was last updated
+	// Can be used to track progress and determine if the installation process is active
+	LastImagingUpdate time.Time `json:"last_imaging_update"`
 }
 
-// TeamMemberUpdate represents the data needed to update a team member's roles
-type TeamMemberUpdate struct {
-	Roles []string `json:"roles"`
+// CPUs represents processor information
+type CPUs struct {
+	Components
+	// Cores per processor
+	Cores uint64 `json:"cores"`
+	// CPU Frequency in Hertz
+	Frequency uint64 `json:"frequency"`
 }
 
-// TeamInvitationRequest contains the information needed to invite a user to a team
-type TeamInvitationRequest struct {
-	Name  string   `json:"name"`
-	Email string   `json:"email"`
-	Roles []string `json:"roles"`
+// Components represents common attributes for hardware components
+type Components struct {
+	// Number of components of this type
+	Count uint64 `json:"count"`
+	// Component manufacturer
+	Manufacturer string `json:"manufacturer"`
+	// Component model
+	Model string `json:"model"`
 }
 
-// BalanceInfo contains balance and estimated time until depletion
-type BalanceInfo struct {
-	AvailableBalance     int64      `json:"available_balance"`
-	HourlyRate           int64      `json:"hourly_rate"`
-	BareMetalServerCount int64      `json:"bare_metal_server_count"`
-	VirtualMachineCount  int64      `json:"virtual_machine_count"`
-	EstimatedRunoutTime  *time.Time `json:"estimated_runout_time,omitempty"`
-	MinimumBalance       int64      `json:"minimum_balance,omitempty"`
+// Disks represents storage device information
+type Disks struct {
+	Components
+	// Disk type, NVMe or SATA-SSD
+	Type string `json:"type"`
+	// Capacity in bytes per disk
+	Capacity uint64 `json:"capacity"`
 }
 
-// PurchaseTeamCreditsRequest represents a request to purchase credits for a team
-type PurchaseTeamCreditsRequest struct {
-	Cents int64 `json:"cents"`
+// EmailCodeRequest contains the email address for requesting a sign-in code
+type EmailCodeRequest struct {
+	// User's email address
+	Email string `json:"email"`
 }
 
-// PurchaseTeamCreditsResponse represents the response to a credits purchase request
-type PurchaseTeamCreditsResponse struct {
-	CheckoutURL string    `json:"checkout_url"`
-	ExpiresAt   time.Time `json:"expires_at"`
+// ExternalService represents information about an externally accessible service
+type ExternalService struct {
+	// Public IP address for service access
+	IPAddress string `json:"ip_address"`
+	// Service port number
+	Port int64 `json:"port"`
+	// DNS hostname for the service if available
+	DNSName string `json:"dns_name,omitempty"`
 }
 
-// SSHKey represents an SSH public key associated with a user
-type SSHKey struct {
-	Type        string `json:"type"`
-	PublicKey   string `json:"public_key"`
-	Fingerprint string `json:"fingerprint"`
-	Comment     string `json:"comment,omitempty"`
+// GPUs represents graphics processing unit information
+type GPUs struct {
+	// Number of GPUs of this type
+	Count uint64 `json:"count"`
+	// GPU manufacturer
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// GPU model
+	Model string `json:"model,omitempty"`
 }
 
-// SSHKeyRequest represents the data needed to add a new SSH key
-type SSHKeyRequest struct {
-	AuthorizedKey string `json:"authorized_key"`
+// GetUserResponse represents information about the authenticated user and their teams
+type GetUserResponse struct {
+	User User `json:"user"`
+	// Teams the user belongs to
+	Teams []UserTeam `json:"teams"`
 }
 
-// UserAPIKey represents a user's API key
-type UserAPIKey struct {
-	Prefix   string       `json:"prefix,omitempty"`
-	Label    string       `json:"label,omitempty"`
-	UserRole string       `json:"user_role"`
-	Teams    []APIKeyTeam `json:"teams,omitempty"`
+// MemoryModules represents memory module information
+type MemoryModules struct {
+	Components
+	// Capacity in bytes per module
+	Capacity uint64 `json:"capacity"`
 }
 
-// APIKeyTeam represents a team that the API Key has access to
-type APIKeyTeam struct {
-	Team
-	Roles []string `json:"roles"`
+// PurchaseTeamCreditsRequest represents a request to purchase credits for a team
+type PurchaseTeamCreditsRequest struct {
+	// The amount to purchase in US cents (100 = $1.00)
+	Cents int64 `json:"cents"`
 }
 
-// UserAPIKeyRequest represents the data needed to create or update an API key
-type UserAPIKeyRequest struct {
-	Label    string                `json:"label,omitempty"`
-	UserRole string                `json:"user_role,omitempty"`
-	Teams    []UserAPIKeyTeamRoles `json:"teams,omitempty"`
+// PurchaseTeamCreditsResponse represents the response to a credits purchase request
+type PurchaseTeamCreditsResponse struct {
+	// URL to the Stripe Checkout page where the customer can complete payment
+	CheckoutURL string `json:"checkout_url"`
+	// Expiration time of the checkout session
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
-// UserAPIKeyTeamRoles defines the permissions for an API key on a specific team
-type UserAPIKeyTeamRoles struct {
-	Team  string   `json:"team"`
-	Roles []string `json:"roles"`
+// RegistrationRequest contains the information needed to register a new user
+type RegistrationRequest struct {
+	// User's full name
+	Name string `json:"name"`
+	// User's email address
+	Email string `json:"email"`
 }
 
-// UserAPIKeyWithToken represents an API key with its full token value
-type UserAPIKeyWithToken struct {
-	UserAPIKey
-	Token string `json:"token,omitempty"`
+// RequestPaymentApprovalRequest represents a request for self-service payment approval
+type RequestPaymentApprovalRequest struct {
+	// Message from the requesting team explaining what they're building
+	Message string `json:"message"`
 }
 
-// Components represents common attributes for hardware components
-type Components struct {
-	Count        uint64 `json:"count"`
-	Manufacturer string `json:"manufacturer"`
-	Model        string `json:"model"`
+// SSHKey represents an SSH public key associated with a user
+type SSHKey struct {
+	// The type of SSH key (e.g., ssh-rsa, ssh-ed25519)
+	Type string `json:"type"`
+	// The base64-encoded public key portion
+	PublicKey string `json:"public_key"`
+	// The unique fingerprint of the SSH key
+	Fingerprint string `json:"fingerprint"`
+	// A descriptive comment or label for the key
+	Comment string `json:"comment,omitempty"`
 }
 
-// CPUs represents processor information
-type CPUs struct {
-	Components
-	Cores     uint64 `json:"cores"`
-	Frequency uint64 `json:"frequency"`
+// SSHKeyRequest represents the data needed to add a new SSH key
+type SSHKeyRequest struct {
+	// SSH public key in the authorized key format of <type> <public_key> <comment>
+	AuthorizedKey string `json:"authorized_key"`
 }
 
-// Disks represents storage device information
-type Disks struct {
-	Components
-	Type     string `json:"type"`
-	Capacity uint64 `json:"capacity"`
+// Session represents an active session with its associated API key information
+type Session struct {
+	// The unique prefix identifier for this session
+	Prefix string `json:"prefix"`
+	// When this session will expire
+	ExpiresAt time.Time `json:"expires_at"`
+	// When this session was created
+	Created time.Time `json:"created"`
+	// The associated API key prefix, if one exists
+	APIKeyPrefix string `json:"api_key_prefix,omitempty"`
 }
 
-// GPUs represents graphics processing unit information
-type GPUs struct {
-	Count        uint64 `json:"count"`
-	Manufacturer string `json:"manufacturer,omitempty"`
-	Model        string `json:"model,omitempty"`
+// Team represents a team
+type Team struct {
+	// Team's unique identifier handle
+	Handle string `json:"handle"`
+	// Team's display name
+	Name string `json:"name"`
+	// Team description
+	Description string `json:"description,omitempty"`
+	// Maximum number of bare metal servers this team can create
+	MaximumBareMetalServers int64 `json:"maximum_bare_metal_servers,omitempty"`
+	// Maximum number of virtual machines this team can create
+	MaximumVirtualMachines int64 `json:"maximum_virtual_machines,omitempty"`
 }
 
-// MemoryModules represents memory module information
-type MemoryModules struct {
-	Components
-	Capacity uint64 `json:"capacity"`
+// TeamInvitationRequest contains the information needed to invite a user to a team
+type TeamInvitationRequest struct {
+	// Invitee's full name
+	Name string `json:"name"`
+	// Invitee's email address
+	Email string `json:"email"`
+	// Roles to assign to the invitee
+	Roles []string `json:"roles"`
 }
 
-// ExternalService represents information about an externally accessible service
-type ExternalService struct {
-	IPAddress string `json:"ip_address"`
-	Port      int64  `json:"port"`
-	DNSName   string `json:"dns_name,omitempty"`
+// TeamMember represents a team member or pending invitation
+type TeamMember struct {
+	// User's full name
+	Name string `json:"name"`
+	// User's email address
+	Email string `json:"email"`
+	// When the user account was created
+	Created time.Time `json:"created"`
+	// The complete list of roles this user has on the team
+	Roles []string `json:"roles"`
+	// This team membership is a invite that has not yet been accepted
+	Invitation bool `json:"invitation,omitempty"`
 }
 
-// BareMetalServer represents a bare metal server
-type BareMetalServer struct {
-	Name                 string           `json:"name"`
-	IPAddress            string           `json:"ip_address"`
-	Manufacturer         string           `json:"manufacturer"`
-	Model                string           `json:"model"`
-	Description          string           `json:"description,omitempty"`
-	SSHAccess            *ExternalService `json:"ssh_access,omitempty"`
-	SupportAccessEnabled bool             `json:"support_access_enabled,omitempty"`
+// TeamMemberUpdate represents the data needed to update a team member's roles
+type TeamMemberUpdate struct {
+	// Updated roles for the team member
+	Roles []string `json:"roles"`
 }
 
-// BareMetalServerSpecs contains the hardware specifications of a server
-type BareMetalServerSpecs struct {
-	CPUCores      uint64          `json:"cpu_cores"`
-	RAMCapacity   uint64          `json:"ram_capacity"`
-	DiskCapacity  uint64          `json:"disk_capacity"`
-	CPUs          []CPUs          `json:"cpus,omitempty"`
-	Disks         []Disks         `json:"disks,omitempty"`
-	GPUs          []GPUs          `json:"gpus,omitempty"`
-	MemoryModules []MemoryModules `json:"memory_modules,omitempty"`
+// TeamUpdate represents a team
+type TeamUpdate struct {
+	// Team's unique identifier handle
+	Handle string `json:"handle"`
+	// Team's display name
+	Name string `json:"name"`
+	// Team description
+	Description string `json:"description,omitempty"`
 }
 
-// BareMetalServerlOSStatus represents the current state of OS installation on a server
-type BareMetalServerlOSStatus struct {
-	OSSelection       string    `json:"os_selection"`
-	OSStatus          string    `json:"os_install_status"`
-	LastImagingUpdate time.Time `json:"last_imaging_update"`
+// User represents a registered user in the system
+type User struct {
+	// User's full name
+	Name string `json:"name"`
+	// User's email address
+	Email string `json:"email"`
+	// When the user account was created
+	Created time.Time `json:"created"`
 }
 
-// BareMetalServerDetails represents a bare metal server with detailed hardware specifications
-type BareMetalServerDetails struct {
-	BareMetalServer
-	BareMetalServerSpecs
-	OSStatus *BareMetalServerlOSStatus `json:"os_status,omitempty"`
+// UserAPIKey represents a user's API key
+type UserAPIKey struct {
+	// The role this API Key has on the user
+	// Keys with a user role of 'owner' can modify the user, including changing name, leaving or accepting invites to teams, and creating api keys
+	UserRole string `json:"user_role"`
+	// A descriptive label for the API key
+	Label string `json:"label,omitempty"`
+	// The unique prefix of this API key (used for identification)
+	Prefix string `json:"prefix,omitempty"`
+	// Teams that this API key has access to and the roles on each team
+	Teams []APIKeyTeam `json:"teams,omitempty"`
 }
 
-// BareMetalServerUpdate represents a bare metal server update request
-type BareMetalServerUpdate struct {
-	Description string `json:"description,omitempty"`
+// UserAPIKeyRequest represents the data needed to create or update an API key
+type UserAPIKeyRequest struct {
+	// A descriptive label for the API key
+	Label string `json:"label,omitempty"`
+	// Teams and their associated permissions for this API key
+	Teams []UserAPIKeyTeamRoles `json:"teams,omitempty"`
+	// The role this API key has on the user
+	UserRole string `json:"user_role,omitempty"`
 }
 
-// BareMetalServerReservation represents a request to reserve a bare metal server
-type BareMetalServerReservation struct {
-	Description string               `json:"description,omitempty"`
-	Specs       BareMetalServerSpecs `json:"specs"`
+// UserAPIKeyTeamRoles defines the permissions for an API key on a specific team
+type UserAPIKeyTeamRoles struct {
+	// Team slug identifier
+	Team string `json:"team"`
+	// The roles assigned to this API key for the team wtf man
+	Roles []string `json:"roles"`
 }
 
-// BareMetalServerReservationResponse represents the response after successfully reserving a server
-type BareMetalServerReservationResponse struct {
-	BareMetalServer
-	CPUCores      uint64                    `json:"cpu_cores"`
-	RAMCapacity   uint64                    `json:"ram_capacity"`
-	DiskCapacity  uint64                    `json:"disk_capacity"`
-	CPUs          []CPUs                    `json:"cpus,omitempty"`
-	Disks         []Disks                   `json:"disks,omitempty"`
-	GPUs          []GPUs                    `json:"gpus,omitempty"`
-	MemoryModules []MemoryModules           `json:"memory_modules,omitempty"`
-	OSStatus      *BareMetalServerlOSStatus `json:"os_status,omitempty"`
+// UserAPIKeyWithToken represents an API key with its full token value
+// Only returned when creating a new API key
+type UserAPIKeyWithToken struct {
+	UserAPIKey
+	// The full token value. This is the only time the full token will be returned, as it is not stored plaintext on the server and cannot be recovered.
+	Token string `json:"token,omitempty"`
 }
 
-// BareMetalServerPowerState represents the current power status of a server
-type BareMetalServerPowerState struct {
-	State string `json:"state"`
+// UserEmailCodeResponse contains information about a sign-in code request
+type UserEmailCodeResponse struct {
+	// User's email address
+	Email string `json:"email"`
+	// When the sign-in code expires
+	CodeExpires time.Time `json:"code_expires"`
 }
 
-// BareMetalServerConsoleURL has a temporary URL that can be used to access a local console
-type BareMetalServerConsoleURL struct {
-	URL string `json:"url"`
+// UserRegistrationResponse contains information about a registration attempt
+type UserRegistrationResponse struct {
+	// User's full name
+	Name string `json:"name"`
+	// User's email address
+	Email string `json:"email"`
+	// When the sign-in code expires
+	CodeExpires time.Time `json:"code_expires"`
 }
 
-// AvailableBareMetalTypes is how many bare metal servers of a given type are available to be reserved
-type AvailableBareMetalTypes struct {
-	Quantity                  int64                `json:"Quantity"`
-	MinimumReservationMinutes int64                `json:"MinimumReservationMinutes"`
-	OnDemandPrice             int64                `json:"OnDemandPrice,omitempty"`
-	Specs                     BareMetalServerSpecs `json:"Specs,omitempty"`
+// UserTeam represents a team that the user belongs to
+type UserTeam struct {
+	Team
+	// The complete list of roles this user has on the team
+	Roles []string `json:"roles"`
+	// The list of effective roles that this authenticated request has, based on API Key restrictions
+	EffectiveRoles []string `json:"effective_roles"`
+	// This team membership is a invite that has not yet been accepted
+	Invitation bool `json:"invitation,omitempty"`
 }
 
-// VirtualMachine represents a virtual machine instance
-type VirtualMachine struct {
-	Name        string           `json:"name"`
-	IPAddress   string           `json:"ip_address"`
-	Description string           `json:"description,omitempty"`
-	SSHAccess   *ExternalService `json:"ssh_access,omitempty"`
+// UserTeamDetails represents a team with detailed information including members and resources
+type UserTeamDetails struct {
+	UserTeamWithMembers
+	// Bare metal servers
+	BareMetalServers []BareMetalServer `json:"bare_metal_servers,omitempty"`
+	// Virtual Machines
+	VirtualMachines []VirtualMachine `json:"virtual_machines,omitempty"`
 }
 
-// VirtualMachineSpecs contains the specifications of a virtual machine
-type VirtualMachineSpecs struct {
-	CPUCores     *uint64 `json:"cpu_cores,omitempty"`
-	RAMCapacity  *uint64 `json:"ram_capacity,omitempty"`
-	DiskCapacity *uint64 `json:"disk_capacity,omitempty"`
-	CPUs         *CPUs   `json:"cpus,omitempty"`
-	GPUs         []GPUs  `json:"gpus,omitempty"`
+// UserTeamWithMembers represents a team with detailed member information
+type UserTeamWithMembers struct {
+	UserTeam
+	// Team members
+	Members []TeamMember `json:"members,omitempty"`
+}
+
+// UserUpdate represents the updateable fields for a user
+type UserUpdate struct {
+	// User's full name
+	Name string `json:"name"`
 }
 
 // VMProvisionRequest represents a request to provision a virtual machine
 type VMProvisionRequest struct {
 	VirtualMachineSpecs
+	// Optional URL to custom cloud-init user-data
+	// When provided, the VM will be provisioned with this user-data applied
+	// Note: This will significantly increase provisioning time as the VM will be rebuilt
 	UserDataURL string `json:"user_data_url,omitempty"`
 }
 
 // VMResetRequest represents a request to reset/rebuild a virtual machine
 type VMResetRequest struct {
+	// Optional URL to custom cloud-init user-data
+	// When provided, the VM will be rebuilt with this user-data applied
 	UserDataURL string `json:"user_data_url,omitempty"`
 }
 
+// VirtualMachine represents a virtual machine instance
+type VirtualMachine struct {
+	// VM Name
+	Name string `json:"name"`
+	// VM's primary IP address
+	IPAddress string `json:"ip_address"`
+	// VM description
+	Description string           `json:"description,omitempty"`
+	SSHAccess   *ExternalService `json:"ssh_access,omitempty"`
+}
+
 // VirtualMachineDetails represents a virtual machine with detailed specifications
 type VirtualMachineDetails struct {
 	VirtualMachine
 	VirtualMachineSpecs
 }
 
-// VirtualMachineUpdate represents a virtual machine update request
-type VirtualMachineUpdate struct {
-	Description string `json:"description,omitempty"`
+// VirtualMachineSpecs contains the specifications of a virtual machine
+type VirtualMachineSpecs struct {
+	// Total CPU Cores
+	CPUCores *uint64 `json:"cpu_cores,omitempty"`
+	// Total RAM in bytes
+	RAMCapacity *uint64 `json:"ram_capacity,omitempty"`
+	// Total Disk in bytes
+	DiskCapacity *uint64 `json:"disk_capacity,omitempty"`
+	CPUs         *CPUs   `json:"cpus,omitempty"`
+	// GPU information if any GPUs are assigned
+	GPUs []GPUs `json:"gpus,omitempty"`
 }
 
 // VirtualMachineState represents the current state of a virtual machine
 type VirtualMachineState struct {
+	// The current state of the virtual machine.
+	// Values can include "running", "shut off", "paused", etc.
 	State string `json:"state"`
-	Host  string `json:"host"`
+	// The host where the VM is running
+	Host string `json:"host"`
 }
 
-// AvailableVirtualMachineTypes is how many virtual machines of a given type are available to be deployed
-type AvailableVirtualMachineTypes struct {
-	Quantity                  int64               `json:"Quantity"`
-	MinimumReservationMinutes int64               `json:"MinimumReservationMinutes"`
-	OnDemandPrice             int64               `json:"OnDemandPrice,omitempty"`
-	Specs                     VirtualMachineSpecs `json:"Specs,omitempty"`
+// VirtualMachineUpdate represents a bare metal server
+type VirtualMachineUpdate struct {
+	// Virtual Machine description
+	Description string `json:"description,omitempty"`
 }
@@ -0,0 +1,65 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
+package client
+
+// endpoint identifies an API operation by method and path template
+type endpoint struct {
+	Method string
+	Path   string
+}
+
+// requiredPermissions holds the x-requires-permissions of every operation in swagger.json
+var requiredPermissions = map[endpoint]string{
+	{"GET", "/teams/"}:                                                     "team role: any (only returns teams for which this API key has a role)",
+	{"POST", "/teams/"}:                                                    "user role: owner",
+	{"GET", "/teams/invitations/"}:                                         "user role: any",
+	{"GET", "/teams/{team}/"}:                                              "team role: any",
+	{"PATCH", "/teams/{team}/"}:                                            "team role: owner",
+	{"POST", "/teams/{team}/accept-invitation/"}:                           "user role: owner",
+	{"GET", "/teams/{team}/balance/"}:                                      "team role: any",
+	{"GET", "/teams/{team}/bare_metal/"}:                                   "team role: any",
+	{"POST", "/teams/{team}/bare_metal/"}:                                  "team role: operator",
+	{"GET", "/teams/{team}/bare_metal/available/"}:                         "team role: any",
+	{"DELETE", "/teams/{team}/bare_metal/{server}/"}:                       "team role: operator",
+	{"GET", "/teams/{team}/bare_metal/{server}/"}:                          "team role: any",
+	{"PATCH", "/teams/{team}/bare_metal/{server}/"}:                        "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/console/"}:                 "team role: operator",
+	{"GET", "/teams/{team}/bare_metal/{server}/power/"}:                    "team role: any",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/ac_reset/"}:          "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/cold_reboot/"}:       "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/force_shutdown/"}:    "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/graceful_shutdown/"}: "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/power_on/"}:          "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/power/warm_reboot/"}:       "team role: operator",
+	{"POST", "/teams/{team}/bare_metal/{server}/reinstall/"}:               "team role: operator",
+	{"DELETE", "/teams/{team}/bare_metal/{server}/support_access_enable/"}: "team role: operator",
+	{"PUT", "/teams/{team}/bare_metal/{server}/support_access_enable/"}:    "team role: operator",
+	{"GET", "/teams/{team}/members/invitations/"}:                          "team role: any",
+	{"POST", "/teams/{team}/members/invitations/"}:                         "team role: owner",
+	{"DELETE", "/teams/{team}/members/{email}/"}:                           "team role: owner (to delete another member) OR user role: owner (to delete self)",
+	{"PATCH", "/teams/{team}/members/{email}/"}:                            "team role: owner",
+	{"GET", "/teams/{team}/virtual_machines/"}:                             "team role: any",
+	{"POST", "/teams/{team}/virtual_machines/"}:                            "team role: operator",
+	{"GET", "/teams/{team}/virtual_machines/available/"}:                   "team role: any",
+	{"DELETE", "/teams/{team}/virtual_machines/{vm}/"}:                     "team role: operator",
+	{"GET", "/teams/{team}/virtual_machines/{vm}/"}:                        "team role: any",
+	{"PATCH", "/teams/{team}/virtual_machines/{vm}/"}:                      "team role: operator",
+	{"GET", "/teams/{team}/virtual_machines/{vm}/console/"}:                "team role: operator",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/hard-reset/"}:            "team role: operator",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/reboot/"}:                "team role: operator",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/rebuild/"}:               "team role: operator",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/shutdown/"}:              "team role: operator",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/start/"}:                 "team role: operator",
+	{"GET", "/teams/{team}/virtual_machines/{vm}/state/"}:                  "team role: any",
+	{"POST", "/teams/{team}/virtual_machines/{vm}/stop/"}:                  "team role: operator",
+	{"GET", "/user/"}:                           "user role: any",
+	{"PATCH", "/user/"}:                         "user role: owner",
+	{"GET", "/user/api_keys/"}:                  "user role: any",
+	{"POST", "/user/api_keys/"}:                 "user role: owner",
+	{"DELETE", "/user/api_keys/{prefix}/"}:      "user role: owner",
+	{"GET", "/user/api_keys/{prefix}/"}:         "user role: any",
+	{"PATCH", "/user/api_keys/{prefix}/"}:       "user role: owner",
+	{"GET", "/user/ssh_keys/"}:                  "user role: any",
+	{"POST", "/user/ssh_keys/"}:                 "user role: owner",
+	{"DELETE", "/user/ssh_keys/{fingerprint}/"}: "user role: owner",
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequiredPermission(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: "POST", path: "/teams/my-team/virtual_machines/vm-1/start/", want: "team role: operator"},
+		{method: "GET", path: "/teams/my-team/virtual_machines/vm-1/", want: "team role: any"},
+		{method: "GET", path: "/teams/invitations/", want: "user role: any"},
+		{method: "GET", path: "/teams/my-team/", want: "team role: any"},
+		{method: "DELETE", path: "/user/api_keys/abc/", want: "user role: owner"},
+		{method: "DELETE", path: "/teams/my-team/bare_metal/s-1/?force=true", want: "team role: operator"},
+		{method: "GET", path: "/unknown/", want: ""},
+		{method: "POST", path: "/teams//virtual_machines/", want: ""},
+	}
+	for _, tt := range tests {
+		if got := RequiredPermission(tt.method, tt.path); got != tt.want {
+			t.Errorf("RequiredPermission(%s, %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestRequiredPermissionsMatchSwagger keeps the permission table in sync with swagger.json
+func TestRequiredPermissionsMatchSwagger(t *testing.T) {
+	data, err := os.ReadFile("../swagger.json")
+	if err != nil {
+		t.Fatalf("read swagger.json: %v", err)
+	}
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("parse swagger.json: %v", err)
+	}
+
+	want := map[endpoint]string{}
+	for path, operations := range spec.Paths {
+		for method, raw := range operations {
+			var op struct {
+				Permission string `json:"x-requires-permissions"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil || op.Permission == "" {
+				continue
+			}
+			want[endpoint{Method: strings.ToUpper(method), Path: path}] = op.Permission
+		}
+	}
+
+	for e, permission := range want {
+		if got := requiredPermissions[e]; got != permission {
+			t.Errorf("requiredPermissions[%s %s] = %q, swagger.json says %q", e.Method, e.Path, got, permission)
+		}
+	}
+	for e := range requiredPermissions {
+		if _, ok := want[e]; !ok {
+			t.Errorf("requiredPermissions has %s %s, which is not in swagger.json", e.Method, e.Path)
+		}
+	}
+}
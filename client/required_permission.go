@@ -0,0 +1,46 @@
+package client
+
+import "strings"
+
+// RequiredPermission returns the role the API requires for a request, such as
+// "team role: operator", or "" if it is not known. path is the request path
+// relative to the base URL, with its parameters filled in.
+func RequiredPermission(method, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	best, bestLiterals := "", -1
+	for e, permission := range requiredPermissions {
+		if e.Method != method {
+			continue
+		}
+		// Prefer the template with the most literal segments, so
+		// /teams/invitations/ wins over /teams/{team}/
+		if literals, ok := matchPathTemplate(e.Path, path); ok && literals > bestLiterals {
+			best, bestLiterals = permission, literals
+		}
+	}
+	return best
+}
+
+// matchPathTemplate reports whether path matches a template such as
+// /teams/{team}/ and how many of the template segments are literal
+func matchPathTemplate(template, path string) (int, bool) {
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateParts) != len(pathParts) {
+		return 0, false
+	}
+	literals := 0
+	for i, part := range templateParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, true
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of attempts per request, including the first
+	DefaultMaxAttempts = 3
+	// DefaultRetryBaseDelay is the default backoff before the first retry
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// DefaultRetryMaxDelay is the default upper bound of a single backoff
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy controls how requests that failed with a transient error are
+// retried. Connection errors and 429, 502, 503 and 504 responses are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per request, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles on every
+	// retry and is jittered.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. A Retry-After asking for a longer wait makes
+	// the request fail instead.
+	MaxDelay time.Duration
+	// RetryNonIdempotent also retries POST and PATCH requests, which may then
+	// be applied twice
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+	}
+}
+
+// WithRetryPolicy sets the retry policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// retryReason reports why an attempt should be retried, or false if it should not
+func (p RetryPolicy) retryReason(method string, resp *http.Response, err error) (string, bool) {
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return "", false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", false
+		}
+		return err.Error(), true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return resp.Status, true
+	}
+	return "", false
+}
+
+// delay returns how long to wait before the given retry, starting at 1. It
+// reports false when the server asked to wait longer than MaxDelay.
+func (p RetryPolicy) delay(retry int, resp *http.Response) (time.Duration, bool) {
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return wait, wait <= maxDelay
+		}
+	}
+
+	backoff := p.BaseDelay
+	if backoff <= 0 {
+		backoff = DefaultRetryBaseDelay
+	}
+	for i := 1; i < retry && backoff < maxDelay; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxDelay)
+	// Equal jitter: wait at least half the backoff so retries still spread out
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isIdempotent reports whether repeating a request with this method has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
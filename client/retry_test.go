@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"hotaisle-cli/test"
+)
+
+// fastRetries retries quickly so tests do not sleep
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+func TestRetryTransientStatus(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}
+	attempts := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		status := statuses[attempts]
+		attempts++
+		return test.NewJSONResponse(t, status, VirtualMachineState{State: VMStateRunning}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+
+	state, err := c.VirtualMachines().GetState(context.Background(), "my-team", "vm-1")
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if attempts != 3 || state.State != VMStateRunning {
+		t.Errorf("GetState() = %q after %d attempts, want running after 3", state.State, attempts)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return test.NewJSONResponse(t, http.StatusGatewayTimeout, nil), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+
+	_, err := c.VirtualMachines().GetState(context.Background(), "my-team", "vm-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("GetState() error = %v, want 504 APIError", err)
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want 3", attempts)
+	}
+}
+
+func TestRetryConnectionReset(t *testing.T) {
+	attempts := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, syscall.ECONNRESET
+		}
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+
+	if err := c.VirtualMachines().Delete(context.Background(), "my-team", "vm-1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("made %d attempts, want 2", attempts)
+	}
+}
+
+func TestRetrySkipsNonIdempotentMethods(t *testing.T) {
+	attempts := 0
+	var bodies []string
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		return test.NewJSONResponse(t, http.StatusServiceUnavailable, nil), nil
+	})
+	update := BareMetalServerUpdate{Description: "gpu box"}
+
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+	if err := c.BareMetal().Update(context.Background(), "my-team", "server-1", update); err == nil {
+		t.Fatal("Update() should fail")
+	}
+	if attempts != 1 {
+		t.Errorf("PATCH made %d attempts, want 1", attempts)
+	}
+
+	attempts = 0
+	bodies = nil
+	policy := fastRetries
+	policy.RetryNonIdempotent = true
+	c = NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(policy))
+	_ = c.BareMetal().Update(context.Background(), "my-team", "server-1", update)
+	if attempts != 3 {
+		t.Errorf("PATCH with RetryNonIdempotent made %d attempts, want 3", attempts)
+	}
+	for i, body := range bodies {
+		if body != `{"description":"gpu box"}` {
+			t.Errorf("attempt %d sent body %q, want the full body on every attempt", i+1, body)
+		}
+	}
+}
+
+func TestRetryDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return test.NewJSONResponse(t, http.StatusNotFound, nil), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+
+	_, _ = c.VirtualMachines().GetState(context.Background(), "my-team", "vm-1")
+	if attempts != 1 {
+		t.Errorf("made %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	attempts := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		resp := test.NewJSONResponse(t, http.StatusTooManyRequests, nil)
+		resp.Header.Set("Retry-After", "3600")
+		return resp, nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient), WithRetryPolicy(fastRetries))
+
+	_, err := c.VirtualMachines().GetState(context.Background(), "my-team", "vm-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("GetState() error = %v, want 429 APIError", err)
+	}
+	if attempts != 1 {
+		t.Errorf("made %d attempts, want 1 when Retry-After exceeds MaxDelay", attempts)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{retry: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{retry: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{retry: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{retry: 10, min: 500 * time.Millisecond, max: time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			delay, ok := p.delay(tt.retry, nil)
+			if !ok || delay < tt.min || delay > tt.max {
+				t.Fatalf("delay(%d) = %v, want between %v and %v", tt.retry, delay, tt.min, tt.max)
+			}
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"1"}}}
+	if delay, ok := p.delay(1, resp); !ok || delay != time.Second {
+		t.Errorf("delay() with Retry-After: 1 = %v, %v, want 1s", delay, ok)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "Tue, 20 May 2025 10:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Tue, 20 May 2025 09:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
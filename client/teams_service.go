@@ -1,3 +1,5 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
 package client
 
 import (
@@ -15,21 +17,27 @@ func (c *Client) Teams() *TeamsService {
 	return &TeamsService{client: c}
 }
 
-// List retrieves all teams the user belongs to
+// List returns information about the teams the user belongs to, including roles.
+//
+// Requires team role: any (only returns teams for which this API key has a role)
 func (s *TeamsService) List(ctx context.Context) ([]UserTeam, error) {
 	var result []UserTeam
 	err := s.client.doRequest(ctx, http.MethodGet, "/teams/", nil, &result)
 	return result, err
 }
 
-// Create creates a new team
-func (s *TeamsService) Create(ctx context.Context, team Team) (*UserTeamWithMembers, error) {
+// Create creates a new team with the authenticated user as the owner.
+//
+// Requires user role: owner
+func (s *TeamsService) Create(ctx context.Context, req Team) (*UserTeamWithMembers, error) {
 	var result UserTeamWithMembers
-	err := s.client.doRequest(ctx, http.MethodPost, "/teams/", team, &result)
+	err := s.client.doRequest(ctx, http.MethodPost, "/teams/", req, &result)
 	return &result, err
 }
 
-// Get retrieves detailed information about a specific team
+// Get returns detailed information about a specific team the user belongs to.
+//
+// Requires team role: any
 func (s *TeamsService) Get(ctx context.Context, teamHandle string) (*UserTeamDetails, error) {
 	path := buildPath("/teams/{team}/", map[string]string{
 		"team": teamHandle,
@@ -39,7 +47,9 @@ func (s *TeamsService) Get(ctx context.Context, teamHandle string) (*UserTeamDet
 	return &result, err
 }
 
-// Update updates a team's information
+// Update updates a team's details.
+//
+// Requires team role: owner
 func (s *TeamsService) Update(ctx context.Context, teamHandle string, update TeamUpdate) (*UserTeamWithMembers, error) {
 	path := buildPath("/teams/{team}/", map[string]string{
 		"team": teamHandle,
@@ -49,14 +59,18 @@ func (s *TeamsService) Update(ctx context.Context, teamHandle string, update Tea
 	return &result, err
 }
 
-// GetInvitations retrieves pending team invitations for the user
+// GetInvitations returns information about the team invitations that are pending for the user.
+//
+// Requires user role: any
 func (s *TeamsService) GetInvitations(ctx context.Context) ([]UserTeam, error) {
 	var result []UserTeam
 	err := s.client.doRequest(ctx, http.MethodGet, "/teams/invitations/", nil, &result)
 	return result, err
 }
 
-// AcceptInvitation accepts a pending team invitation
+// AcceptInvitation accepts a pending invitation to join a team.
+//
+// Requires user role: owner
 func (s *TeamsService) AcceptInvitation(ctx context.Context, teamHandle string) (*UserTeamWithMembers, error) {
 	path := buildPath("/teams/{team}/accept-invitation/", map[string]string{
 		"team": teamHandle,
@@ -66,7 +80,9 @@ func (s *TeamsService) AcceptInvitation(ctx context.Context, teamHandle string)
 	return &result, err
 }
 
-// GetBalance retrieves team balance information
+// GetBalance returns the current available balance, estimated runout time, and hourly rate for all active resources in the team.
+//
+// Requires team role: any
 func (s *TeamsService) GetBalance(ctx context.Context, teamHandle string) (*BalanceInfo, error) {
 	path := buildPath("/teams/{team}/balance/", map[string]string{
 		"team": teamHandle,
@@ -76,7 +92,7 @@ func (s *TeamsService) GetBalance(ctx context.Context, teamHandle string) (*Bala
 	return &result, err
 }
 
-// PurchaseCredits creates a Stripe checkout session for purchasing credits
+// PurchaseCredits creates a Stripe checkout session for purchasing credits for the team
 func (s *TeamsService) PurchaseCredits(ctx context.Context, teamHandle string, req PurchaseTeamCreditsRequest) (*PurchaseTeamCreditsResponse, error) {
 	path := buildPath("/teams/{team}/purchase-credits/", map[string]string{
 		"team": teamHandle,
@@ -86,7 +102,9 @@ func (s *TeamsService) PurchaseCredits(ctx context.Context, teamHandle string, r
 	return &result, err
 }
 
-// GetTeamInvitations retrieves pending invitations for a team
+// GetTeamInvitations returns a list of pending invitations for a team.
+//
+// Requires team role: any
 func (s *TeamsService) GetTeamInvitations(ctx context.Context, teamHandle string) ([]TeamMember, error) {
 	path := buildPath("/teams/{team}/members/invitations/", map[string]string{
 		"team": teamHandle,
@@ -96,7 +114,9 @@ func (s *TeamsService) GetTeamInvitations(ctx context.Context, teamHandle string
 	return result, err
 }
 
-// InviteMember invites a user to join the team
+// InviteMember creates an invitation for a user to join a team.
+//
+// Requires team role: owner
 func (s *TeamsService) InviteMember(ctx context.Context, teamHandle string, req TeamInvitationRequest) error {
 	path := buildPath("/teams/{team}/members/invitations/", map[string]string{
 		"team": teamHandle,
@@ -104,7 +124,9 @@ func (s *TeamsService) InviteMember(ctx context.Context, teamHandle string, req
 	return s.client.doRequest(ctx, http.MethodPost, path, req, nil)
 }
 
-// UpdateMember updates a team member's roles
+// UpdateMember updates a team member's roles.
+//
+// Requires team role: owner
 func (s *TeamsService) UpdateMember(ctx context.Context, teamHandle, email string, update TeamMemberUpdate) (*TeamMember, error) {
 	path := buildPath("/teams/{team}/members/{email}/", map[string]string{
 		"team":  teamHandle,
@@ -115,7 +137,9 @@ func (s *TeamsService) UpdateMember(ctx context.Context, teamHandle, email strin
 	return &result, err
 }
 
-// RemoveMember removes a member from a team
+// RemoveMember removes a member from a team. This can be: 1. Yourself (must be a contact owner) 2. Another member (must be a team owner) 3. A pending invitation (works as rejection)
+//
+// Requires team role: owner (to delete another member) OR user role: owner (to delete self)
 func (s *TeamsService) RemoveMember(ctx context.Context, teamHandle, email string) error {
 	path := buildPath("/teams/{team}/members/{email}/", map[string]string{
 		"team":  teamHandle,
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// redacted replaces secrets in printed and logged requests
+const redacted = "REDACTED"
+
+// sensitiveHeaders are the headers that are never printed or logged as they are
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// redactHeader returns a copy of a header with the sensitive values redacted
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := out[name]; ok {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// DryRunTransport is an http.RoundTripper that prints mutating requests as
+// curl commands instead of sending them, and answers them with 204 No
+// Content. GET, HEAD and OPTIONS requests are sent, so that commands still
+// see the real state of the resources. Install it with WithDryRun.
+type DryRunTransport struct {
+	// Out receives the printed requests
+	Out io.Writer
+	// Next sends the requests that are not intercepted, or
+	// http.DefaultTransport if nil
+	Next http.RoundTripper
+}
+
+// WithDryRun prints the mutating requests of the client to out instead of
+// sending them, with a DryRunTransport wrapping the transport of its HTTP client
+func WithDryRun(out io.Writer) Option {
+	return func(c *Client) {
+		c.dryRunOut = out
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isSafe(req.Method) {
+		next := t.Next
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintln(t.Out, curlCommand(req, body)); err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"X-Dry-Run": {"true"}},
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+// isSafe reports whether a request method does not change anything
+func isSafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// curlCommand formats a request as a curl command, with the secret headers redacted
+func curlCommand(req *http.Request, body []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "curl -X %s %s", req.Method, shellQuote(req.URL.String()))
+
+	header := redactHeader(req.Header)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(name+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(&b, " \\\n  --data-raw %s", shellQuote(string(body)))
+	}
+	return b.String()
+}
+
+// shellQuote quotes a string for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
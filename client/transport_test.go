@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"hotaisle-cli/test"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	var sent []string
+	next := test.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Method+" "+req.URL.Path)
+		return test.NewJSONResponse(t, http.StatusOK, []VirtualMachineDetails{}), nil
+	})
+	var out strings.Builder
+	httpClient := &http.Client{Transport: &DryRunTransport{Out: &out, Next: next}}
+	c := NewClient(WithBaseURL("https://api.example.com/api"), WithHTTPClient(httpClient), WithToken("secret-token"), WithUserAgent("hotaisle/test"))
+	ctx := context.Background()
+
+	if _, err := c.VirtualMachines().List(ctx, "my-team"); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	err := c.VirtualMachines().Update(ctx, "my-team", "vm-1", VirtualMachineUpdate{Description: "it's mine"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	vm, err := c.VirtualMachines().Provision(ctx, "my-team", VMProvisionRequest{UserDataURL: "https://example.com/init"})
+	if err != nil || vm == nil {
+		t.Fatalf("Provision() = %v, %v, want an empty VM", vm, err)
+	}
+
+	if len(sent) != 1 || sent[0] != "GET /api/teams/my-team/virtual_machines/" {
+		t.Errorf("sent %v, want only the GET", sent)
+	}
+	want := `curl -X PATCH 'https://api.example.com/api/teams/my-team/virtual_machines/vm-1/' \
+  -H 'Accept: application/json' \
+  -H 'Authorization: REDACTED' \
+  -H 'Content-Type: application/json' \
+  -H 'User-Agent: hotaisle/test' \
+  --data-raw '{"description":"it'\''s mine"}'
+curl -X POST 'https://api.example.com/api/teams/my-team/virtual_machines/' \
+`
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("printed:\n%s\nwant prefix:\n%s", out.String(), want)
+	}
+	if strings.Contains(out.String(), "secret-token") {
+		t.Error("the token was printed")
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{"Authorization": {"secret"}, "Accept": {"application/json"}}
+	got := redactHeader(h)
+	if got.Get("Authorization") != redacted || got.Get("Accept") != "application/json" {
+		t.Errorf("redactHeader() = %v", got)
+	}
+	if h.Get("Authorization") != "secret" {
+		t.Error("redactHeader() changed its argument")
+	}
+}
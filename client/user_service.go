@@ -1,3 +1,5 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
 package client
 
 import (
@@ -15,35 +17,45 @@ func (c *Client) User() *UserService {
 	return &UserService{client: c}
 }
 
-// Get retrieves information about the currently authenticated user
+// Get returns user details including name, email, and account creation date for the authenticated user. Also includes information about the teams the user belongs to, including roles.
+//
+// Requires user role: any
 func (s *UserService) Get(ctx context.Context) (*GetUserResponse, error) {
 	var result GetUserResponse
 	err := s.client.doRequest(ctx, http.MethodGet, "/user/", nil, &result)
 	return &result, err
 }
 
-// Update updates the currently authenticated user profile
+// Update allows updating user profile information such as display name.
+//
+// Requires user role: owner
 func (s *UserService) Update(ctx context.Context, update UserUpdate) (*User, error) {
 	var result User
 	err := s.client.doRequest(ctx, http.MethodPatch, "/user/", update, &result)
 	return &result, err
 }
 
-// GetSSHKeys retrieves all SSH keys for the user
+// GetSSHKeys returns a list of all SSH keys belonging to the user.
+//
+// Requires user role: any
 func (s *UserService) GetSSHKeys(ctx context.Context) ([]SSHKey, error) {
 	var result []SSHKey
 	err := s.client.doRequest(ctx, http.MethodGet, "/user/ssh_keys/", nil, &result)
 	return result, err
 }
 
-// AddSSHKey adds a new SSH key to the user's account
-func (s *UserService) AddSSHKey(ctx context.Context, key SSHKeyRequest) (*SSHKey, error) {
+// AddSSHKey adds a new SSH public key to the user's account.
+//
+// Requires user role: owner
+func (s *UserService) AddSSHKey(ctx context.Context, req SSHKeyRequest) (*SSHKey, error) {
 	var result SSHKey
-	err := s.client.doRequest(ctx, http.MethodPost, "/user/ssh_keys/", key, &result)
+	err := s.client.doRequest(ctx, http.MethodPost, "/user/ssh_keys/", req, &result)
 	return &result, err
 }
 
-// DeleteSSHKey permanently deletes an SSH key from the user's account
+// DeleteSSHKey permanently deletes an SSH key from the user's account.
+//
+// Requires user role: owner
 func (s *UserService) DeleteSSHKey(ctx context.Context, fingerprint string) error {
 	path := buildPath("/user/ssh_keys/{fingerprint}/", map[string]string{
 		"fingerprint": fingerprint,
@@ -51,14 +63,18 @@ func (s *UserService) DeleteSSHKey(ctx context.Context, fingerprint string) erro
 	return s.client.doRequest(ctx, http.MethodDelete, path, nil, nil)
 }
 
-// GetAPIKeys retrieves all API keys for the user
+// GetAPIKeys returns a list of all API keys belonging to the user.
+//
+// Requires user role: any
 func (s *UserService) GetAPIKeys(ctx context.Context) ([]UserAPIKey, error) {
 	var result []UserAPIKey
 	err := s.client.doRequest(ctx, http.MethodGet, "/user/api_keys/", nil, &result)
 	return result, err
 }
 
-// GetAPIKey retrieves detailed information about a specific API key
+// GetAPIKey returns detailed information about a specific API key.
+//
+// Requires user role: any
 func (s *UserService) GetAPIKey(ctx context.Context, prefix string) (*UserAPIKey, error) {
 	path := buildPath("/user/api_keys/{prefix}/", map[string]string{
 		"prefix": prefix,
@@ -68,14 +84,18 @@ func (s *UserService) GetAPIKey(ctx context.Context, prefix string) (*UserAPIKey
 	return &result, err
 }
 
-// CreateAPIKey creates a new API key with specified permissions
+// CreateAPIKey creates a new API key with specified permissions.
+//
+// Requires user role: owner
 func (s *UserService) CreateAPIKey(ctx context.Context, req UserAPIKeyRequest) (*UserAPIKeyWithToken, error) {
 	var result UserAPIKeyWithToken
 	err := s.client.doRequest(ctx, http.MethodPost, "/user/api_keys/", req, &result)
 	return &result, err
 }
 
-// UpdateAPIKey updates an existing API key
+// UpdateAPIKey updates an existing API key with new permissions or label.
+//
+// Requires user role: owner
 func (s *UserService) UpdateAPIKey(ctx context.Context, prefix string, req UserAPIKeyRequest) (*UserAPIKey, error) {
 	path := buildPath("/user/api_keys/{prefix}/", map[string]string{
 		"prefix": prefix,
@@ -85,7 +105,9 @@ func (s *UserService) UpdateAPIKey(ctx context.Context, prefix string, req UserA
 	return &result, err
 }
 
-// DeleteAPIKey permanently deletes an API key
+// DeleteAPIKey permanently deletes an API key.
+//
+// Requires user role: owner
 func (s *UserService) DeleteAPIKey(ctx context.Context, prefix string) error {
 	path := buildPath("/user/api_keys/{prefix}/", map[string]string{
 		"prefix": prefix,
@@ -1,8 +1,12 @@
+// Code generated by clientgen from swagger.json. DO NOT EDIT.
+
 package client
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"net/url"
 )
 
 // VirtualMachinesService handles virtual machine-related API operations
@@ -15,7 +19,9 @@ func (c *Client) VirtualMachines() *VirtualMachinesService {
 	return &VirtualMachinesService{client: c}
 }
 
-// List retrieves all virtual machines for a team
+// List returns a list of virtual machines associated with the team.
+//
+// Requires team role: any
 func (s *VirtualMachinesService) List(ctx context.Context, teamHandle string) ([]VirtualMachineDetails, error) {
 	path := buildPath("/teams/{team}/virtual_machines/", map[string]string{
 		"team": teamHandle,
@@ -25,7 +31,9 @@ func (s *VirtualMachinesService) List(ctx context.Context, teamHandle string) ([
 	return result, err
 }
 
-// Get retrieves detailed information about a specific virtual machine
+// Get returns detailed information about a specific virtual machine.
+//
+// Requires team role: any
 func (s *VirtualMachinesService) Get(ctx context.Context, teamHandle, vmName string) (*VirtualMachineDetails, error) {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/", map[string]string{
 		"team": teamHandle,
@@ -36,7 +44,11 @@ func (s *VirtualMachinesService) Get(ctx context.Context, teamHandle, vmName str
 	return &result, err
 }
 
-// Provision assigns and provisions a virtual machine
+// Provision assigns and provisions an available virtual machine matching the specified requirements.
+// Optionally provide a user_data_url to apply custom cloud-init configuration during provisioning. When provided, the VM will be assigned and then rebuilt with custom user-data applied. Default configuration will be applied as vendor-data and custom user-data will be merged.
+// WARNING: Providing custom user-data will significantly increase provisioning time as the VM needs to be rebuilt. The provisioning will continue in the background if the HTTP request is canceled, but the VM will still be provisioned successfully.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Provision(ctx context.Context, teamHandle string, req VMProvisionRequest) (*VirtualMachineDetails, error) {
 	path := buildPath("/teams/{team}/virtual_machines/", map[string]string{
 		"team": teamHandle,
@@ -46,7 +58,9 @@ func (s *VirtualMachinesService) Provision(ctx context.Context, teamHandle strin
 	return &result, err
 }
 
-// Update updates a virtual machine's description
+// Update updates a vrutal machine's description.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Update(ctx context.Context, teamHandle, vmName string, update VirtualMachineUpdate) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/", map[string]string{
 		"team": teamHandle,
@@ -55,7 +69,14 @@ func (s *VirtualMachinesService) Update(ctx context.Context, teamHandle, vmName
 	return s.client.doRequest(ctx, http.MethodPatch, path, update, nil)
 }
 
-// Delete completely deletes a virtual machine and all its resources
+// Delete completely deletes a virtual machine and all its resources.
+// WARNING: This operation will DELETE the VM and ALL of its data. This operation cannot be undone.
+// This request will not return until the reset is complete, but the reset will continue if the request is canceled.
+// When using force=true, the VM can be deleted even if the minimum reservation time has not been met. However, the minimum charge for the reservation period will NOT be refunded.
+//
+// Use DeleteWithForce to send the query parameters.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Delete(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/", map[string]string{
 		"team": teamHandle,
@@ -64,7 +85,27 @@ func (s *VirtualMachinesService) Delete(ctx context.Context, teamHandle, vmName
 	return s.client.doRequest(ctx, http.MethodDelete, path, nil, nil)
 }
 
-// GetAvailable retrieves available virtual machine types
+// DeleteWithForce completely deletes a virtual machine and all its resources.
+// WARNING: This operation will DELETE the VM and ALL of its data. This operation cannot be undone.
+// This request will not return until the reset is complete, but the reset will continue if the request is canceled.
+// When using force=true, the VM can be deleted even if the minimum reservation time has not been met. However, the minimum charge for the reservation period will NOT be refunded.
+//
+// Requires team role: operator
+func (s *VirtualMachinesService) DeleteWithForce(ctx context.Context, teamHandle, vmName string, force bool) error {
+	path := buildPath("/teams/{team}/virtual_machines/{vm}/", map[string]string{
+		"team": teamHandle,
+		"vm":   vmName,
+	})
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return s.client.doRequest(ctx, http.MethodDelete, withQuery(path, query), nil, nil)
+}
+
+// GetAvailable returns a list of available virtual machine types that can be provisioned, including pricing information.
+//
+// Requires team role: any
 func (s *VirtualMachinesService) GetAvailable(ctx context.Context, teamHandle string) ([]AvailableVirtualMachineTypes, error) {
 	path := buildPath("/teams/{team}/virtual_machines/available/", map[string]string{
 		"team": teamHandle,
@@ -74,7 +115,9 @@ func (s *VirtualMachinesService) GetAvailable(ctx context.Context, teamHandle st
 	return result, err
 }
 
-// GetState retrieves the current power state of a virtual machine
+// GetState returns the current power state of the virtual machine.
+//
+// Requires team role: any
 func (s *VirtualMachinesService) GetState(ctx context.Context, teamHandle, vmName string) (*VirtualMachineState, error) {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/state/", map[string]string{
 		"team": teamHandle,
@@ -85,7 +128,9 @@ func (s *VirtualMachinesService) GetState(ctx context.Context, teamHandle, vmNam
 	return &result, err
 }
 
-// Start starts a virtual machine that is currently stopped
+// Start starts a virtual machine that is currently stopped.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Start(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/start/", map[string]string{
 		"team": teamHandle,
@@ -94,7 +139,9 @@ func (s *VirtualMachinesService) Start(ctx context.Context, teamHandle, vmName s
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// Stop forcefully stops a running virtual machine
+// Stop forcefully stops a running virtual machine (equivalent to pulling the power).
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Stop(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/stop/", map[string]string{
 		"team": teamHandle,
@@ -103,7 +150,9 @@ func (s *VirtualMachinesService) Stop(ctx context.Context, teamHandle, vmName st
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// Shutdown sends a graceful shutdown signal to a running virtual machine
+// Shutdown sends a graceful shutdown signal to a running virtual machine.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Shutdown(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/shutdown/", map[string]string{
 		"team": teamHandle,
@@ -112,7 +161,9 @@ func (s *VirtualMachinesService) Shutdown(ctx context.Context, teamHandle, vmNam
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// Reboot gracefully reboots a running virtual machine
+// Reboot gracefully reboots a running virtual machine by sending an ACPI reboot signal. This allows the guest OS to perform a clean shutdown and restart. This is the preferred way to restart a VM when possible.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Reboot(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/reboot/", map[string]string{
 		"team": teamHandle,
@@ -121,7 +172,9 @@ func (s *VirtualMachinesService) Reboot(ctx context.Context, teamHandle, vmName
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// HardReset forcefully resets a running virtual machine
+// HardReset forcefully resets a running virtual machine (equivalent to pressing the hardware reset button). This operation is abrupt and may result in data loss if the VM's filesystem is not properly synced. Use the graceful reboot endpoint when possible.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) HardReset(ctx context.Context, teamHandle, vmName string) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/hard-reset/", map[string]string{
 		"team": teamHandle,
@@ -130,7 +183,12 @@ func (s *VirtualMachinesService) HardReset(ctx context.Context, teamHandle, vmNa
 	return s.client.doRequest(ctx, http.MethodPost, path, nil, nil)
 }
 
-// Rebuild performs a complete rebuild of the virtual machine to its initial state
+// Rebuild performs a complete rebuild of the virtual machine to its initial state.
+// WARNING: This operation will DELETE ALL DATA on the virtual machine. The VM will be recreated from the original template, resulting in complete data loss. This operation cannot be undone.
+// Optionally provide a user_data_url to apply custom cloud-init configuration during the rebuild. When provided, default configuration will be applied as vendor-data and custom user-data will be merged.
+// This request will not return until the rebuild is complete, but the rebuild will continue if the request is canceled.
+//
+// Requires team role: operator
 func (s *VirtualMachinesService) Rebuild(ctx context.Context, teamHandle, vmName string, req VMResetRequest) error {
 	path := buildPath("/teams/{team}/virtual_machines/{vm}/rebuild/", map[string]string{
 		"team": teamHandle,
@@ -138,3 +196,16 @@ func (s *VirtualMachinesService) Rebuild(ctx context.Context, teamHandle, vmName
 	})
 	return s.client.doRequest(ctx, http.MethodPost, path, req, nil)
 }
+
+// Console opens a WebSocket connection to the virtual machine's console
+//
+// Reads return what the server sends and writes are sent to it.
+//
+// Requires team role: operator
+func (s *VirtualMachinesService) Console(ctx context.Context, teamHandle, vmName string) (io.ReadWriteCloser, error) {
+	path := buildPath("/teams/{team}/virtual_machines/{vm}/console/", map[string]string{
+		"team": teamHandle,
+		"vm":   vmName,
+	})
+	return s.client.dialWebSocket(ctx, path)
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Virtual machine states reported by GetState
+const (
+	VMStateRunning = "running"
+	VMStateShutOff = "shut off"
+	VMStatePaused  = "paused"
+)
+
+// VMStateIs returns a predicate that matches any of the given virtual machine states
+func VMStateIs(states ...string) func(*VirtualMachineState) bool {
+	return func(s *VirtualMachineState) bool {
+		for _, state := range states {
+			if s.State == state {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// VMStateReturnsTo returns a predicate that matches the state once the
+// virtual machine has been seen in another state, e.g. running again after a
+// reboot. A reboot can also finish between two polls, so the state matches as
+// well once grace has passed since the first poll. It keeps what it has seen,
+// so use a new one for every wait.
+func VMStateReturnsTo(state string, grace time.Duration) func(*VirtualMachineState) bool {
+	left := false
+	var start time.Time
+	return func(s *VirtualMachineState) bool {
+		if start.IsZero() {
+			start = time.Now()
+		}
+		if s.State != state {
+			left = true
+		}
+		return s.State == state && (left || time.Since(start) >= grace)
+	}
+}
+
+// WaitForState polls the state of a virtual machine until until reports true.
+// onChange, if not nil, is called with the first state and then every time it changes.
+func (s *VirtualMachinesService) WaitForState(ctx context.Context, teamHandle, vmName string, cfg WaitConfig, until func(*VirtualMachineState) bool, onChange func(*VirtualMachineState)) (*VirtualMachineState, error) {
+	var previous *VirtualMachineState
+	state, err := Wait(ctx, cfg, func(ctx context.Context) (*VirtualMachineState, error) {
+		return s.GetState(ctx, teamHandle, vmName)
+	}, func(state *VirtualMachineState) (bool, error) {
+		if onChange != nil && (previous == nil || previous.State != state.State) {
+			onChange(state)
+		}
+		previous = state
+		return until(state), nil
+	})
+	if errors.Is(err, ErrWaitTimeout) && state != nil {
+		return state, fmt.Errorf("%w: virtual machine %s is %s", err, vmName, state.State)
+	}
+	return state, err
+}
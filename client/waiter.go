@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultWaitInterval is the default delay before the second poll
+	DefaultWaitInterval = 2 * time.Second
+	// DefaultWaitMaxInterval is the default upper bound of the backoff between polls
+	DefaultWaitMaxInterval = 30 * time.Second
+)
+
+// ErrWaitTimeout is returned when the condition is not met before the timeout
+var ErrWaitTimeout = errors.New("timed out waiting for condition")
+
+// WaitConfig controls how Wait polls
+type WaitConfig struct {
+	// Interval is the delay before the second poll. It doubles after every poll.
+	Interval time.Duration
+	// MaxInterval caps the delay between polls
+	MaxInterval time.Duration
+	// Timeout bounds the whole wait. Zero means the wait is only bounded by the context.
+	Timeout time.Duration
+}
+
+// Wait calls poll until done reports true, backing off between polls, and
+// returns the last polled value. The first poll happens immediately. An error
+// from poll or done stops the wait.
+func Wait[T any](ctx context.Context, cfg WaitConfig, poll func(context.Context) (T, error), done func(T) (bool, error)) (T, error) {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	maxInterval := cfg.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultWaitMaxInterval
+	}
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var last T
+	for {
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		if err := ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return last, ErrWaitTimeout
+			}
+			return last, err
+		}
+
+		value, err := poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				continue
+			}
+			return last, err
+		}
+		last = value
+
+		ok, err := done(value)
+		if err != nil {
+			return last, err
+		}
+		if ok {
+			return last, nil
+		}
+
+		timer.Reset(interval)
+		interval = min(interval*2, maxInterval)
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"hotaisle-cli/test"
+)
+
+func TestWaitReturnsWhenDone(t *testing.T) {
+	polls := 0
+	value, err := Wait(context.Background(), WaitConfig{Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+		polls++
+		return polls, nil
+	}, func(v int) (bool, error) {
+		return v == 3, nil
+	})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if value != 3 || polls != 3 {
+		t.Errorf("Wait() = %d after %d polls, want 3 after 3 polls", value, polls)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	value, err := Wait(context.Background(), WaitConfig{Interval: time.Millisecond, Timeout: 20 * time.Millisecond}, func(ctx context.Context) (string, error) {
+		return "pending", nil
+	}, func(v string) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("Wait() error = %v, want ErrWaitTimeout", err)
+	}
+	if value != "pending" {
+		t.Errorf("Wait() = %q, want last polled value", value)
+	}
+}
+
+func TestWaitContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Wait(ctx, WaitConfig{}, func(ctx context.Context) (int, error) {
+		t.Error("poll should not be called with a canceled context")
+		return 0, nil
+	}, func(v int) (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitStopsOnError(t *testing.T) {
+	pollErr := errors.New("boom")
+	_, err := Wait(context.Background(), WaitConfig{Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+		return 0, pollErr
+	}, func(v int) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, pollErr) {
+		t.Errorf("Wait() error = %v, want poll error", err)
+	}
+
+	doneErr := errors.New("failed state")
+	_, err = Wait(context.Background(), WaitConfig{Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, func(v int) (bool, error) {
+		return false, doneErr
+	})
+	if !errors.Is(err, doneErr) {
+		t.Errorf("Wait() error = %v, want done error", err)
+	}
+}
+
+func TestWaitForState(t *testing.T) {
+	states := []string{VMStateShutOff, VMStateShutOff, VMStateRunning}
+	polls := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/teams/my-team/virtual_machines/vm-1/state/" {
+			t.Errorf("unexpected path %s", req.URL.Path)
+		}
+		state := states[min(polls, len(states)-1)]
+		polls++
+		return test.NewJSONResponse(t, http.StatusOK, VirtualMachineState{State: state, Host: "host-1"}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	var changes []string
+	state, err := c.VirtualMachines().WaitForState(context.Background(), "my-team", "vm-1",
+		WaitConfig{Interval: time.Millisecond},
+		VMStateIs(VMStateRunning),
+		func(s *VirtualMachineState) { changes = append(changes, s.State) },
+	)
+	if err != nil {
+		t.Fatalf("WaitForState() error = %v", err)
+	}
+	if state.State != VMStateRunning {
+		t.Errorf("WaitForState() state = %q, want %q", state.State, VMStateRunning)
+	}
+	if len(changes) != 2 || changes[0] != VMStateShutOff || changes[1] != VMStateRunning {
+		t.Errorf("WaitForState() reported changes %v, want [shut off running]", changes)
+	}
+}
+
+func TestVMStateReturnsTo(t *testing.T) {
+	until := VMStateReturnsTo(VMStateRunning, time.Hour)
+	for i, tt := range []struct {
+		state string
+		want  bool
+	}{
+		{VMStateRunning, false},
+		{"rebooting", false},
+		{VMStateRunning, true},
+	} {
+		if got := until(&VirtualMachineState{State: tt.state}); got != tt.want {
+			t.Errorf("poll %d: until(%q) = %v, want %v", i, tt.state, got, tt.want)
+		}
+	}
+
+	// A reboot that finished between two polls is done after the grace period
+	until = VMStateReturnsTo(VMStateRunning, 10*time.Millisecond)
+	if until(&VirtualMachineState{State: VMStateRunning}) {
+		t.Error("until(running) = true on the first poll, want false")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !until(&VirtualMachineState{State: VMStateRunning}) {
+		t.Error("until(running) = false after the grace period, want true")
+	}
+}
+
+func TestWaitForStateTimeout(t *testing.T) {
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		return test.NewJSONResponse(t, http.StatusOK, VirtualMachineState{State: VMStatePaused}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	_, err := c.VirtualMachines().WaitForState(context.Background(), "my-team", "vm-1",
+		WaitConfig{Interval: time.Millisecond, Timeout: 20 * time.Millisecond},
+		VMStateIs(VMStateRunning),
+		nil,
+	)
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("WaitForState() error = %v, want ErrWaitTimeout", err)
+	}
+	if want := "virtual machine vm-1 is paused"; !strings.Contains(err.Error(), want) {
+		t.Errorf("WaitForState() error = %q, want it to mention %q", err, want)
+	}
+}
+
+func TestWaitForInstall(t *testing.T) {
+	stages := []string{OSStatusBIOSReset, OSStatusInstallingOS, OSStatusInstalled}
+	polls := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/teams/my-team/bare_metal/server-1/" {
+			t.Errorf("unexpected path %s", req.URL.Path)
+		}
+		stage := stages[min(polls, len(stages)-1)]
+		polls++
+		return test.NewJSONResponse(t, http.StatusOK, BareMetalServerDetails{
+			OSStatus: &BareMetalServerlOSStatus{OSStatus: stage},
+		}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	var seen []string
+	server, err := c.BareMetal().WaitForInstall(context.Background(), "my-team", "server-1", time.Time{},
+		WaitConfig{Interval: time.Millisecond},
+		func(s *BareMetalServerDetails) { seen = append(seen, s.OSStatus.OSStatus) },
+	)
+	if err != nil {
+		t.Fatalf("WaitForInstall() error = %v", err)
+	}
+	if server.OSStatus.OSStatus != OSStatusInstalled {
+		t.Errorf("WaitForInstall() status = %q, want %q", server.OSStatus.OSStatus, OSStatusInstalled)
+	}
+	if len(seen) != 3 {
+		t.Errorf("WaitForInstall() polled %v, want every stage", seen)
+	}
+}
+
+func TestWaitForInstallIgnoresEarlierInstall(t *testing.T) {
+	since := time.Now()
+	earlier := since.Add(-time.Hour)
+	statuses := []BareMetalServerlOSStatus{
+		{OSStatus: OSStatusInstalled, LastImagingUpdate: earlier},
+		{OSStatus: OSStatusShuttingDown, LastImagingUpdate: since.Add(time.Second)},
+		{OSStatus: OSStatusInstalled, LastImagingUpdate: since.Add(time.Minute)},
+	}
+	polls := 0
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		status := statuses[min(polls, len(statuses)-1)]
+		polls++
+		return test.NewJSONResponse(t, http.StatusOK, BareMetalServerDetails{OSStatus: &status}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	var seen []string
+	server, err := c.BareMetal().WaitForInstall(context.Background(), "my-team", "server-1", since,
+		WaitConfig{Interval: time.Millisecond},
+		func(s *BareMetalServerDetails) { seen = append(seen, s.OSStatus.OSStatus) },
+	)
+	if err != nil {
+		t.Fatalf("WaitForInstall() error = %v", err)
+	}
+	if polls != 3 || !server.OSStatus.LastImagingUpdate.After(since) {
+		t.Errorf("WaitForInstall() ended after %d polls with %+v, want the install after since", polls, server.OSStatus)
+	}
+	if len(seen) != 2 || seen[0] != OSStatusShuttingDown {
+		t.Errorf("WaitForInstall() reported %v, want the stages after since only", seen)
+	}
+
+	// A final stage reported after since ends the wait even if no stage was polled before
+	polls = 2
+	if _, err := c.BareMetal().WaitForInstall(context.Background(), "my-team", "server-1", since, WaitConfig{Interval: time.Millisecond}, nil); err != nil {
+		t.Fatalf("WaitForInstall() error = %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("WaitForInstall() polled %d times, want 1", polls-2)
+	}
+}
+
+func TestWaitForInstallFailed(t *testing.T) {
+	httpClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		return test.NewJSONResponse(t, http.StatusOK, BareMetalServerDetails{
+			OSStatus: &BareMetalServerlOSStatus{OSStatus: OSStatusFailed},
+		}), nil
+	})
+	c := NewClient(WithBaseURL(""), WithHTTPClient(httpClient))
+
+	server, err := c.BareMetal().WaitForInstall(context.Background(), "my-team", "server-1", time.Time{}, WaitConfig{Interval: time.Millisecond}, nil)
+	if !errors.Is(err, ErrOSInstallFailed) {
+		t.Fatalf("WaitForInstall() error = %v, want ErrOSInstallFailed", err)
+	}
+	if server == nil || server.OSStatus.OSStatus != OSStatusFailed {
+		t.Errorf("WaitForInstall() should return the failed server, got %+v", server)
+	}
+}
+
+func TestOSInstallStageIndex(t *testing.T) {
+	if got := OSInstallStageIndex(OSStatusShuttingDown); got != 0 {
+		t.Errorf("OSInstallStageIndex(shutting_down) = %d, want 0", got)
+	}
+	if got := OSInstallStageIndex(OSStatusInstalled); got != len(OSInstallStages)-1 {
+		t.Errorf("OSInstallStageIndex(installed) = %d, want last", got)
+	}
+	if got := OSInstallStageIndex(OSStatusFailed); got != -1 {
+		t.Errorf("OSInstallStageIndex(failed) = %d, want -1", got)
+	}
+}
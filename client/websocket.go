@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+)
+
+// dialWebSocket opens an authenticated WebSocket to path. A handshake rejected
+// by the API is returned as an *APIError.
+func (c *Client) dialWebSocket(ctx context.Context, path string) (io.ReadWriteCloser, error) {
+	header := http.Header{}
+	if token := c.authToken(); token != "" {
+		header.Set("Authorization", token)
+	}
+	if c.userAgent != "" {
+		header.Set("User-Agent", c.userAgent)
+	}
+
+	conn, resp, err := websocket.Dial(ctx, c.baseURL+path, &websocket.DialOptions{
+		HTTPClient: c.httpClient,
+		HTTPHeader: header,
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, &APIError{
+				StatusCode: resp.StatusCode,
+				Message:    string(body),
+				Method:     http.MethodGet,
+				Path:       path,
+			}
+		}
+		return nil, fmt.Errorf("websocket connection failed: %w", err)
+	}
+	// Console output can arrive in bursts larger than the 32KiB default
+	conn.SetReadLimit(-1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webSocketStream{conn: conn, ctx: ctx, cancel: cancel}, nil
+}
+
+// webSocketStream adapts a WebSocket to an io.ReadWriteCloser. Messages of
+// either type are read as one continuous stream and writes are sent as binary
+// messages.
+type webSocketStream struct {
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	readMu sync.Mutex
+	reader io.Reader
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Read implements io.Reader. It returns io.EOF once the server closes the connection.
+func (s *webSocketStream) Read(p []byte) (int, error) {
+	s.readMu.Lock()
+	defer s.readMu.Unlock()
+
+	for {
+		if s.reader == nil {
+			_, reader, err := s.conn.Reader(s.ctx)
+			if err != nil {
+				if websocket.CloseStatus(err) != -1 || errors.Is(err, io.EOF) || s.ctx.Err() != nil {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			s.reader = reader
+		}
+
+		n, err := s.reader.Read(p)
+		if errors.Is(err, io.EOF) {
+			s.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Write implements io.Writer
+func (s *webSocketStream) Write(p []byte) (int, error) {
+	if err := s.conn.Write(s.ctx, websocket.MessageBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements io.Closer
+func (s *webSocketStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.conn.Close(websocket.StatusNormalClosure, "")
+		s.cancel()
+	})
+	return s.closeErr
+}
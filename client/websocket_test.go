@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hotaisle-cli/internal/log"
+
+	"github.com/coder/websocket"
+)
+
+// newConsoleServer serves the console of vm-1, which writes a login prompt
+// and echoes the first input
+func newConsoleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/teams/my-team/virtual_machines/vm-1/console/" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "secret" {
+			t.Errorf("Authorization = %q, want %q", got, "secret")
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept: %v", err)
+			return
+		}
+		defer conn.CloseNow()
+
+		ctx := r.Context()
+		if err := conn.Write(ctx, websocket.MessageText, []byte("login: ")); err != nil {
+			t.Errorf("write: %v", err)
+			return
+		}
+		_, input, err := conn.Read(ctx)
+		if err != nil {
+			t.Errorf("read: %v", err)
+			return
+		}
+		_ = conn.Write(ctx, websocket.MessageBinary, append([]byte("echo "), input...))
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// checkConsole runs the session of a console of newConsoleServer
+func checkConsole(t *testing.T, c *Client) {
+	t.Helper()
+	console, err := c.VirtualMachines().Console(context.Background(), "my-team", "vm-1")
+	if err != nil {
+		t.Fatalf("Console() error = %v", err)
+	}
+	defer console.Close()
+
+	buf := make([]byte, 7)
+	if _, err := io.ReadFull(console, buf); err != nil || string(buf) != "login: " {
+		t.Fatalf("read prompt = %q, %v", buf, err)
+	}
+	if _, err := console.Write([]byte("root\r")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	rest, err := io.ReadAll(console)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(rest) != "echo root\r" {
+		t.Errorf("read after input = %q, want %q", rest, "echo root\r")
+	}
+}
+
+func TestConsole(t *testing.T) {
+	server := newConsoleServer(t)
+	checkConsole(t, NewClient(WithBaseURL(server.URL), WithToken("secret")))
+}
+
+func TestConsole_TraceLogging(t *testing.T) {
+	server := newConsoleServer(t)
+	var out strings.Builder
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: log.LevelTrace}))
+	checkConsole(t, NewClient(WithBaseURL(server.URL), WithToken("secret"), WithLogger(logger)))
+
+	got := out.String()
+	for _, want := range []string{"path=/teams/my-team/virtual_machines/vm-1/console/", "status=101", "response-headers="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "login") || strings.Contains(got, "response-body") {
+		t.Errorf("log %q contains the console output", got)
+	}
+}
+
+func TestConsoleRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"forbidden"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL))
+	_, err := c.VirtualMachines().Console(context.Background(), "my-team", "vm-1")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Console() error = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, http.StatusForbidden)
+	}
+}
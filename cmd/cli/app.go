@@ -6,8 +6,10 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"hotaisle-cli/client"
 	"hotaisle-cli/internal/api"
 	"hotaisle-cli/internal/config"
 	"hotaisle-cli/internal/log"
@@ -22,7 +24,7 @@ func Run() {
 	if err := runApp(ctx); err != nil {
 		printError(err)
 		stop()
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	stop()
@@ -45,33 +47,74 @@ type App struct {
 	AppCli *cli.Command
 	Config *config.Config
 	Client *api.Client
+	// Output is the --output format used by printOutput
+	Output string
+
+	// yes answers confirmations, set by --yes
+	yes bool
+	// dryRun prints mutating requests instead of sending them, set by --dry-run
+	dryRun bool
+
+	// retries and retryUnsafe override the retry settings of the profile when
+	// the global flags are set
+	retries     *int
+	retryUnsafe *bool
+
+	// connection overrides the connection settings of the profile when the
+	// global flags are set
+	connection connectionSettings
+	// connectionOpts are the client options of the connection settings in
+	// use, set by applyConfig
+	connectionOpts []client.Option
 }
 
 func makeCommands(app *App) []*cli.Command {
 	return []*cli.Command{
 		newCommandConfig(app),
+		newCommandLogin(app),
+		newCommandLogout(app),
 		newCommandUser(app),
 		newCommandTeam(app),
 		newCommandBareMetal(app),
 		newCommandVirtualMachine(app),
+		newCommandInventory(app),
+		newCommandCopy(app),
+		newCommandSSHConfig(app),
+		newCommandPlan(app),
+		newCommandApply(app),
+		newCommandDev(app),
 	}
 }
 
 func makeApp() (*App, error) {
 	app := &App{}
 
-	cfg, err := config.Load(nil)
+	// Like the profile below, the config file is needed before the command
+	// line is parsed, so that the profile is looked up in the right file
+	var configFile *string
+	if path := globalConfigFile(os.Args[1:]); path != "" {
+		configFile = &path
+	}
+	config.Passphrase = askPassphrase
+	cfg, err := config.Load(configFile)
 	if err != nil {
 		return nil, err
 	}
 	app.Config = cfg
 
-	level := app.Config.LogLevel
-	if err := setupLogging(level); err != nil {
+	// The profile has to be selected before the commands are built, since
+	// they take the default team from it.
+	profile := globalFlagValue(os.Args[1:], "profile")
+	if profile == "" {
+		profile = os.Getenv("HOTAISLE_PROFILE")
+	}
+	if err := app.Config.UseProfile(profile); err != nil {
 		return nil, err
 	}
 
-	app.Client = api.NewClient(app.Config.ApiToken, Version)
+	if err := app.applyConfig(); err != nil {
+		return nil, err
+	}
 
 	app.AppCli = &cli.Command{
 		Usage: "Manage Hot Aisle resources from your terminal.",
@@ -94,6 +137,9 @@ func makeApp() (*App, error) {
 					}
 					app.Config = cfg
 					slog.Info("Loaded config", "file", configFile)
+					if err := app.applyConfig(); err != nil {
+						return err
+					}
 
 					// commands have a dependency on app.Config
 					app.AppCli.Commands = makeCommands(app)
@@ -101,6 +147,76 @@ func makeApp() (*App, error) {
 					return nil
 				},
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "Config profile to use instead of the active profile",
+				Sources: cli.EnvVars("HOTAISLE_PROFILE"),
+				Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+					if err := app.Config.UseProfile(s); err != nil {
+						return err
+					}
+					slog.Debug("Using profile", "profile", s)
+					return app.applyConfig()
+				},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format: table, wide, json, jsonl, yaml, go-template=<template> or jsonpath=<expression>",
+				Value:   defaultOutput,
+				Sources: cli.EnvVars("HOTAISLE_OUTPUT"),
+				Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+					if _, _, err := parseOutput(s); err != nil {
+						return err
+					}
+					app.Output = s
+					return nil
+				},
+			},
+			&cli.IntFlag{
+				Name:    "retries",
+				Usage:   "Number of times a request that failed with a transient error is retried",
+				Value:   app.retryPolicy().MaxAttempts - 1,
+				Sources: cli.EnvVars("HOTAISLE_RETRIES"),
+				Action: func(ctx context.Context, cmd *cli.Command, n int) error {
+					if n < 0 {
+						return fmt.Errorf("invalid retries %d, must not be negative", n)
+					}
+					app.retries = &n
+					return app.applyConfig()
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "yes",
+				Usage: "Answer yes to confirmations, needed by destructive commands when stdin is not a terminal",
+				Action: func(ctx context.Context, cmd *cli.Command, b bool) error {
+					app.yes = b
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the requests that would change anything as curl commands instead of sending them",
+				Action: func(ctx context.Context, cmd *cli.Command, b bool) error {
+					app.dryRun = b
+					return app.applyConfig()
+				},
+			},
+			&cli.BoolFlag{
+				Name:    "retry-unsafe",
+				Usage:   "Also retry POST and PATCH requests, which may then be applied twice",
+				Sources: cli.EnvVars("HOTAISLE_RETRY_UNSAFE"),
+				Action: func(ctx context.Context, cmd *cli.Command, b bool) error {
+					app.retryUnsafe = &b
+					return app.applyConfig()
+				},
+			},
+			connectionFlag(&app.connection.BaseURL, "base-url", "API base URL, e.g. of a staging endpoint", app),
+			connectionFlag(&app.connection.RequestTimeout, "request-timeout", "How long a request may take, its retries included, e.g. 90s", app),
+			connectionFlag(&app.connection.HTTPSProxy, "https-proxy", "URL of the proxy to send the requests through, instead of the one of HTTPS_PROXY", app),
+			connectionFlag(&app.connection.CABundle, "ca-bundle", "PEM file of certificates to trust on top of the system ones", app),
+			connectionFlag(&app.connection.ClientCert, "client-cert", "PEM file of a client certificate for mutual TLS", app),
+			connectionFlag(&app.connection.ClientKey, "client-key", "PEM file of the key of --client-cert", app),
 		},
 		Commands: makeCommands(app),
 	}
@@ -108,6 +224,106 @@ func makeApp() (*App, error) {
 	return app, nil
 }
 
+// applyConfig sets up logging and the API client from the settings of the
+// profile in use
+func (app *App) applyConfig() error {
+	if err := setupLogging(app.Config.LogLevel); err != nil {
+		return err
+	}
+	opts, err := app.connectionSettings().clientOptions()
+	if err != nil {
+		return err
+	}
+	app.connectionOpts = opts
+	app.Client = app.newAPIClient()
+	return nil
+}
+
+// connectionFlag is a global flag overriding a connection setting of the
+// profile, also set by its HOTAISLE_ environment variable
+func connectionFlag(setting *string, name, usage string, app *App) cli.Flag {
+	return &cli.StringFlag{
+		Name:    name,
+		Usage:   usage,
+		Sources: cli.EnvVars("HOTAISLE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))),
+		Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+			*setting = s
+			return app.applyConfig()
+		},
+	}
+}
+
+// defaultRetries is the number of retries used when neither the profile nor the flags set one
+const defaultRetries = client.DefaultMaxAttempts - 1
+
+// retryPolicy returns the retry policy of the profile in use, overridden by the global flags
+func (app *App) retryPolicy() client.RetryPolicy {
+	policy := client.DefaultRetryPolicy()
+
+	retries := defaultRetries
+	if app.Config.Retries != nil {
+		retries = *app.Config.Retries
+	}
+	if app.retries != nil {
+		retries = *app.retries
+	}
+	policy.MaxAttempts = retries + 1
+
+	policy.RetryNonIdempotent = app.Config.RetryUnsafe
+	if app.retryUnsafe != nil {
+		policy.RetryNonIdempotent = *app.retryUnsafe
+	}
+	return policy
+}
+
+// newAPIClient creates an API client for the settings of the profile in use
+// and the global flags
+func (app *App) newAPIClient() *api.Client {
+	opts := []client.Option{client.WithRetryPolicy(app.retryPolicy())}
+	if baseURL := app.connectionSettings().BaseURL; baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	opts = append(opts, app.connectionOpts...)
+	if app.dryRun {
+		opts = append(opts, client.WithDryRun(os.Stderr))
+	}
+	// The token is read when the first request is made, since reading it
+	// may ask for a passphrase
+	opts = append(opts, client.WithTokenFunc(app.Config.Token))
+	return api.NewClient("", Version, opts...)
+}
+
+// globalConfigFile returns the config file given by --config-file, -c or
+// HOTAISLE_CONFIG_FILE, or "" for the default one
+func globalConfigFile(args []string) string {
+	if path := globalFlagValue(args, "config-file"); path != "" {
+		return path
+	}
+	if path := globalFlagValue(args, "c"); path != "" {
+		return path
+	}
+	return os.Getenv("HOTAISLE_CONFIG_FILE")
+}
+
+// globalFlagValue returns the value of a global flag from the raw command line,
+// for settings that are needed before the command line is parsed
+func globalFlagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, prefix := range []string{"-", "--"} {
+			if arg == prefix+name && i+1 < len(args) {
+				return args[i+1]
+			}
+			if value, ok := strings.CutPrefix(arg, prefix+name+"="); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
 // setupLogging initializes the logging configuration
 func setupLogging(level string) error {
 	// Set up logging
@@ -121,9 +337,12 @@ func setupLogging(level string) error {
 	return nil
 }
 
-// printError prints an error message to stderr
+// printError prints an error message to stderr, followed by a hint on how to fix it if there is one
 func printError(err error) {
-	printErrorf("Error: %v\n", err)
+	printErrorf("Error: %s\n", errorMessage(err))
+	if hint := errorHint(err); hint != "" {
+		printErrorf("Hint: %s\n", hint)
+	}
 }
 
 // printErrorf prints a formatted error message to stderr
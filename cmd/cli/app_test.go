@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,7 +11,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
 	"hotaisle-cli/internal/config"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
 
 	"github.com/urfave/cli/v3"
 )
@@ -31,6 +37,23 @@ func setupTestApp(t *testing.T) (*App, string) {
 	return app, tmpDir
 }
 
+// newCassetteApp returns a test app whose API requests are replayed from
+// testdata/cassettes/<name>.json. With HOTAISLE_RECORD=1 they are recorded
+// instead, with the token of HOTAISLE_API_TOKEN, against the API at
+// HOTAISLE_RECORD_BASE_URL if set, such as a dev fake-server.
+func newCassetteApp(t *testing.T, name string) (*App, *test.Cassette) {
+	app, _ := setupTestApp(t)
+	cassette := test.NewCassette(t, filepath.Join("testdata", "cassettes", name+".json"))
+	app.Config.ApiToken = cassette.Value("HOTAISLE_API_TOKEN", "test-token")
+
+	opts := []client.Option{client.WithHTTPClient(cassette.Client())}
+	if baseURL := os.Getenv("HOTAISLE_RECORD_BASE_URL"); test.Recording() && baseURL != "" {
+		opts = append(opts, client.WithBaseURL(baseURL))
+	}
+	app.Client = api.NewClient(app.Config.ApiToken, Version, opts...)
+	return app, cassette
+}
+
 func TestMakeApp(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
@@ -87,9 +110,9 @@ func TestMakeAppCommands(t *testing.T) {
 	assert.NotNil(t, app)
 
 	assert.NotNil(t, app.AppCli.Commands)
-	assert.Len(t, app.AppCli.Commands, 5)
+	assert.Len(t, app.AppCli.Commands, 13)
 
-	expectedCommands := []string{"config", "user", "team", "bm", "vm"}
+	expectedCommands := []string{"config", "login", "logout", "user", "team", "bm", "vm", "inventory", "cp", "ssh-config", "plan", "apply", "dev"}
 	commandNames := []string{}
 	for _, cmd := range app.AppCli.Commands {
 		commandNames = append(commandNames, cmd.Name)
@@ -100,6 +123,16 @@ func TestMakeAppCommands(t *testing.T) {
 	}
 }
 
+// findGlobalFlag returns the global flag with the given name, or nil
+func findGlobalFlag(app *App, name string) cli.Flag {
+	for _, flag := range app.AppCli.Flags {
+		if flag.Names()[0] == name {
+			return flag
+		}
+	}
+	return nil
+}
+
 func TestMakeAppConfigFileFlag(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
@@ -109,7 +142,7 @@ func TestMakeAppConfigFileFlag(t *testing.T) {
 	assert.NotNil(t, app)
 
 	assert.NotNil(t, app.AppCli.Flags)
-	assert.Len(t, app.AppCli.Flags, 1)
+	assert.Len(t, app.AppCli.Flags, 13)
 
 	flag := app.AppCli.Flags[0]
 	stringFlag, ok := flag.(*cli.StringFlag)
@@ -118,6 +151,156 @@ func TestMakeAppConfigFileFlag(t *testing.T) {
 	assert.Contains(t, stringFlag.Value, config.Pretty)
 }
 
+func TestMakeAppOutputFlag(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	app, err := makeApp()
+	assert.NoError(t, err)
+	assert.NotNil(t, app)
+
+	stringFlag, ok := findGlobalFlag(app, "output").(*cli.StringFlag)
+	assert.True(t, ok)
+	assert.Contains(t, stringFlag.Aliases, "o")
+	assert.Equal(t, defaultOutput, stringFlag.Value)
+}
+
+func TestAppRetryPolicy(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	policy := app.retryPolicy()
+	assert.Equal(t, defaultRetries+1, policy.MaxAttempts)
+	assert.False(t, policy.RetryNonIdempotent)
+
+	retries := 5
+	app.Config.Retries = &retries
+	app.Config.RetryUnsafe = true
+	policy = app.retryPolicy()
+	assert.Equal(t, 6, policy.MaxAttempts)
+	assert.True(t, policy.RetryNonIdempotent)
+
+	noRetries := 0
+	safe := false
+	app.retries = &noRetries
+	app.retryUnsafe = &safe
+	policy = app.retryPolicy()
+	assert.Equal(t, 1, policy.MaxAttempts, "the flag overrides the profile")
+	assert.False(t, policy.RetryNonIdempotent)
+}
+
+func TestMakeAppWithRetriesEnv(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("HOTAISLE_RETRIES", "-1")
+
+	app, err := makeApp()
+	require.NoError(t, err)
+
+	err = app.AppCli.Run(context.Background(), []string{"hotaisle", "config", "get", "retries"})
+	assert.ErrorContains(t, err, "invalid retries")
+}
+
+func TestMakeAppProfileFlag(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	app, err := makeApp()
+	assert.NoError(t, err)
+	assert.NotNil(t, app)
+
+	stringFlag, ok := findGlobalFlag(app, "profile").(*cli.StringFlag)
+	assert.True(t, ok)
+	assert.Contains(t, stringFlag.Usage, "profile")
+}
+
+func TestMakeAppWithProfileEnv(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	configDir := filepath.Join(tmp, ".hotaisle")
+	require.NoError(t, os.MkdirAll(configDir, 0o700))
+	content := `{
+		"active_profile": "default",
+		"profiles": {
+			"default": {"api_token": "personal", "default_team": "mine"},
+			"ci": {"api_token": "ci-token", "default_team": "builds", "log_level": "warn"}
+		}
+	}`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(content), 0o600))
+
+	t.Setenv("HOTAISLE_PROFILE", "ci")
+	app, err := makeApp()
+	require.NoError(t, err)
+
+	assert.Equal(t, "ci", app.Config.ProfileName())
+	assert.Equal(t, "ci-token", app.Config.ApiToken)
+	assert.Equal(t, "builds", app.Config.DefaultTeam)
+	assert.Equal(t, "warn", app.Config.LogLevel)
+}
+
+func TestMakeAppWithUnknownProfile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("HOTAISLE_PROFILE", "missing")
+
+	app, err := makeApp()
+	assert.Error(t, err)
+	assert.Nil(t, app)
+}
+
+func TestMakeAppProfileFromConfigFileEnv(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	path := filepath.Join(tmp, "other.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"active_profile": "default", "profiles": {"default": {}, "ci": {"default_team": "ci-team"}}}`), 0o600))
+
+	t.Setenv("HOTAISLE_CONFIG_FILE", path)
+	t.Setenv("HOTAISLE_PROFILE", "ci")
+	app, err := makeApp()
+	require.NoError(t, err)
+	assert.Equal(t, "ci-team", app.Config.DefaultTeam)
+}
+
+func TestMakeAppConfigFileFlag_SavesToIt(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	path := filepath.Join(tmp, "custom.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+
+	app, err := makeApp()
+	require.NoError(t, err)
+	err = app.AppCli.Run(context.Background(), []string{"hotaisle", "--config-file", path, "config", "profiles", "create", "p2"})
+	require.NoError(t, err)
+
+	saved, err := config.Load(&path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "p2"}, saved.ProfileNames())
+	saved, err = config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, saved.ProfileNames(), "the default config file is left alone")
+}
+
+func TestGlobalFlagValue(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "separate value", args: []string{"--profile", "ci", "vm", "list"}, want: "ci"},
+		{name: "equals value", args: []string{"vm", "list", "--profile=ci"}, want: "ci"},
+		{name: "single dash", args: []string{"-profile", "ci"}, want: "ci"},
+		{name: "not set", args: []string{"vm", "list"}, want: ""},
+		{name: "missing value", args: []string{"--profile"}, want: ""},
+		{name: "after terminator", args: []string{"--", "--profile", "ci"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, globalFlagValue(tt.args, "profile"))
+		})
+	}
+}
+
 func TestMakeAppWithLogLevel(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
@@ -156,9 +339,9 @@ func TestMakeCommands(t *testing.T) {
 
 	commands := makeCommands(app)
 	assert.NotNil(t, commands)
-	assert.Len(t, commands, 5)
+	assert.Len(t, commands, 13)
 
-	expectedCommands := []string{"config", "user", "team", "bm", "vm"}
+	expectedCommands := []string{"config", "login", "logout", "user", "team", "bm", "vm", "inventory", "cp", "ssh-config", "plan", "apply", "dev"}
 	commandNames := []string{}
 	for _, cmd := range commands {
 		commandNames = append(commandNames, cmd.Name)
@@ -210,3 +393,95 @@ func TestMakeAppDefaultValues(t *testing.T) {
 	assert.Equal(t, "help", app.AppCli.DefaultCommand)
 	assert.True(t, app.AppCli.EnableShellCompletion)
 }
+
+func TestDryRun(t *testing.T) {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1000_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Config.BaseURL = srv.URL + "/api"
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	ctx := context.Background()
+	var req client.VMProvisionRequest
+	cores := uint64(2)
+	req.CPUCores = &cores
+	vm, err := app.Client.Api.VirtualMachines().Provision(ctx, "ml", req)
+	require.NoError(t, err)
+
+	app.dryRun = true
+	cmd, err := getCommand(app, virtualMachineCommands, "delete", map[string]string{"team": "ml", "vm": vm.Name})
+	require.NoError(t, err)
+	stderr := test.CaptureStderr(t, func() error {
+		// The requests are printed to the captured stderr
+		app.Client = app.newAPIClient()
+		executeCommand(t, cmd)
+		return nil
+	})
+
+	assert.Contains(t, stderr, "curl -X DELETE '"+srv.URL+"/api/teams/ml/virtual_machines/"+vm.Name+"/'")
+	assert.Contains(t, stderr, "-H 'Authorization: REDACTED'")
+	assert.NotContains(t, stderr, fake.Token())
+	vms, err := app.Client.Api.VirtualMachines().List(ctx, "ml")
+	require.NoError(t, err)
+	assert.Len(t, vms, 1, "the VM is still there, and GET requests are sent")
+}
+
+func TestDryRun_SkipsWaitAndResult(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.yes = true
+	fake := fakeapi.New(fakeapi.WithTransitionTime(0), fakeapi.WithInstallStageTime(0))
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1000_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Config.BaseURL = srv.URL + "/api"
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	ctx := context.Background()
+	var req client.VMProvisionRequest
+	cores := uint64(2)
+	req.CPUCores = &cores
+	vm, err := app.Client.Api.VirtualMachines().Provision(ctx, "ml", req)
+	require.NoError(t, err)
+	server, err := app.Client.Api.BareMetal().Reserve(ctx, "ml", client.BareMetalServerReservation{
+		Specs: client.BareMetalServerSpecs{CPUCores: 104},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		def   commandDef
+		path  string
+		flags map[string]string
+	}{
+		{name: "provision", def: virtualMachineCommands, path: "provision", flags: map[string]string{"team": "ml", "cpu-cores": "2"}},
+		{name: "provision --wait", def: virtualMachineCommands, path: "provision", flags: map[string]string{"team": "ml", "cpu-cores": "2", "wait": "true"}},
+		{name: "stop --wait", def: virtualMachineCommands, path: "stop", flags: map[string]string{"team": "ml", "vm": vm.Name, "wait": "true", "wait-timeout": "10ms"}},
+		{name: "reboot --wait", def: virtualMachineCommands, path: "reboot", flags: map[string]string{"team": "ml", "vm": vm.Name, "wait": "true", "wait-timeout": "10ms"}},
+		{name: "reserve", def: bareMetalCommands, path: "reserve", flags: map[string]string{"team": "ml", "cpu-cores": "104", "ram-gb": "2048", "disk-gb": "30720"}},
+		{name: "reinstall --follow", def: bareMetalCommands, path: "reinstall", flags: map[string]string{"team": "ml", "server": server.Name, "follow": "true"}},
+		{name: "team create", def: teamCommands, path: "create", flags: map[string]string{"handle": "new", "name": "New"}},
+	}
+	app.dryRun = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := getCommand(app, tt.def, tt.path, tt.flags)
+			require.NoError(t, err)
+			var stdout string
+			stderr := test.CaptureStderr(t, func() error {
+				app.Client = app.newAPIClient()
+				stdout = test.CaptureStdout(t, func() error {
+					return cmd.Action(ctx, cmd)
+				})
+				return nil
+			})
+			assert.Contains(t, stderr, "curl -X POST")
+			assert.NotContains(t, stdout, "{", "there is no result to print")
+		})
+	}
+
+	vms, err := app.Client.Api.VirtualMachines().List(ctx, "ml")
+	require.NoError(t, err)
+	assert.Len(t, vms, 1)
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotaisle-cli/client"
+
+	"github.com/urfave/cli/v3"
+)
+
+// errBalanceAlert is returned by `team balance watch --check` when the balance needs attention
+var errBalanceAlert = errors.New("balance alert")
+
+// Statuses of a balance alert
+const (
+	balanceStatusOK    = "ok"
+	balanceStatusAlert = "alert"
+)
+
+// balanceAlert is the state of a team balance, as sent to the alert sinks
+type balanceAlert struct {
+	Team                string     `json:"team"`
+	Status              string     `json:"status"`
+	Reasons             []string   `json:"reasons,omitempty"`
+	AvailableBalance    int64      `json:"available_balance"`
+	MinimumBalance      int64      `json:"minimum_balance"`
+	HourlyRate          int64      `json:"hourly_rate"`
+	EstimatedRunoutTime *time.Time `json:"estimated_runout_time,omitempty"`
+	Time                time.Time  `json:"time"`
+}
+
+// message sums up an alert in one line
+func (a balanceAlert) message() string {
+	if a.Status == balanceStatusOK {
+		return fmt.Sprintf("Balance of team %s is back to normal: %s", a.Team, formatCents(a.AvailableBalance))
+	}
+	return fmt.Sprintf("Balance of team %s needs attention: %s", a.Team, strings.Join(a.Reasons, ", "))
+}
+
+// balanceThresholds decide when a balance needs attention. Zero values are off.
+type balanceThresholds struct {
+	RunoutWithin time.Duration
+	Below        int64
+}
+
+// checkBalance returns the state of a balance against the thresholds. Reaching
+// the minimum balance of the team always raises an alert.
+func checkBalance(team string, balance client.BalanceInfo, th balanceThresholds, now time.Time) balanceAlert {
+	alert := balanceAlert{
+		Team:                team,
+		Status:              balanceStatusOK,
+		AvailableBalance:    balance.AvailableBalance,
+		MinimumBalance:      balance.MinimumBalance,
+		HourlyRate:          balance.HourlyRate,
+		EstimatedRunoutTime: balance.EstimatedRunoutTime,
+		Time:                now,
+	}
+	if balance.AvailableBalance <= balance.MinimumBalance && (balance.MinimumBalance > 0 || balance.HourlyRate > 0) {
+		alert.Reasons = append(alert.Reasons, fmt.Sprintf("balance of %s reached the minimum balance of %s",
+			formatCents(balance.AvailableBalance), formatCents(balance.MinimumBalance)))
+	}
+	if th.Below > 0 && balance.AvailableBalance < th.Below {
+		alert.Reasons = append(alert.Reasons, fmt.Sprintf("balance of %s is below %s",
+			formatCents(balance.AvailableBalance), formatCents(th.Below)))
+	}
+	if th.RunoutWithin > 0 && balance.EstimatedRunoutTime != nil {
+		if left := balance.EstimatedRunoutTime.Sub(now); left <= th.RunoutWithin {
+			alert.Reasons = append(alert.Reasons, fmt.Sprintf("balance runs out in %s, within %s",
+				formatRunout(max(left, 0)), formatRunout(th.RunoutWithin)))
+		}
+	}
+	if len(alert.Reasons) > 0 {
+		alert.Status = balanceStatusAlert
+	}
+	return alert
+}
+
+// alertSink delivers balance alerts
+type alertSink interface {
+	send(ctx context.Context, alert balanceAlert) error
+}
+
+// stderrSink writes alerts to stderr
+type stderrSink struct{}
+
+func (stderrSink) send(_ context.Context, alert balanceAlert) error {
+	printErrorf("%s %s\n", alert.Time.Format(time.RFC3339), alert.message())
+	return nil
+}
+
+// webhookSink POSTs alerts as JSON to a URL
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s webhookSink) send(ctx context.Context, alert balanceAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// commandSink runs a shell command for each alert, with the alert as JSON on
+// stdin and its main fields in HOTAISLE_ALERT_* environment variables
+type commandSink struct {
+	command string
+}
+
+func (s commandSink) send(ctx context.Context, alert balanceAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	c := exec.CommandContext(ctx, shell, flag, s.command)
+	c.Stdin = bytes.NewReader(body)
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(),
+		"HOTAISLE_ALERT_TEAM="+alert.Team,
+		"HOTAISLE_ALERT_STATUS="+alert.Status,
+		"HOTAISLE_ALERT_MESSAGE="+alert.message(),
+		"HOTAISLE_ALERT_BALANCE="+strconv.FormatInt(alert.AvailableBalance, 10),
+	)
+	return c.Run()
+}
+
+// desktopSink shows alerts as desktop notifications
+type desktopSink struct{}
+
+func (desktopSink) send(ctx context.Context, alert balanceAlert) error {
+	title := "Hot Aisle team " + alert.Team
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(alert.message()), strconv.Quote(title))
+		return exec.CommandContext(ctx, "osascript", "-e", script).Run()
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.CommandContext(ctx, "notify-send", title, alert.message()).Run()
+	}
+	return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+}
+
+// balanceWatchFlags are the flags of `team balance watch`, which also takes
+// --handle from `team balance`
+var balanceWatchFlags = []flagDef{
+	{Name: "interval", Usage: "Time between checks", Value: "5m"},
+	{Name: "runout-hours", Usage: "Alert when the balance runs out within this many hours, 0 to turn off", Value: "24"},
+	{Name: "below-cents", Usage: "Alert when the balance goes below this many cents"},
+	{Name: "webhook", Usage: "POST alerts as JSON to this URL"},
+	{Name: "exec", Usage: "Run this shell command for each alert, with the alert as JSON on stdin"},
+	{Name: "desktop", Usage: "Show alerts as desktop notifications", Bool: true},
+	{Name: "check", Usage: "Check once and exit non-zero if the balance needs attention, for cron or monitoring", Bool: true},
+}
+
+// parseBalanceThresholds reads the thresholds of `team balance watch`
+func parseBalanceThresholds(cmd *cli.Command) (balanceThresholds, error) {
+	var th balanceThresholds
+	hours, err := strconv.ParseFloat(cmd.String("runout-hours"), 64)
+	if err != nil || hours < 0 {
+		return th, fmt.Errorf("invalid runout-hours %q, must be a non-negative number", cmd.String("runout-hours"))
+	}
+	th.RunoutWithin = time.Duration(hours * float64(time.Hour))
+	if cmd.IsSet("below-cents") {
+		th.Below, err = strconv.ParseInt(cmd.String("below-cents"), 10, 64)
+		if err != nil || th.Below < 0 {
+			return th, fmt.Errorf("invalid below-cents %q, must be a non-negative number", cmd.String("below-cents"))
+		}
+	}
+	return th, nil
+}
+
+// balanceSinks returns the alert sinks set by the flags. Alerts always go to
+// stderr, except with --check where the alert is printed as the result.
+func balanceSinks(cmd *cli.Command) []alertSink {
+	var sinks []alertSink
+	if !cmd.Bool("check") {
+		sinks = append(sinks, stderrSink{})
+	}
+	if url := cmd.String("webhook"); url != "" {
+		sinks = append(sinks, webhookSink{url: url, client: &http.Client{Timeout: 30 * time.Second}})
+	}
+	if command := cmd.String("exec"); command != "" {
+		sinks = append(sinks, commandSink{command: command})
+	}
+	if cmd.Bool("desktop") {
+		sinks = append(sinks, desktopSink{})
+	}
+	return sinks
+}
+
+// sendAlert delivers an alert to every sink. A failing sink is only logged, so
+// that it does not stop the others or the watch.
+func sendAlert(ctx context.Context, sinks []alertSink, alert balanceAlert) {
+	for _, sink := range sinks {
+		if err := sink.send(ctx, alert); err != nil {
+			slog.Warn("Could not send balance alert", "team", alert.Team, "sink", fmt.Sprintf("%T", sink), "error", err)
+		}
+	}
+}
+
+// watchBalance polls the balance of a team until ctx is canceled, alerting
+// when it starts needing attention and again when it is back to normal.
+// With --check, it polls once instead.
+func watchBalance(app *App, ctx context.Context, cmd *cli.Command) error {
+	team := cmd.String("handle")
+	th, err := parseBalanceThresholds(cmd)
+	if err != nil {
+		return err
+	}
+	sinks := balanceSinks(cmd)
+
+	if cmd.Bool("check") {
+		balance, err := app.Client.Api.Teams().GetBalance(ctx, team)
+		if err != nil {
+			return err
+		}
+		alert := checkBalance(team, *balance, th, time.Now())
+		if err := printOutput(app, alert); err != nil {
+			return err
+		}
+		if alert.Status == balanceStatusOK {
+			return nil
+		}
+		sendAlert(ctx, sinks, alert)
+		return fmt.Errorf("%w: %s", errBalanceAlert, strings.Join(alert.Reasons, ", "))
+	}
+
+	interval, err := time.ParseDuration(cmd.String("interval"))
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid interval %q, must be a duration like 5m", cmd.String("interval"))
+	}
+
+	status := balanceStatusOK
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		balance, err := app.Client.Api.Teams().GetBalance(ctx, team)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			slog.Warn("Could not get the balance", "team", team, "error", errorMessage(err))
+		default:
+			alert := checkBalance(team, *balance, th, time.Now())
+			slog.Debug("Checked balance", "team", team, "balance", balance.AvailableBalance, "status", alert.Status)
+			if alert.Status != status {
+				sendAlert(ctx, sinks, alert)
+				status = alert.Status
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
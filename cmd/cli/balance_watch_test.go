@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBalance(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	in := func(d time.Duration) *time.Time {
+		runout := now.Add(d)
+		return &runout
+	}
+	th := balanceThresholds{RunoutWithin: 24 * time.Hour, Below: 100_00}
+
+	tests := []struct {
+		name    string
+		balance client.BalanceInfo
+		reasons []string
+	}{
+		{
+			name:    "healthy",
+			balance: client.BalanceInfo{AvailableBalance: 500_00, HourlyRate: 100, EstimatedRunoutTime: in(500 * time.Hour)},
+		},
+		{
+			name:    "nothing running",
+			balance: client.BalanceInfo{AvailableBalance: 500_00},
+		},
+		{
+			name:    "runs out soon",
+			balance: client.BalanceInfo{AvailableBalance: 500_00, HourlyRate: 25_00, EstimatedRunoutTime: in(20 * time.Hour)},
+			reasons: []string{"balance runs out in 20h0m0s, within 24h0m0s"},
+		},
+		{
+			name:    "below threshold",
+			balance: client.BalanceInfo{AvailableBalance: 99_99},
+			reasons: []string{"balance of $99.99 is below $100.00"},
+		},
+		{
+			name: "at the minimum balance",
+			balance: client.BalanceInfo{
+				AvailableBalance: 200_00, MinimumBalance: 200_00, HourlyRate: 100, EstimatedRunoutTime: in(0),
+			},
+			reasons: []string{
+				"balance of $200.00 reached the minimum balance of $200.00",
+				"balance runs out in 0s, within 24h0m0s",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := checkBalance("ml", tt.balance, th, now)
+			assert.Equal(t, tt.reasons, alert.Reasons)
+			if tt.reasons == nil {
+				assert.Equal(t, balanceStatusOK, alert.Status)
+			} else {
+				assert.Equal(t, balanceStatusAlert, alert.Status)
+			}
+		})
+	}
+
+	alert := checkBalance("ml", client.BalanceInfo{AvailableBalance: 1, HourlyRate: 100, EstimatedRunoutTime: in(time.Hour)}, balanceThresholds{}, now)
+	assert.Equal(t, balanceStatusOK, alert.Status, "thresholds turned off")
+}
+
+// runBalanceWatch runs `team balance watch`, which takes --handle from `team balance`
+func runBalanceWatch(ctx context.Context, app *App, args ...string) error {
+	return buildCommand(app, teamCommands).Run(ctx, append([]string{"team", "balance", "watch", "--handle", "ml"}, args...))
+}
+
+// balanceSequence serves the given balances in turn, repeating the last one
+func balanceSequence(t *testing.T, balances ...int64) *http.Client {
+	var calls atomic.Int32
+	return test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/teams/ml/balance/", req.URL.Path)
+		i := min(int(calls.Add(1))-1, len(balances)-1)
+		return test.NewJSONResponse(t, http.StatusOK, client.BalanceInfo{AvailableBalance: balances[i]}), nil
+	})
+}
+
+func TestTeamBalanceWatch(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(balanceSequence(t, 500_00, 50_00, 40_00, 500_00)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var alerts []balanceAlert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var alert balanceAlert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		w.WriteHeader(http.StatusNoContent)
+		w.(http.Flusher).Flush()
+		mu.Lock()
+		defer mu.Unlock()
+		if alerts = append(alerts, alert); len(alerts) == 2 {
+			cancel()
+		}
+	}))
+	defer webhook.Close()
+
+	var runErr error
+	stderr := test.CaptureStderr(t, func() error {
+		runErr = runBalanceWatch(ctx, app, "--interval", "1ms", "--below-cents", "10000", "--webhook", webhook.URL)
+		return nil
+	})
+	require.NoError(t, runErr)
+
+	// One alert when the balance drops, and one when it is back to normal
+	require.Len(t, alerts, 2)
+	assert.Equal(t, balanceStatusAlert, alerts[0].Status)
+	assert.Equal(t, int64(50_00), alerts[0].AvailableBalance)
+	assert.Equal(t, []string{"balance of $50.00 is below $100.00"}, alerts[0].Reasons)
+	assert.Equal(t, balanceStatusOK, alerts[1].Status)
+	assert.Contains(t, stderr, "Balance of team ml needs attention: balance of $50.00 is below $100.00\n")
+	assert.Contains(t, stderr, "Balance of team ml is back to normal: $500.00\n")
+	assert.NotContains(t, stderr, "$40.00")
+}
+
+func TestTeamBalanceWatch_Check(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command hook uses sh")
+	}
+	app, _ := setupTestApp(t)
+	out := filepath.Join(t.TempDir(), "alert.json")
+	run := func(balance int64) (string, error) {
+		app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(balanceSequence(t, balance)))
+		var runErr error
+		output := test.CaptureStdout(t, func() error {
+			runErr = runBalanceWatch(context.Background(), app, "--below-cents", "10000", "--check",
+				"--exec", `cat > "`+out+`"; echo " $HOTAISLE_ALERT_STATUS" >> "`+out+`"`)
+			return nil
+		})
+		return output, runErr
+	}
+
+	output, err := run(500_00)
+	require.NoError(t, err)
+	var alert balanceAlert
+	require.NoError(t, json.Unmarshal([]byte(output), &alert))
+	assert.Equal(t, balanceStatusOK, alert.Status)
+	assert.NoFileExists(t, out, "no alert, no hook")
+
+	_, err = run(50_00)
+	assert.ErrorIs(t, err, errBalanceAlert)
+	assert.EqualError(t, err, "balance alert: balance of $50.00 is below $100.00")
+	assert.Equal(t, exitBalanceAlert, exitCode(err))
+
+	hook, err := os.ReadFile(out)
+	require.NoError(t, err)
+	dec := json.NewDecoder(bytes.NewReader(hook))
+	require.NoError(t, dec.Decode(&alert))
+	assert.Equal(t, "ml", alert.Team)
+	assert.Equal(t, balanceStatusAlert, alert.Status)
+	assert.True(t, strings.HasSuffix(string(hook), "} alert\n"), "the status is in the environment")
+}
+
+func TestTeamBalanceWatch_InvalidFlags(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(balanceSequence(t, 500_00)))
+
+	for flag, want := range map[string]string{
+		"interval":     `invalid interval "soon", must be a duration like 5m`,
+		"runout-hours": `invalid runout-hours "soon", must be a non-negative number`,
+		"below-cents":  `invalid below-cents "soon", must be a non-negative number`,
+	} {
+		assert.EqualError(t, runBalanceWatch(context.Background(), app, "--"+flag, "soon"), want)
+	}
+}
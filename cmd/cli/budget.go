@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+	"hotaisle-cli/internal/pricing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// forceFlag lets provisioning go ahead over the budget of the team
+var forceFlag = flagDef{Name: "force", Usage: "Go ahead even if the budget of the team would be broken", Bool: true}
+
+// vmPrice looks up the price of the VM type matching a provision request
+func vmPrice(ctx context.Context, api *client.Client, team string, req client.VMProvisionRequest) (pricing.Price, error) {
+	types, err := api.VirtualMachines().GetAvailable(ctx, team)
+	if err != nil {
+		return pricing.Price{}, err
+	}
+	return pricing.MatchVM(types, req.VirtualMachineSpecs)
+}
+
+// bareMetalPrice looks up the price of the bare metal type matching a reservation
+func bareMetalPrice(ctx context.Context, api *client.Client, team string, req client.BareMetalServerReservation) (pricing.Price, error) {
+	types, err := api.BareMetal().GetAvailable(ctx, team)
+	if err != nil {
+		return pricing.Price{}, err
+	}
+	return pricing.MatchBareMetal(types, req.Specs)
+}
+
+// guardCost prints what a new resource costs the team, and refuses to go ahead
+// when it breaks the budget of the team, unless --force is given. When the
+// cost cannot be estimated, only teams with a budget are refused.
+func guardCost(app *App, ctx context.Context, cmd *cli.Command, team string, price pricing.Price, priceErr error) error {
+	budget := app.Config.Budgets[team]
+	force := cmd.Bool("force")
+
+	var balance *client.BalanceInfo
+	err := priceErr
+	if err == nil {
+		balance, err = app.Client.Api.Teams().GetBalance(ctx, team)
+	}
+	if err != nil {
+		if budget.IsZero() || force {
+			slog.Warn("Could not estimate the cost", "team", team, "error", errorMessage(err))
+			return nil
+		}
+		return fmt.Errorf("could not check the budget of team %s, pass --force to go ahead anyway: %w", team, err)
+	}
+
+	now := time.Now()
+	estimate := pricing.NewEstimate(price, *balance, now)
+	printEstimate(team, estimate, now)
+
+	if err := checkBudget(budget, estimate, now); err != nil {
+		if force {
+			slog.Warn("Going ahead over budget because of --force", "team", team, "reason", err)
+			return nil
+		}
+		return fmt.Errorf("%w, pass --force to go ahead anyway", err)
+	}
+	return nil
+}
+
+// printEstimate writes an estimate to stderr, leaving stdout to the created resource
+func printEstimate(team string, e pricing.Estimate, now time.Time) {
+	printErrorf("Estimated cost: %s/hr, billed for at least %d minutes (%s)\n",
+		formatCents(e.HourlyCost), e.MinimumMinutes, formatCents(e.MinimumCost))
+	runout := "never"
+	if e.RunoutTime != nil {
+		runout = fmt.Sprintf("%s (in %s)", formatTime(*e.RunoutTime), formatRunout(e.RunoutTime.Sub(now)))
+	}
+	printErrorf("Team %s: %s/hr in total, balance of %s runs out %s\n",
+		team, formatCents(e.HourlyRate), formatCents(e.AvailableBalance), runout)
+}
+
+// checkBudget returns why an estimate breaks a budget, or nil
+func checkBudget(budget config.Budget, e pricing.Estimate, now time.Time) error {
+	if budget.MaxHourlyRate > 0 && e.HourlyRate > budget.MaxHourlyRate {
+		return fmt.Errorf("the team would spend %s/hr, over its budget of %s/hr",
+			formatCents(e.HourlyRate), formatCents(budget.MaxHourlyRate))
+	}
+	if budget.MinRunout != "" && e.RunoutTime != nil {
+		minRunout, err := time.ParseDuration(budget.MinRunout)
+		if err != nil {
+			return fmt.Errorf("invalid min_runout %q in the budget: %w", budget.MinRunout, err)
+		}
+		if left := e.RunoutTime.Sub(now); left < minRunout {
+			return fmt.Errorf("the balance of the team would run out in %s, before the minimum of %s",
+				formatRunout(left), formatRunout(minRunout))
+		}
+	}
+	return nil
+}
+
+// formatRunout formats a duration to the hour, or to the minute below an hour
+func formatRunout(d time.Duration) string {
+	if d < time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	return d.Round(time.Hour).String()
+}
+
+// parseBudgetFlags updates a budget from the flags of `config set budget`
+func parseBudgetFlags(cmd *cli.Command, budget config.Budget) (config.Budget, error) {
+	if cmd.IsSet("max-hourly-cents") {
+		cents := cmd.String("max-hourly-cents")
+		n, err := strconv.ParseInt(cents, 10, 64)
+		if err != nil || n < 0 {
+			return budget, fmt.Errorf("invalid max-hourly-cents %q, must be a non-negative number", cents)
+		}
+		budget.MaxHourlyRate = n
+	}
+	if cmd.IsSet("min-runout") {
+		runout := cmd.String("min-runout")
+		if runout == "0" {
+			runout = ""
+		}
+		if runout != "" {
+			d, err := time.ParseDuration(runout)
+			if err != nil || d < 0 {
+				return budget, fmt.Errorf("invalid min-runout %q, must be a duration like 72h", runout)
+			}
+		}
+		budget.MinRunout = runout
+	}
+	if !cmd.IsSet("max-hourly-cents") && !cmd.IsSet("min-runout") {
+		return budget, errors.New("missing budget, set --max-hourly-cents or --min-runout")
+	}
+	return budget, nil
+}
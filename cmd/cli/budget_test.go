@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/internal/config"
+	"hotaisle-cli/internal/pricing"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBudgetTestApp returns an app using a fake API with team ml, which has $100
+func newBudgetTestApp(t *testing.T) *App {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 100_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Client = api.NewClient(fake.Token(), "1.0.0", client.WithBaseURL(srv.URL+"/api"))
+	return app
+}
+
+// provisionSmallVM runs `vm provision` for the $0.10/hr VM type of the fake
+// API, returning the error and what was written to stderr
+func provisionSmallVM(t *testing.T, app *App, force bool) (string, error) {
+	flags := map[string]string{"team": "ml", "cpu-cores": "2", "ram-gb": "8"}
+	if force {
+		flags["force"] = "true"
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "provision", flags)
+	require.NoError(t, err)
+
+	var runErr error
+	stderr := test.CaptureStderr(t, func() error {
+		test.CaptureStdout(t, func() error {
+			runErr = cmd.Action(context.Background(), cmd)
+			return nil
+		})
+		return nil
+	})
+	return stderr, runErr
+}
+
+func vmCount(t *testing.T, app *App) int {
+	vms, err := app.Client.Api.VirtualMachines().List(context.Background(), "ml")
+	require.NoError(t, err)
+	return len(vms)
+}
+
+func TestProvisionShowsEstimate(t *testing.T) {
+	app := newBudgetTestApp(t)
+
+	stderr, err := provisionSmallVM(t, app, false)
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "Estimated cost: $0.10/hr, billed for at least 30 minutes ($0.05)\n")
+	assert.Regexp(t, `Team ml: \$0\.10/hr in total, balance of \$100\.00 runs out \S+ \(in 1000h0m0s\)`, stderr)
+	assert.Equal(t, 1, vmCount(t, app))
+}
+
+func TestProvisionOverBudget(t *testing.T) {
+	app := newBudgetTestApp(t)
+	app.Config.Budgets = map[string]config.Budget{"ml": {MaxHourlyRate: 5}}
+
+	_, err := provisionSmallVM(t, app, false)
+	assert.EqualError(t, err, "the team would spend $0.10/hr, over its budget of $0.05/hr, pass --force to go ahead anyway")
+	assert.Equal(t, 0, vmCount(t, app))
+
+	_, err = provisionSmallVM(t, app, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, vmCount(t, app))
+}
+
+func TestProvisionUnderRunout(t *testing.T) {
+	app := newBudgetTestApp(t)
+	app.Config.Budgets = map[string]config.Budget{"ml": {MinRunout: "2000h"}}
+
+	_, err := provisionSmallVM(t, app, false)
+	assert.EqualError(t, err, "the balance of the team would run out in 1000h0m0s, before the minimum of 2000h0m0s, pass --force to go ahead anyway")
+	assert.Equal(t, 0, vmCount(t, app))
+}
+
+func TestReserveOverBudget(t *testing.T) {
+	app := newBudgetTestApp(t)
+	reserve := func() (string, error) {
+		cmd, err := getCommand(app, bareMetalCommands, "reserve", map[string]string{
+			"team": "ml", "cpu-cores": "104", "ram-gb": "2048", "disk-gb": "30720",
+		})
+		require.NoError(t, err)
+		var runErr error
+		stderr := test.CaptureStderr(t, func() error {
+			test.CaptureStdout(t, func() error {
+				runErr = cmd.Action(context.Background(), cmd)
+				return nil
+			})
+			return nil
+		})
+		return stderr, runErr
+	}
+
+	app.Config.Budgets = map[string]config.Budget{"ml": {MaxHourlyRate: 10_00}}
+	stderr, err := reserve()
+	assert.Contains(t, stderr, "Estimated cost: $15.92/hr, billed for at least 1440 minutes ($382.08)\n")
+	assert.Contains(t, stderr, "Team ml: $15.92/hr in total, balance of $100.00 runs out ")
+	assert.ErrorContains(t, err, "over its budget of $10.00/hr")
+
+	app.Config.Budgets = map[string]config.Budget{"ml": {MaxHourlyRate: 20_00}}
+	_, err = reserve()
+	require.NoError(t, err)
+	servers, err := app.Client.Api.BareMetal().List(context.Background(), "ml")
+	require.NoError(t, err)
+	assert.Len(t, servers, 1)
+}
+
+func TestProvisionWithoutEstimate(t *testing.T) {
+	app := newBudgetTestApp(t)
+	flags := map[string]string{"team": "ml", "cpu-cores": "64"}
+
+	// Without a budget, provisioning goes ahead and the API decides
+	cmd, err := getCommand(app, virtualMachineCommands, "provision", flags)
+	require.NoError(t, err)
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, client.ErrUnprocessable)
+
+	app.Config.Budgets = map[string]config.Budget{"ml": {MaxHourlyRate: 1000_00}}
+	cmd, err = getCommand(app, virtualMachineCommands, "provision", flags)
+	require.NoError(t, err)
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, pricing.ErrNoMatch)
+	assert.ErrorContains(t, err, "could not check the budget of team ml, pass --force")
+}
+
+func TestCheckBudget(t *testing.T) {
+	now := time.Now()
+	runout := now.Add(48 * time.Hour)
+	estimate := pricing.Estimate{HourlyRate: 500, RunoutTime: &runout}
+
+	assert.NoError(t, checkBudget(config.Budget{}, estimate, now))
+	assert.NoError(t, checkBudget(config.Budget{MaxHourlyRate: 500, MinRunout: "48h"}, estimate, now))
+	assert.ErrorContains(t, checkBudget(config.Budget{MaxHourlyRate: 499}, estimate, now), "over its budget of $4.99/hr")
+	assert.ErrorContains(t, checkBudget(config.Budget{MinRunout: "49h"}, estimate, now), "run out in 48h0m0s, before the minimum of 49h0m0s")
+	assert.ErrorContains(t, checkBudget(config.Budget{MinRunout: "soon"}, estimate, now), `invalid min_runout "soon"`)
+	assert.NoError(t, checkBudget(config.Budget{MinRunout: "49h"}, pricing.Estimate{}, now), "nothing is spent")
+}
@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/urfave/cli/v3"
+)
+
+// selectorFlags pick the targets of a bulk command, next to a repeated --vm or --server
+var selectorFlags = []flagDef{
+	{Name: "all", Usage: "Run on every machine of the team", Bool: true},
+	{Name: "name-match", Usage: "Run on machines whose name matches this glob, e.g. 'train-*'"},
+	{Name: "name-regex", Usage: "Run on machines whose name matches this regular expression"},
+	{Name: "description-match", Usage: "Run on machines whose description matches this regular expression"},
+	{Name: "parallel", Usage: "Maximum number of machines to act on at once", Value: "4"},
+}
+
+// vmBulkFlags are the flags of VM commands that run on one or many VMs
+func vmBulkFlags(extra ...flagDef) []flagDef {
+	flags := []flagDef{
+		{Name: "team", Usage: "Team handle", Required: true},
+		{Name: "vm", Usage: "VM name, repeat for several VMs", Multiple: true},
+	}
+	flags = append(flags, selectorFlags...)
+	return append(flags, extra...)
+}
+
+// serverBulkFlags are the flags of bare metal commands that run on one or many servers
+func serverBulkFlags(extra ...flagDef) []flagDef {
+	flags := []flagDef{
+		{Name: "team", Usage: "Team handle", Required: true},
+		{Name: "server", Usage: "Server name, repeat for several servers", Multiple: true},
+	}
+	flags = append(flags, selectorFlags...)
+	return append(flags, extra...)
+}
+
+// selector holds the selector flags of a bulk command
+type selector struct {
+	names       []string
+	all         bool
+	nameGlob    string
+	nameRegex   *regexp.Regexp
+	description *regexp.Regexp
+}
+
+// parseSelector reads the selector flags, with nameFlag holding the repeated names
+func parseSelector(cmd *cli.Command, nameFlag string) (selector, error) {
+	s := selector{
+		names:    cmd.StringSlice(nameFlag),
+		all:      cmd.Bool("all"),
+		nameGlob: cmd.String("name-match"),
+	}
+	if s.nameGlob != "" {
+		if _, err := path.Match(s.nameGlob, ""); err != nil {
+			return s, fmt.Errorf("invalid name-match %q: %w", s.nameGlob, err)
+		}
+	}
+	var err error
+	if re := cmd.String("name-regex"); re != "" {
+		if s.nameRegex, err = regexp.Compile(re); err != nil {
+			return s, fmt.Errorf("invalid name-regex %q: %w", re, err)
+		}
+	}
+	if re := cmd.String("description-match"); re != "" {
+		if s.description, err = regexp.Compile(re); err != nil {
+			return s, fmt.Errorf("invalid description-match %q: %w", re, err)
+		}
+	}
+	if len(s.names) == 0 && !s.filters() {
+		return s, fmt.Errorf("missing --%s, or a selector: --all, --name-match, --name-regex or --description-match", nameFlag)
+	}
+	return s, nil
+}
+
+// filters reports whether the selector needs the list of machines
+func (s selector) filters() bool {
+	return s.all || s.nameGlob != "" || s.nameRegex != nil || s.description != nil
+}
+
+// single reports whether the selector is a single name, which runs like before bulk commands existed
+func (s selector) single() bool {
+	return len(s.names) == 1 && !s.filters()
+}
+
+// match reports whether a machine matches all the filters
+func (s selector) match(name, description string) bool {
+	if s.nameGlob != "" {
+		if ok, _ := path.Match(s.nameGlob, name); !ok {
+			return false
+		}
+	}
+	if s.nameRegex != nil && !s.nameRegex.MatchString(name) {
+		return false
+	}
+	return s.description == nil || s.description.MatchString(description)
+}
+
+// machine is a VM or server a selector can match
+type machine struct {
+	name        string
+	description string
+}
+
+// targets returns the sorted names given with the name flag, together with
+// the machines matching the filters. list is only called when filters are set.
+func (s selector) targets(list func() ([]machine, error)) ([]string, error) {
+	names := slices.Clone(s.names)
+	if s.filters() {
+		machines, err := list()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range machines {
+			if s.match(m.name, m.description) {
+				names = append(names, m.name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// bulkAction is what a bulk command does to each target
+type bulkAction struct {
+	// Kind names a single target in messages, e.g. "VM"
+	Kind string
+	// Done is printed after running on a single target
+	Done string
+	// Run acts on one target, returning what to show as its result in bulk
+	// runs, or "" for "ok"
+	Run func(ctx context.Context, name string) (string, error)
+	// Wait, when set, runs after Run, e.g. to honor --wait
+	Wait func(ctx context.Context, name string) error
+	// ReadOnly actions, like power status, change nothing and run on several
+	// targets without asking
+	ReadOnly bool
+}
+
+// forEachVM runs an action on the VMs picked by --vm and the selector flags
+func forEachVM(app *App, ctx context.Context, cmd *cli.Command, action bulkAction) error {
+	return forEachTarget(app, ctx, cmd, "vm", action, func() ([]machine, error) {
+		vms, err := app.Client.Api.VirtualMachines().List(ctx, cmd.String("team"))
+		if err != nil {
+			return nil, err
+		}
+		machines := make([]machine, len(vms))
+		for i, vm := range vms {
+			machines[i] = machine{vm.Name, vm.Description}
+		}
+		return machines, nil
+	})
+}
+
+// forEachServer runs an action on the servers picked by --server and the selector flags
+func forEachServer(app *App, ctx context.Context, cmd *cli.Command, action bulkAction) error {
+	return forEachTarget(app, ctx, cmd, "server", action, func() ([]machine, error) {
+		servers, err := app.Client.Api.BareMetal().List(ctx, cmd.String("team"))
+		if err != nil {
+			return nil, err
+		}
+		machines := make([]machine, len(servers))
+		for i, server := range servers {
+			machines[i] = machine{server.Name, server.Description}
+		}
+		return machines, nil
+	})
+}
+
+// forEachTarget runs an action on a single named target as the command always
+// did, or on every selected target in parallel, printing a result per target.
+// Destructive commands ask to type the name of the target, or the number of
+// targets in bulk. Other actions that change several targets, such as power
+// actions on --all, ask to type the number of targets too.
+func forEachTarget(app *App, ctx context.Context, cmd *cli.Command, nameFlag string, action bulkAction, list func() ([]machine, error)) error {
+	sel, err := parseSelector(cmd, nameFlag)
+	if err != nil {
+		return err
+	}
+	if sel.single() {
+		name := sel.names[0]
+		if isDestructive(cmd) {
+			if err := confirmName(app, cmd, name); err != nil {
+				return err
+			}
+		}
+		if _, err := action.Run(ctx, name); err != nil {
+			return err
+		}
+		fmt.Println(action.Done)
+		if action.Wait != nil {
+			return action.Wait(ctx, name)
+		}
+		return nil
+	}
+
+	parallel, err := strconv.Atoi(cmd.String("parallel"))
+	if err != nil || parallel < 1 {
+		return fmt.Errorf("invalid parallel %q, must be a positive number", cmd.String("parallel"))
+	}
+	targets, err := sel.targets(list)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no %s matches the selectors", action.Kind)
+	}
+	switch {
+	case isDestructive(cmd):
+		if err := confirmCount(app, cmd, action.Kind, targets); err != nil {
+			return err
+		}
+	case !action.ReadOnly && len(targets) > 1:
+		if err := confirmTargets(app, cmd, action.Kind, targets); err != nil {
+			return err
+		}
+	}
+	return runBulk(app, ctx, targets, parallel, func(ctx context.Context, name string) (string, error) {
+		result, err := action.Run(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if action.Wait != nil {
+			if err := action.Wait(ctx, name); err != nil {
+				return "", err
+			}
+		}
+		if result == "" {
+			result = "ok"
+		}
+		return result, nil
+	})
+}
+
+// bulkResult is the outcome of a bulk command on one target
+type bulkResult struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+
+	err error
+}
+
+// runBulk runs fn on every target, at most parallel at once, prints a result
+// per target and returns an error when any failed. When all failures share an
+// exit code, the error keeps it.
+func runBulk(app *App, ctx context.Context, targets []string, parallel int, fn func(ctx context.Context, name string) (string, error)) error {
+	results := make([]bulkResult, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parallel, len(targets)) {
+		wg.Go(func() {
+			for i := range jobs {
+				result, err := fn(ctx, targets[i])
+				results[i] = bulkResult{Name: targets[i], Result: result}
+				if err != nil {
+					results[i] = bulkResult{Name: targets[i], Result: "failed", Error: errorMessage(err), err: err}
+				}
+			}
+		})
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := printOutput(app, results); err != nil {
+		return err
+	}
+
+	var failed []error
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.err)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if !slices.ContainsFunc(failed, func(err error) bool { return exitCode(err) != exitCode(failed[0]) }) {
+		return fmt.Errorf("%d of %d targets failed, the first with: %w", len(failed), len(targets), failed[0])
+	}
+	return fmt.Errorf("%d of %d targets failed", len(failed), len(targets))
+}
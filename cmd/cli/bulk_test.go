@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkTestEnv is a fake API with team ml running VMs described trainer, trainer and web
+type bulkTestEnv struct {
+	app *App
+	vms []string
+}
+
+func newBulkTestEnv(t *testing.T) *bulkTestEnv {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New(fakeapi.WithTransitionTime(0), fakeapi.WithInstallStageTime(0))
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1_000_000_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Client = api.NewClient(fake.Token(), "1.0.0", client.WithBaseURL(srv.URL+"/api"))
+
+	env := &bulkTestEnv{app: app}
+	ctx := context.Background()
+	for _, description := range []string{"trainer", "trainer", "web"} {
+		var req client.VMProvisionRequest
+		cores := uint64(2)
+		req.CPUCores = &cores
+		vm, err := app.Client.Api.VirtualMachines().Provision(ctx, "ml", req)
+		require.NoError(t, err)
+		require.NoError(t, app.Client.Api.VirtualMachines().Update(ctx, "ml", vm.Name, client.VirtualMachineUpdate{Description: description}))
+		env.vms = append(env.vms, vm.Name)
+	}
+	return env
+}
+
+// run runs a command with arguments, returning its stdout
+func (env *bulkTestEnv) run(t *testing.T, def commandDef, path string, args ...string) (string, error) {
+	cmdDef := def.findCommand(path)
+	require.NotNil(t, cmdDef)
+	var runErr error
+	output := test.CaptureStdout(t, func() error {
+		runErr = buildCommand(env.app, *cmdDef).Run(context.Background(), append([]string{cmdDef.Name, "--team", "ml"}, args...))
+		return nil
+	})
+	return output, runErr
+}
+
+func (env *bulkTestEnv) state(t *testing.T, vm string) string {
+	state, err := env.app.Client.Api.VirtualMachines().GetState(context.Background(), "ml", vm)
+	require.NoError(t, err)
+	return state.State
+}
+
+func TestBulkVMStop_DescriptionMatch(t *testing.T) {
+	env := newBulkTestEnv(t)
+	env.app.yes = true
+
+	output, err := env.run(t, virtualMachineCommands, "stop", "--description-match", "^trainer$", "--wait")
+	require.NoError(t, err)
+
+	var results []bulkResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	require.Len(t, results, 2)
+	for _, r := range results {
+		assert.Equal(t, "ok", r.Result)
+		assert.Equal(t, client.VMStateShutOff, env.state(t, r.Name))
+	}
+	assert.Equal(t, client.VMStateRunning, env.state(t, env.vms[2]), "the web VM is left alone")
+}
+
+func TestBulkVMReboot_RepeatedVM(t *testing.T) {
+	env := newBulkTestEnv(t)
+	env.app.Output = outputTable
+	env.app.yes = true
+
+	output, err := env.run(t, virtualMachineCommands, "reboot", "--vm", env.vms[0], "--vm", "missing", "--vm", env.vms[1])
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.ErrorContains(t, err, "1 of 3 targets failed, the first with: ")
+	assert.Equal(t, exitNotFound, exitCode(err), "all failures share the exit code")
+
+	assert.Regexp(t, `NAME\s+RESULT\s+ERROR\n`, output)
+	assert.Regexp(t, `missing\s+failed\s+.*HTTP 404`, output)
+	assert.Regexp(t, env.vms[0]+`\s+ok\s+-\n`, output)
+}
+
+func TestBulkVM_SingleTarget(t *testing.T) {
+	env := newBulkTestEnv(t)
+
+	output, err := env.run(t, virtualMachineCommands, "hard-reset", "--vm", env.vms[0])
+	require.NoError(t, err)
+	assert.Equal(t, "VM hard-reset command sent\n", output)
+}
+
+func TestBulkVMDelete_Confirmation(t *testing.T) {
+	env := newBulkTestEnv(t)
+
+	_, err := env.run(t, virtualMachineCommands, "delete", "--name-match", "*")
+	assert.EqualError(t, err, "stdin is not a terminal, pass --yes to confirm")
+	vms, err := env.app.Client.Api.VirtualMachines().List(context.Background(), "ml")
+	require.NoError(t, err)
+	assert.Len(t, vms, 3)
+
+	env.app.yes = true
+	_, err = env.run(t, virtualMachineCommands, "delete", "--all")
+	require.NoError(t, err)
+	vms, err = env.app.Client.Api.VirtualMachines().List(context.Background(), "ml")
+	require.NoError(t, err)
+	assert.Empty(t, vms)
+}
+
+func TestBulkServerPower(t *testing.T) {
+	env := newBulkTestEnv(t)
+	ctx := context.Background()
+	for range 2 {
+		_, err := env.app.Client.Api.BareMetal().Reserve(ctx, "ml", client.BareMetalServerReservation{
+			Specs: client.BareMetalServerSpecs{CPUCores: 104},
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := env.run(t, bareMetalCommands, "power.ac-reset", "--all")
+	assert.EqualError(t, err, "stdin is not a terminal, pass --yes to confirm")
+	_, err = env.run(t, bareMetalCommands, "power.force-shutdown", "--all")
+	assert.EqualError(t, err, "stdin is not a terminal, pass --yes to confirm")
+
+	env.app.yes = true
+	_, err = env.run(t, bareMetalCommands, "power.force-shutdown", "--all", "--parallel", "1")
+	require.NoError(t, err)
+
+	// Reading the power state changes nothing, so it does not ask
+	env.app.yes = false
+	output, err := env.run(t, bareMetalCommands, "power.status", "--all")
+	require.NoError(t, err)
+	var results []bulkResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, "Off", results[0].Result)
+	assert.Equal(t, "Off", results[1].Result)
+}
+
+func TestBulkVMHardReset_Confirmation(t *testing.T) {
+	env := newBulkTestEnv(t)
+
+	withTerminal(t, "2\n")
+	stderr := test.CaptureStderr(t, func() error {
+		_, err := env.run(t, virtualMachineCommands, "hard-reset", "--all")
+		assert.EqualError(t, err, "hard-reset canceled")
+		return nil
+	})
+	assert.Contains(t, stderr, "hard-reset will run on 3 VMs: ")
+	assert.Contains(t, stderr, "Type 3 to confirm: ")
+
+	withTerminal(t, "3\n")
+	output, err := env.run(t, virtualMachineCommands, "hard-reset", "--all")
+	require.NoError(t, err)
+	var results []bulkResult
+	require.NoError(t, json.Unmarshal([]byte(output), &results))
+	assert.Len(t, results, 3)
+}
+
+func TestBulkSelectorErrors(t *testing.T) {
+	env := newBulkTestEnv(t)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "missing --vm, or a selector: --all, --name-match, --name-regex or --description-match"},
+		{[]string{"--name-regex", "("}, `invalid name-regex "(": error parsing regexp: missing closing ): ` + "`(`"},
+		{[]string{"--name-match", "["}, `invalid name-match "[": syntax error in pattern`},
+		{[]string{"--all", "--parallel", "0"}, `invalid parallel "0", must be a positive number`},
+		{[]string{"--name-match", "nothing-*"}, "no VM matches the selectors"},
+	}
+	for _, tt := range tests {
+		_, err := env.run(t, virtualMachineCommands, "start", tt.args...)
+		assert.EqualError(t, err, tt.want)
+	}
+}
+
+func TestSelectorTargets(t *testing.T) {
+	machines := []machine{{"train-1", "gpu trainer"}, {"train-2", "cpu"}, {"web-1", "gpu web"}}
+	list := func() ([]machine, error) { return machines, nil }
+
+	tests := []struct {
+		name string
+		sel  selector
+		want []string
+	}{
+		{"names only", selector{names: []string{"b", "a"}}, []string{"a", "b"}},
+		{"all", selector{all: true}, []string{"train-1", "train-2", "web-1"}},
+		{"glob", selector{nameGlob: "train-*"}, []string{"train-1", "train-2"}},
+		{"filters combine", selector{nameGlob: "train-*", description: regexp.MustCompile("gpu")}, []string{"train-1"}},
+		{"names and filters", selector{names: []string{"web-1", "other"}, nameGlob: "train-*"}, []string{"other", "train-1", "train-2", "web-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sel.targets(list)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunBulk_Parallel(t *testing.T) {
+	app, _ := setupTestApp(t)
+	var inFlight, maxInFlight atomic.Int32
+	fn := func(ctx context.Context, name string) (string, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if name == "c" {
+			return "", errors.New("boom")
+		}
+		return "", nil
+	}
+
+	var err error
+	test.CaptureStdout(t, func() error {
+		err = runBulk(app, context.Background(), []string{"a", "b", "c", "d", "e"}, 2, fn)
+		return nil
+	})
+	assert.EqualError(t, err, "1 of 5 targets failed, the first with: boom")
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
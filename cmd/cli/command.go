@@ -2,8 +2,6 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 
 	"github.com/urfave/cli/v3"
 )
@@ -14,11 +12,17 @@ import (
 //
 //	{Name: "name", Usage: "User's full name", Required: true}
 //	{Name: "user-role", Usage: "User role (owner or user)", Value: "user"}
+//	{Name: "wait", Usage: "Wait for the VM to start", Bool: true}
+//	{Name: "file", Aliases: []string{"f"}, Usage: "Manifest file", Required: true}
+//	{Name: "vm", Usage: "VM name, repeat for several VMs", Multiple: true}
 type flagDef struct {
 	Name     string
+	Aliases  []string
 	Usage    string
 	Required bool
 	Value    string // Default value
+	Bool     bool   // Boolean switch, read with cmd.Bool
+	Multiple bool   // Repeatable, read with cmd.StringSlice
 }
 
 // commandDef defines a command and its subcommands declaratively.
@@ -37,7 +41,7 @@ type flagDef struct {
 //	                if err != nil {
 //	                    return err
 //	                }
-//	                return printJSON(resources)
+//	                return printOutput(app, resources)
 //	            },
 //	        },
 //	        {
@@ -53,7 +57,7 @@ type flagDef struct {
 //	                if err != nil {
 //	                    return err
 //	                }
-//	                return printJSON(resource)
+//	                return printOutput(app, resource)
 //	            },
 //	        },
 //	    },
@@ -65,11 +69,19 @@ type flagDef struct {
 //	    return buildCommand(app, myCommands)
 //	}
 type commandDef struct {
-	Name     string
-	Usage    string
-	Flags    []flagDef
-	Action   func(*App, context.Context, *cli.Command) error
-	Commands []commandDef
+	Name      string
+	Aliases   []string
+	Usage     string
+	ArgsUsage string // Positional arguments shown in help, e.g. "[-- command...]"
+	Hidden    bool   // Left out of help, for development tools
+	// Destructive names the flag holding the resource a command destroys. The
+	// command then asks to type that name first, unless --yes is given.
+	// Commands with a repeated flag ask in forEachVM or forEachServer, once
+	// their targets are known.
+	Destructive string
+	Flags       []flagDef
+	Action      func(*App, context.Context, *cli.Command) error
+	Commands    []commandDef
 }
 
 // findCommand looks up a command by path (e.g., "get", "ssh-keys.list", "api-keys.create")
@@ -103,6 +115,16 @@ func (def commandDef) findCommand(path string) *commandDef {
 	return nil
 }
 
+// multiple reports whether the named flag of the command is repeatable
+func (def commandDef) multiple(name string) bool {
+	for _, flag := range def.Flags {
+		if flag.Name == name {
+			return flag.Multiple
+		}
+	}
+	return false
+}
+
 func splitPath(path string) []string {
 	var result []string
 	current := ""
@@ -133,21 +155,14 @@ func joinPath(parts []string) string {
 	return result
 }
 
-// printJSON marshals a value to pretty-printed JSON and prints it
-func printJSON(v any) error {
-	prettyJSON, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return err
-	}
-	fmt.Print(string(prettyJSON))
-	return nil
-}
-
 // buildCommand recursively builds a cli.Command from a commandDef
 func buildCommand(app *App, def commandDef) *cli.Command {
 	cmd := &cli.Command{
-		Name:  def.Name,
-		Usage: def.Usage,
+		Name:      def.Name,
+		Aliases:   def.Aliases,
+		Usage:     def.Usage,
+		ArgsUsage: def.ArgsUsage,
+		Hidden:    def.Hidden,
 	}
 
 	if len(def.Flags) > 0 {
@@ -159,12 +174,32 @@ func buildCommand(app *App, def commandDef) *cli.Command {
 				flag.Usage = flag.Usage + " (uses default_team from config)"
 			}
 
+			if flag.Bool {
+				cmd.Flags[i] = &cli.BoolFlag{
+					Name:    flag.Name,
+					Aliases: flag.Aliases,
+					Usage:   flag.Usage,
+				}
+				continue
+			}
+
 			if flag.Required {
 				flag.Usage = flag.Usage + " (required)"
 			}
 
+			if flag.Multiple {
+				cmd.Flags[i] = &cli.StringSliceFlag{
+					Name:     flag.Name,
+					Aliases:  flag.Aliases,
+					Usage:    flag.Usage,
+					Required: flag.Required,
+				}
+				continue
+			}
+
 			cmd.Flags[i] = &cli.StringFlag{
 				Name:     flag.Name,
+				Aliases:  flag.Aliases,
 				Usage:    flag.Usage,
 				Required: flag.Required,
 				Value:    flag.Value,
@@ -172,8 +207,20 @@ func buildCommand(app *App, def commandDef) *cli.Command {
 		}
 	}
 
+	if def.Destructive != "" {
+		cmd.Metadata = map[string]any{destructiveKey: def.Destructive}
+	}
+
 	if def.Action != nil {
 		action := def.Action
+		if def.Destructive != "" && !def.multiple(def.Destructive) {
+			action = func(app *App, ctx context.Context, cmd *cli.Command) error {
+				if err := confirmName(app, cmd, cmd.String(def.Destructive)); err != nil {
+					return err
+				}
+				return def.Action(app, ctx, cmd)
+			}
+		}
 		cmd.Action = func(ctx context.Context, command *cli.Command) error {
 			return action(app, ctx, command)
 		}
@@ -2,8 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"strconv"
+	"time"
 
 	"hotaisle-cli/client"
 
@@ -25,7 +29,7 @@ var bareMetalCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(servers)
+				return printOutput(app, servers)
 			},
 		},
 		{
@@ -40,7 +44,7 @@ var bareMetalCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(server)
+				return printOutput(app, server)
 			},
 		},
 		{
@@ -52,6 +56,7 @@ var bareMetalCommands = commandDef{
 				{Name: "cpu-cores", Usage: "Required CPU cores", Required: true},
 				{Name: "ram-gb", Usage: "Required RAM in GB", Required: true},
 				{Name: "disk-gb", Usage: "Required Disk in GB", Required: true},
+				forceFlag,
 			},
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
 				cpuCores, err := strconv.ParseUint(cmd.String("cpu-cores"), 10, 64)
@@ -67,18 +72,24 @@ var bareMetalCommands = commandDef{
 					return fmt.Errorf("invalid disk-gb: %w", err)
 				}
 
-				resp, err := app.Client.Api.BareMetal().Reserve(ctx, cmd.String("team"), client.BareMetalServerReservation{
+				req := client.BareMetalServerReservation{
 					Description: cmd.String("description"),
 					Specs: client.BareMetalServerSpecs{
 						CPUCores:     cpuCores,
-						RAMCapacity:  ramGB,
-						DiskCapacity: diskGB,
+						RAMCapacity:  ramGB << 30,
+						DiskCapacity: diskGB << 30,
 					},
-				})
+				}
+				price, err := bareMetalPrice(ctx, app.Client.Api, cmd.String("team"), req)
+				if err := guardCost(app, ctx, cmd, cmd.String("team"), price, err); err != nil {
+					return err
+				}
+
+				resp, err := app.Client.Api.BareMetal().Reserve(ctx, cmd.String("team"), req)
 				if err != nil {
 					return err
 				}
-				return printJSON(resp)
+				return printResult(app, resp)
 			},
 		},
 		{
@@ -101,8 +112,9 @@ var bareMetalCommands = commandDef{
 			},
 		},
 		{
-			Name:  "delete",
-			Usage: "Release a bare metal server back to the pool.",
+			Name:        "delete",
+			Usage:       "Release a bare metal server back to the pool.",
+			Destructive: "server",
 			Flags: []flagDef{
 				{Name: "team", Usage: "Team handle", Required: true},
 				{Name: "server", Usage: "Server name", Required: true},
@@ -127,7 +139,7 @@ var bareMetalCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(available)
+				return printOutput(app, available)
 			},
 		},
 		{
@@ -136,130 +148,188 @@ var bareMetalCommands = commandDef{
 			Commands: []commandDef{
 				{
 					Name:  "status",
-					Usage: "Get current power state.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Get current power state of servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						state, err := app.Client.Api.BareMetal().GetPowerState(ctx, cmd.String("team"), cmd.String("server"))
+						sel, err := parseSelector(cmd, "server")
 						if err != nil {
 							return err
 						}
-						return printJSON(state)
+						if sel.single() {
+							state, err := app.Client.Api.BareMetal().GetPowerState(ctx, cmd.String("team"), sel.names[0])
+							if err != nil {
+								return err
+							}
+							return printOutput(app, state)
+						}
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind:     "server",
+							ReadOnly: true,
+							Run: func(ctx context.Context, server string) (string, error) {
+								state, err := app.Client.Api.BareMetal().GetPowerState(ctx, cmd.String("team"), server)
+								if err != nil {
+									return "", err
+								}
+								return state.State, nil
+							},
+						})
 					},
 				},
 				{
 					Name:  "on",
-					Usage: "Power on the server.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Power on servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().PowerOn(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("Power on command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "Power on command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().PowerOn(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 				{
 					Name:  "shutdown",
-					Usage: "Gracefully shutdown the server.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Gracefully shutdown servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().GracefulShutdown(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("Graceful shutdown command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "Graceful shutdown command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().GracefulShutdown(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 				{
 					Name:  "force-shutdown",
-					Usage: "Immediately power off the server.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Immediately power off servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().ForceShutdown(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("Force shutdown command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "Force shutdown command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().ForceShutdown(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 				{
 					Name:  "reboot",
-					Usage: "Warm reboot the server.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Warm reboot servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().WarmReboot(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("Warm reboot command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "Warm reboot command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().WarmReboot(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 				{
 					Name:  "cold-reboot",
-					Usage: "Cold reboot the server.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Usage: "Cold reboot servers.",
+					Flags: serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().ColdReboot(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("Cold reboot command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "Cold reboot command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().ColdReboot(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 				{
-					Name:  "ac-reset",
-					Usage: "Perform a complete AC reset.",
-					Flags: []flagDef{
-						{Name: "team", Usage: "Team handle", Required: true},
-						{Name: "server", Usage: "Server name", Required: true},
-					},
+					Name:        "ac-reset",
+					Usage:       "Perform a complete AC reset of servers.",
+					Destructive: "server",
+					Flags:       serverBulkFlags(),
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						err := app.Client.Api.BareMetal().ACReset(ctx, cmd.String("team"), cmd.String("server"))
-						if err != nil {
-							return err
-						}
-						fmt.Println("AC reset command sent")
-						return nil
+						return forEachServer(app, ctx, cmd, bulkAction{
+							Kind: "server",
+							Done: "AC reset command sent",
+							Run: func(ctx context.Context, server string) (string, error) {
+								return "", app.Client.Api.BareMetal().ACReset(ctx, cmd.String("team"), server)
+							},
+						})
 					},
 				},
 			},
 		},
 		{
-			Name:  "reinstall",
-			Usage: "Wipe all disks and reinstall the OS.",
+			Name:        "reinstall",
+			Usage:       "Wipe all disks and reinstall the OS.",
+			Destructive: "server",
 			Flags: []flagDef{
 				{Name: "team", Usage: "Team handle", Required: true},
 				{Name: "server", Usage: "Server name", Required: true},
+				{Name: "follow", Usage: "Follow the install until it finishes", Bool: true},
+				{Name: "stall-timeout", Usage: "Warn when --follow sees no install progress for this long", Value: defaultStallTimeout},
+				{Name: "follow-timeout", Usage: "Maximum time to follow the install with --follow", Value: defaultFollowTimeout},
 			},
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				follow, err := parseInstallFollowFlags(cmd)
+				if err != nil {
+					return err
+				}
+				started := time.Now()
 				server, err := app.Client.Api.BareMetal().Reinstall(ctx, cmd.String("team"), cmd.String("server"))
 				if err != nil {
 					return err
 				}
-				return printJSON(server)
+				if app.dryRun {
+					// Nothing was reinstalled, so there is no install to follow
+					return nil
+				}
+				if cmd.Bool("follow") {
+					// The install is told apart from the previous one with the
+					// time the API gave it, so that the local clock does not matter
+					since := started
+					if server.OSStatus != nil && !server.OSStatus.LastImagingUpdate.IsZero() {
+						since = server.OSStatus.LastImagingUpdate
+					}
+					server, err = followInstall(app, ctx, cmd.String("team"), cmd.String("server"), since, follow)
+					if err != nil {
+						return err
+					}
+				}
+				return printOutput(app, server)
+			},
+		},
+		{
+			Name:  "install-status",
+			Usage: "Show the progress of an OS install.",
+			Flags: []flagDef{
+				{Name: "team", Usage: "Team handle", Required: true},
+				{Name: "server", Usage: "Server name", Required: true},
+				{Name: "follow", Usage: "Follow the install until it finishes", Bool: true},
+				{Name: "stall-timeout", Usage: "Warn when --follow sees no install progress for this long", Value: defaultStallTimeout},
+				{Name: "follow-timeout", Usage: "Maximum time to follow the install with --follow", Value: defaultFollowTimeout},
+			},
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				follow, err := parseInstallFollowFlags(cmd)
+				if err != nil {
+					return err
+				}
+				var server *client.BareMetalServerDetails
+				if cmd.Bool("follow") {
+					server, err = followInstall(app, ctx, cmd.String("team"), cmd.String("server"), time.Time{}, follow)
+				} else {
+					server, err = app.Client.Api.BareMetal().Get(ctx, cmd.String("team"), cmd.String("server"))
+				}
+				if err != nil {
+					return err
+				}
+				if server.OSStatus == nil {
+					return fmt.Errorf("server %s has no OS install status", cmd.String("server"))
+				}
+				return printOutput(app, server.OSStatus)
 			},
 		},
 		{
@@ -274,7 +344,23 @@ var bareMetalCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(url)
+				return printOutput(app, url)
+			},
+		},
+		{
+			Name:      "ssh",
+			Usage:     "Connect to a server with ssh, running the command after -- if any.",
+			ArgsUsage: "[-- command...]",
+			Flags: append([]flagDef{
+				{Name: "team", Usage: "Team handle", Required: true},
+				{Name: "server", Usage: "Server name", Required: true},
+			}, sshFlags...),
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				target, err := serverSSHTarget(ctx, app.Client.Api, cmd.String("team"), cmd.String("server"))
+				if err != nil {
+					return err
+				}
+				return runSSH(app, ctx, cmd, target)
 			},
 		},
 		{
@@ -318,6 +404,54 @@ var bareMetalCommands = commandDef{
 	},
 }
 
+// defaultStallTimeout is the default of the --stall-timeout flag
+const defaultStallTimeout = "15m"
+
+// defaultFollowTimeout is the default of the --follow-timeout flag
+const defaultFollowTimeout = "2h"
+
+// installFollowFlags holds the --stall-timeout and --follow-timeout of an install command
+type installFollowFlags struct {
+	stallAfter time.Duration
+	timeout    time.Duration
+}
+
+// parseInstallFollowFlags parses the --follow flags of an install command,
+// before the install is changed
+func parseInstallFollowFlags(cmd *cli.Command) (installFollowFlags, error) {
+	stallAfter, err := time.ParseDuration(cmd.String("stall-timeout"))
+	if err != nil {
+		return installFollowFlags{}, fmt.Errorf("invalid stall-timeout: %w", err)
+	}
+	timeout, err := time.ParseDuration(cmd.String("follow-timeout"))
+	if err != nil {
+		return installFollowFlags{}, fmt.Errorf("invalid follow-timeout: %w", err)
+	}
+	return installFollowFlags{stallAfter: stallAfter, timeout: timeout}, nil
+}
+
+// followInstall polls an OS install until it is installed or failed, drawing a
+// stage progress bar on stderr and printing the time spent per stage at the end.
+// since is when a reinstall was started, see WaitForInstall.
+func followInstall(app *App, ctx context.Context, team, serverName string, since time.Time, follow installFollowFlags) (*client.BareMetalServerDetails, error) {
+	progress := newInstallProgress(os.Stderr, follow.stallAfter)
+	server, err := app.Client.Api.BareMetal().WaitForInstall(ctx, team, serverName, since, client.WaitConfig{Timeout: follow.timeout},
+		func(server *client.BareMetalServerDetails) {
+			progress.update(server.OSStatus)
+		},
+	)
+	if summaryErr := progress.summary(); summaryErr != nil {
+		slog.Debug("Failed to print install summary", "error", summaryErr)
+	}
+	if errors.Is(err, client.ErrOSInstallFailed) {
+		return server, fmt.Errorf("%w (last stage: %s)", err, progress.lastStage())
+	}
+	if err != nil {
+		return server, fmt.Errorf("following install of %s: %w", serverName, err)
+	}
+	return server, nil
+}
+
 func newCommandBareMetal(app *App) *cli.Command {
 	return buildCommand(app, bareMetalCommands)
 }
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"hotaisle-cli/client"
 	"hotaisle-cli/internal/api"
@@ -75,7 +77,7 @@ func TestBareMetalReserveCommand_Success(t *testing.T) {
 		},
 	}
 
-	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/", http.MethodPost, 200, mockResp)
+	mockClient := newCreateMockClient(t, "/api/teams/test-team/bare_metal/", mockResp)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
 
 	flags := map[string]string{
@@ -116,6 +118,7 @@ func TestBareMetalUpdateCommand_Success(t *testing.T) {
 
 func TestBareMetalDeleteCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/server-1/", http.MethodDelete, 200, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
@@ -268,6 +271,7 @@ func TestBareMetalPowerColdRebootCommand_Success(t *testing.T) {
 
 func TestBareMetalPowerACResetCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/server-1/power/ac_reset/", http.MethodPost, 200, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
@@ -285,6 +289,7 @@ func TestBareMetalPowerACResetCommand_Success(t *testing.T) {
 
 func TestBareMetalReinstallCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockServer := &client.BareMetalServerDetails{
 		BareMetalServer: client.BareMetalServer{
@@ -310,6 +315,164 @@ func TestBareMetalReinstallCommand_Success(t *testing.T) {
 	assert.Equal(t, "server-1", result.Name)
 }
 
+func TestBareMetalReinstallCommand_Follow(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.yes = true
+	// The clock of the API is behind, so its install ends before the local time
+	serverNow := time.Now().Add(-time.Hour)
+
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/api/teams/test-team/bare_metal/server-1/reinstall/":
+			return test.NewJSONResponse(t, http.StatusOK, client.BareMetalServerDetails{
+				BareMetalServer: client.BareMetalServer{Name: "server-1"},
+				OSStatus: &client.BareMetalServerlOSStatus{
+					OSStatus:          client.OSStatusShuttingDown,
+					LastImagingUpdate: serverNow,
+				},
+			}), nil
+		case req.Method == http.MethodGet && req.URL.Path == "/api/teams/test-team/bare_metal/server-1/":
+			return test.NewJSONResponse(t, http.StatusOK, client.BareMetalServerDetails{
+				BareMetalServer: client.BareMetalServer{Name: "server-1"},
+				OSStatus: &client.BareMetalServerlOSStatus{
+					OSStatus:          client.OSStatusInstalled,
+					LastImagingUpdate: serverNow.Add(time.Minute),
+				},
+			}), nil
+		}
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return test.NewJSONResponse(t, http.StatusNotFound, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":           "test-team",
+		"server":         "server-1",
+		"follow":         "true",
+		"follow-timeout": "1s",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "reinstall", flags)
+	assert.NoError(t, err)
+
+	output := executeCommand(t, cmd)
+
+	var result client.BareMetalServerDetails
+	err = json.Unmarshal([]byte(output), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, client.OSStatusInstalled, result.OSStatus.OSStatus)
+}
+
+func TestBareMetalReinstallCommand_InvalidStallTimeout(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.yes = true
+
+	flags := map[string]string{
+		"team":          "test-team",
+		"server":        "server-1",
+		"stall-timeout": "later",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "reinstall", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid stall-timeout")
+}
+
+func TestBareMetalReinstallCommand_InvalidFollowTimeout(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.yes = true
+
+	flags := map[string]string{
+		"team":           "test-team",
+		"server":         "server-1",
+		"follow":         "true",
+		"follow-timeout": "later",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "reinstall", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorContains(t, err, "invalid follow-timeout")
+}
+
+func TestBareMetalInstallStatusCommand_Success(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	mockServer := &client.BareMetalServerDetails{
+		BareMetalServer: client.BareMetalServer{Name: "server-1"},
+		OSStatus: &client.BareMetalServerlOSStatus{
+			OSSelection: "ubuntu-24.04",
+			OSStatus:    client.OSStatusInstallingOS,
+		},
+	}
+
+	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/server-1/", http.MethodGet, 200, mockServer)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":   "test-team",
+		"server": "server-1",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "install-status", flags)
+	assert.NoError(t, err)
+
+	output := executeCommand(t, cmd)
+
+	var result client.BareMetalServerlOSStatus
+	err = json.Unmarshal([]byte(output), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, client.OSStatusInstallingOS, result.OSStatus)
+}
+
+func TestBareMetalInstallStatusCommand_FollowFailed(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	mockServer := &client.BareMetalServerDetails{
+		BareMetalServer: client.BareMetalServer{Name: "server-1"},
+		OSStatus:        &client.BareMetalServerlOSStatus{OSStatus: client.OSStatusFailed},
+	}
+
+	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/server-1/", http.MethodGet, 200, mockServer)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":   "test-team",
+		"server": "server-1",
+		"follow": "true",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "install-status", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, client.ErrOSInstallFailed)
+}
+
+func TestBareMetalInstallStatusCommand_FollowTimeout(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	mockServer := &client.BareMetalServerDetails{
+		BareMetalServer: client.BareMetalServer{Name: "server-1"},
+		OSStatus:        &client.BareMetalServerlOSStatus{OSStatus: client.OSStatusInstallingOS},
+	}
+
+	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/bare_metal/server-1/", http.MethodGet, 200, mockServer)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":           "test-team",
+		"server":         "server-1",
+		"follow":         "true",
+		"follow-timeout": "10ms",
+	}
+	cmd, err := getCommand(app, bareMetalCommands, "install-status", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, client.ErrWaitTimeout)
+	assert.ErrorContains(t, err, "server-1 is installing_os")
+}
+
 func TestBareMetalConsoleCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
 
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 
+	"hotaisle-cli/client"
 	"hotaisle-cli/internal/config"
 
 	"github.com/urfave/cli/v3"
@@ -38,9 +41,50 @@ var configCommands = commandDef{
 						return nil
 					},
 				},
+				{
+					Name:      "token-store",
+					Usage:     "Move the API token to a credential store: " + strings.Join(config.StoreNames, ", ") + ".",
+					ArgsUsage: "<store>",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						store := strings.TrimSpace(cmd.Args().First())
+						if len(store) == 0 {
+							return errors.New("missing token-store")
+						}
+						if !slices.Contains(config.StoreNames, store) {
+							return fmt.Errorf("invalid token-store %q, must be one of %s", store, strings.Join(config.StoreNames, ", "))
+						}
+						if err := app.Config.SetTokenStore(store); err != nil {
+							return err
+						}
+						err := config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "token-store", store)
+						return nil
+					},
+				},
+				{
+					Name:      "credential-helper",
+					Usage:     "Set the command of the helper token store, like pass or \"git credential-store\".",
+					ArgsUsage: "<command>",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						helper := strings.TrimSpace(strings.Join(cmd.Args().Slice(), " "))
+						if len(helper) == 0 {
+							return errors.New("missing credential-helper")
+						}
+						app.Config.CredentialHelper = helper
+						err := config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "credential-helper", helper)
+						return nil
+					},
+				},
 				{
 					Name:  "log-level",
-					Usage: "Set the log-level. Valid values are: debug, info, warn, error, fatal, panic.",
+					Usage: "Set the log-level. Valid values are: trace, debug, info, warn, error.",
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
 						logLevel := strings.TrimSpace(cmd.Args().First())
 						if len(logLevel) == 0 {
@@ -72,6 +116,119 @@ var configCommands = commandDef{
 						return nil
 					},
 				},
+				{
+					Name:  "retries",
+					Usage: "Set how many times a request that failed with a transient error is retried.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						value := strings.TrimSpace(cmd.Args().First())
+						if len(value) == 0 {
+							return errors.New("missing retries")
+						}
+						retries, err := strconv.Atoi(value)
+						if err != nil || retries < 0 {
+							return fmt.Errorf("invalid retries %q, must be a non-negative number", value)
+						}
+						app.Config.Retries = &retries
+						err = config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "retries", retries)
+						return nil
+					},
+				},
+				{
+					Name:  "retry-unsafe",
+					Usage: "Set whether POST and PATCH requests are retried too (true or false).",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						value := strings.TrimSpace(cmd.Args().First())
+						if len(value) == 0 {
+							return errors.New("missing retry-unsafe")
+						}
+						retryUnsafe, err := strconv.ParseBool(value)
+						if err != nil {
+							return fmt.Errorf("invalid retry-unsafe %q, must be true or false", value)
+						}
+						app.Config.RetryUnsafe = retryUnsafe
+						err = config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "retry-unsafe", retryUnsafe)
+						return nil
+					},
+				},
+				{
+					Name:  "base-url",
+					Usage: `Set the API base URL, e.g. of a staging endpoint. "" restores the default.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "base-url", &app.Config.BaseURL, checkBaseURL)
+					},
+				},
+				{
+					Name:  "request-timeout",
+					Usage: `Set how long a request may take, its retries included, e.g. 90s. "" restores the default.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "request-timeout", &app.Config.RequestTimeout, checkRequestTimeout)
+					},
+				},
+				{
+					Name:  "https-proxy",
+					Usage: `Set the URL of the proxy to send the requests through. "" uses HTTPS_PROXY again.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "https-proxy", &app.Config.HTTPSProxy, checkProxy)
+					},
+				},
+				{
+					Name:  "ca-bundle",
+					Usage: `Set a PEM file of certificates to trust on top of the system ones. "" removes it.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "ca-bundle", &app.Config.CABundle, checkFile)
+					},
+				},
+				{
+					Name:  "client-cert",
+					Usage: `Set the PEM file of a client certificate for mutual TLS, with client-key. "" removes it.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "client-cert", &app.Config.ClientCert, checkFile)
+					},
+				},
+				{
+					Name:  "client-key",
+					Usage: `Set the PEM file of the key of the client certificate. "" removes it.`,
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						return setConnectionSetting(app, cmd, "client-key", &app.Config.ClientKey, checkFile)
+					},
+				},
+				{
+					Name:  "budget",
+					Usage: "Set the budget checked before provisioning for a team. 0 removes a limit.",
+					Flags: []flagDef{
+						{Name: "team", Usage: "Team handle", Required: true},
+						{Name: "max-hourly-cents", Usage: "Highest hourly rate of the team, in cents"},
+						{Name: "min-runout", Usage: "Shortest time the balance must last, e.g. 72h"},
+					},
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						team := cmd.String("team")
+						budget, err := parseBudgetFlags(cmd, app.Config.Budgets[team])
+						if err != nil {
+							return err
+						}
+						if app.Config.Budgets == nil {
+							app.Config.Budgets = map[string]config.Budget{}
+						}
+						app.Config.Budgets[team] = budget
+						if budget.IsZero() {
+							delete(app.Config.Budgets, team)
+						}
+						err = config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "team", team, "max-hourly-cents", budget.MaxHourlyRate, "min-runout", budget.MinRunout)
+						return nil
+					},
+				},
 			},
 		},
 		{
@@ -80,15 +237,30 @@ var configCommands = commandDef{
 			Commands: []commandDef{
 				{
 					Name:  "token",
-					Usage: "Get the API token.",
+					Usage: "Get the API token, masked unless --show is given.",
+					Flags: []flagDef{
+						{Name: "show", Usage: "Print the whole token", Bool: true},
+					},
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						if cmd.Bool("show") {
+							fmt.Print(app.Config.Token())
+							return nil
+						}
+						fmt.Print(maskToken(app.Config.Token()))
+						return nil
+					},
+				},
+				{
+					Name:  "token-store",
+					Usage: "Get the credential store holding the API token.",
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-						fmt.Print(app.Config.ApiToken)
+						fmt.Print(app.Config.TokenStore())
 						return nil
 					},
 				},
 				{
 					Name:  "log-level",
-					Usage: "Get the log-level. Valid values are: debug, info, warn, error, fatal, panic.",
+					Usage: "Get the log-level. Valid values are: trace, debug, info, warn, error.",
 					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
 						fmt.Print(app.Config.LogLevel)
 						return nil
@@ -102,11 +274,232 @@ var configCommands = commandDef{
 						return nil
 					},
 				},
+				{
+					Name:  "retries",
+					Usage: "Get how many times a request that failed with a transient error is retried.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						retries := defaultRetries
+						if app.Config.Retries != nil {
+							retries = *app.Config.Retries
+						}
+						fmt.Print(retries)
+						return nil
+					},
+				},
+				{
+					Name:  "retry-unsafe",
+					Usage: "Get whether POST and PATCH requests are retried too.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						fmt.Print(app.Config.RetryUnsafe)
+						return nil
+					},
+				},
+				{
+					Name:  "base-url",
+					Usage: "Get the API base URL.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						baseURL := app.Config.BaseURL
+						if baseURL == "" {
+							baseURL = client.DefaultBaseURL
+						}
+						fmt.Print(baseURL)
+						return nil
+					},
+				},
+				{
+					Name:  "request-timeout",
+					Usage: "Get how long a request may take, its retries included.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						timeout := app.Config.RequestTimeout
+						if timeout == "" {
+							timeout = client.DefaultRequestTimeout.String()
+						}
+						fmt.Print(timeout)
+						return nil
+					},
+				},
+				{
+					Name:  "https-proxy",
+					Usage: "Get the URL of the proxy the requests are sent through, if not the one of HTTPS_PROXY.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						fmt.Print(app.Config.HTTPSProxy)
+						return nil
+					},
+				},
+				{
+					Name:  "ca-bundle",
+					Usage: "Get the PEM file of certificates trusted on top of the system ones.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						fmt.Print(app.Config.CABundle)
+						return nil
+					},
+				},
+				{
+					Name:  "client-cert",
+					Usage: "Get the PEM file of the client certificate for mutual TLS.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						fmt.Print(app.Config.ClientCert)
+						return nil
+					},
+				},
+				{
+					Name:  "client-key",
+					Usage: "Get the PEM file of the key of the client certificate.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						fmt.Print(app.Config.ClientKey)
+						return nil
+					},
+				},
+				{
+					Name:  "budget",
+					Usage: "Get the budget of a team, or of every team without --team.",
+					Flags: []flagDef{
+						{Name: "team", Usage: "Team handle"},
+					},
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						if team := cmd.String("team"); team != "" {
+							return printOutput(app, app.Config.Budgets[team])
+						}
+						budgets := app.Config.Budgets
+						if budgets == nil {
+							budgets = map[string]config.Budget{}
+						}
+						return printOutput(app, budgets)
+					},
+				},
+			},
+		},
+		{
+			Name:  "profiles",
+			Usage: "Manage named configuration profiles.",
+			Commands: []commandDef{
+				{
+					Name:  "list",
+					Usage: "List all profiles.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						names := app.Config.ProfileNames()
+						profiles := make([]profileSummary, len(names))
+						for i, name := range names {
+							p := app.Config.Profiles[name]
+							profiles[i] = profileSummary{
+								Name:        name,
+								Active:      name == app.Config.ActiveProfile,
+								BaseURL:     p.BaseURL,
+								DefaultTeam: p.DefaultTeam,
+								LogLevel:    p.LogLevel,
+								TokenStore:  config.StorePlaintext,
+							}
+							if p.TokenRef != "" {
+								profiles[i].TokenStore, _, _ = strings.Cut(p.TokenRef, ":")
+							}
+							token := p.ApiToken
+							if name == app.Config.ProfileName() {
+								token = app.Config.ApiToken
+							}
+							if token != "" {
+								profiles[i].Token = partialToken(token)
+							}
+						}
+						return printOutput(app, profiles)
+					},
+				},
+				{
+					Name:  "use",
+					Usage: "Set the active profile.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						name := strings.TrimSpace(cmd.Args().First())
+						if len(name) == 0 {
+							return errors.New("missing profile name")
+						}
+						if err := app.Config.SetActiveProfile(name); err != nil {
+							return err
+						}
+						err := config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Config set", "active-profile", name)
+						return nil
+					},
+				},
+				{
+					Name:  "create",
+					Usage: "Create a new profile. The API token is read from the HOTAISLE_API_TOKEN environment variable, if set.",
+					Flags: []flagDef{
+						{Name: "base-url", Usage: "API base URL"},
+						{Name: "default-team", Usage: "Default team handle"},
+						{Name: "log-level", Usage: "Log level", Value: config.DefaultLogLevel},
+					},
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						name := strings.TrimSpace(cmd.Args().First())
+						if len(name) == 0 {
+							return errors.New("missing profile name")
+						}
+						err := app.Config.CreateProfile(name, config.Profile{
+							ApiToken:    strings.TrimSpace(os.Getenv("HOTAISLE_API_TOKEN")),
+							BaseURL:     cmd.String("base-url"),
+							DefaultTeam: cmd.String("default-team"),
+							LogLevel:    cmd.String("log-level"),
+						})
+						if err != nil {
+							return err
+						}
+						err = config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Profile created", "profile", name)
+						return nil
+					},
+				},
+				{
+					Name:  "delete",
+					Usage: "Delete a profile.",
+					Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+						name := strings.TrimSpace(cmd.Args().First())
+						if len(name) == 0 {
+							return errors.New("missing profile name")
+						}
+						if err := app.Config.DeleteProfile(name); err != nil {
+							return err
+						}
+						err := config.Save(app.Config)
+						if err != nil {
+							return err
+						}
+						slog.Info("Profile deleted", "profile", name)
+						return nil
+					},
+				},
 			},
 		},
 	},
 }
 
+// profileSummary is how a profile is shown by config profiles list
+type profileSummary struct {
+	Name        string `json:"name"`
+	Active      bool   `json:"active"`
+	BaseURL     string `json:"base_url,omitempty"`
+	DefaultTeam string `json:"default_team,omitempty"`
+	LogLevel    string `json:"log_level,omitempty"`
+	// Token is only the first segment of the API token, only known for
+	// plaintext tokens and the profile in use
+	Token      string `json:"token,omitempty"`
+	TokenStore string `json:"token_store"`
+}
+
+// maskToken hides the secret part of a token, keeping the prefix that names it
+func maskToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if prefix, _, ok := strings.Cut(token, "."); ok {
+		return prefix + ".********"
+	}
+	return "********"
+}
+
 func partialToken(token string) string {
 	tokens := strings.Split(token, ".")
 	if len(tokens) > 0 {
@@ -2,10 +2,16 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"hotaisle-cli/internal/config"
+	"hotaisle-cli/test"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v3"
 )
 
@@ -116,14 +122,50 @@ func TestConfigSetDefaultTeam_Success(t *testing.T) {
 
 func TestConfigGetToken(t *testing.T) {
 	app, _ := setupTestApp(t)
-	app.Config.ApiToken = "test-token-get"
+	app.Config.ApiToken = "prefix.test-token-get"
 
 	cmd, err := getCommand(app, configCommands, "get.token", nil)
 	assert.NoError(t, err)
-
 	output := executeCommand(t, cmd)
+	assert.Equal(t, "prefix.********", output)
+
+	cmd, err = getCommand(app, configCommands, "get.token", map[string]string{"show": "true"})
+	assert.NoError(t, err)
+	output = executeCommand(t, cmd)
+	assert.Equal(t, "prefix.test-token-get", output)
+}
+
+func TestMaskToken(t *testing.T) {
+	assert.Equal(t, "", maskToken(""))
+	assert.Equal(t, "********", maskToken("no-prefix"))
+	assert.Equal(t, "abc.********", maskToken("abc.secret"))
+}
 
-	assert.Equal(t, "test-token-get", output)
+func TestConfigSetTokenStore(t *testing.T) {
+	app, tmpDir := setupTestApp(t)
+	t.Setenv("HOTAISLE_PASSPHRASE", "correct horse")
+	t.Setenv("HOTAISLE_API_TOKEN", "prefix.secret")
+	require.NoError(t, runConfigCommand(t, app, "set", "token"))
+
+	err := runConfigCommand(t, app, "set", "token-store", "vault")
+	assert.EqualError(t, err, `invalid token-store "vault", must be one of plaintext, encrypted-file, helper, secret-service`)
+
+	require.NoError(t, runConfigCommand(t, app, "set", "token-store", "encrypted-file"))
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".hotaisle", "config.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.Contains(t, string(data), `"token_ref": "encrypted-file:default"`)
+
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "prefix.secret", saved.Token())
+	assert.Equal(t, config.StoreEncryptedFile, saved.TokenStore())
+
+	require.NoError(t, runConfigCommand(t, app, "set", "token-store", "plaintext"))
+	saved, err = config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "prefix.secret", saved.Profiles["default"].ApiToken)
+	assert.Equal(t, config.StorePlaintext, saved.TokenStore())
 }
 
 func TestConfigGetLogLevel(t *testing.T) {
@@ -156,17 +198,137 @@ func TestConfigCommandStructure(t *testing.T) {
 
 	assert.Equal(t, "config", cmd.Name)
 	assert.Equal(t, "Config File Management", cmd.Usage)
-	assert.Len(t, cmd.Commands, 2) // "set" and "get"
+	assert.Len(t, cmd.Commands, 3) // "set", "get" and "profiles"
 
 	// Test "set" command
 	setCmd := cmd.Commands[0]
 	assert.Equal(t, "set", setCmd.Name)
-	assert.Len(t, setCmd.Commands, 3) // "token", "log-level", "default-team"
+	assert.Len(t, setCmd.Commands, 14) // "token", "token-store", "credential-helper", "log-level", "default-team", "retries", "retry-unsafe", the 6 connection settings and "budget"
 
 	// Test "get" command
 	getCmd := cmd.Commands[1]
 	assert.Equal(t, "get", getCmd.Name)
-	assert.Len(t, getCmd.Commands, 3) // "token", "log-level", "default-team"
+	assert.Len(t, getCmd.Commands, 13) // "token", "token-store", "log-level", "default-team", "retries", "retry-unsafe", the 6 connection settings and "budget"
+}
+
+func runConfigCommand(t *testing.T, app *App, args ...string) error {
+	t.Helper()
+	app.AppCli = &cli.Command{
+		Commands: []*cli.Command{newCommandConfig(app)},
+	}
+	return app.AppCli.Run(context.Background(), append([]string{"app", "config"}, args...))
+}
+
+func TestConfigProfilesCreateUseDelete(t *testing.T) {
+	app, tmpDir := setupTestApp(t)
+	app.Config.ApiToken = "personal.token"
+
+	t.Setenv("HOTAISLE_API_TOKEN", "ci.token")
+	err := runConfigCommand(t, app, "profiles", "create", "--default-team", "builds", "ci")
+	require.NoError(t, err)
+
+	err = runConfigCommand(t, app, "profiles", "create", "ci")
+	assert.Error(t, err)
+
+	err = runConfigCommand(t, app, "profiles", "use", "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", app.Config.ActiveProfile)
+	assert.Equal(t, "ci.token", app.Config.ApiToken)
+	assert.Equal(t, "builds", app.Config.DefaultTeam)
+
+	// The saved file keeps both profiles
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", saved.ProfileName())
+	assert.Equal(t, []string{"ci", "default"}, saved.ProfileNames())
+	assert.Equal(t, "personal.token", saved.Profiles["default"].ApiToken)
+	_, err = os.Stat(filepath.Join(tmpDir, ".hotaisle", "config.json"))
+	assert.NoError(t, err)
+
+	err = runConfigCommand(t, app, "profiles", "delete", "ci")
+	assert.Error(t, err, "the active profile cannot be deleted")
+
+	err = runConfigCommand(t, app, "profiles", "use", "default")
+	require.NoError(t, err)
+	err = runConfigCommand(t, app, "profiles", "delete", "ci")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, app.Config.ProfileNames())
+}
+
+func TestConfigProfilesUseUnknown(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	err := runConfigCommand(t, app, "profiles", "use", "missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
+
+func TestConfigProfilesList(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Config.ApiToken = "prefix.secret"
+	require.NoError(t, app.Config.CreateProfile("staging", config.Profile{BaseURL: "https://staging.example.com/api"}))
+
+	cmd, err := getCommand(app, configCommands, "profiles.list", nil)
+	require.NoError(t, err)
+
+	output := executeCommand(t, cmd)
+
+	var result []profileSummary
+	require.NoError(t, json.Unmarshal([]byte(output), &result))
+	require.Len(t, result, 2)
+	assert.Equal(t, "default", result[0].Name)
+	assert.True(t, result[0].Active)
+	assert.Equal(t, "prefix", result[0].Token)
+	assert.NotContains(t, output, "secret")
+	assert.Equal(t, "staging", result[1].Name)
+	assert.False(t, result[1].Active)
+	assert.Equal(t, "https://staging.example.com/api", result[1].BaseURL)
+}
+
+func TestConfigProfilesList_DoesNotReadStoredToken(t *testing.T) {
+	app, _ := setupTestApp(t)
+	t.Setenv("HOTAISLE_PASSPHRASE", "correct horse")
+	t.Setenv("HOTAISLE_API_TOKEN", "prefix.secret")
+	require.NoError(t, runConfigCommand(t, app, "set", "token"))
+	require.NoError(t, runConfigCommand(t, app, "set", "token-store", "encrypted-file"))
+
+	// Listing the profiles does not ask for the passphrase
+	asked := 0
+	passphrase := config.Passphrase
+	config.Passphrase = func() (string, error) {
+		asked++
+		return "correct horse", nil
+	}
+	t.Cleanup(func() { config.Passphrase = passphrase })
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	app = &App{Config: cfg}
+	app.Client = app.newAPIClient()
+	test.CaptureStdout(t, func() error {
+		return runConfigCommand(t, app, "profiles", "list")
+	})
+	assert.Equal(t, 0, asked)
+
+	// but getting the token does
+	output := test.CaptureStdout(t, func() error {
+		return runConfigCommand(t, app, "get", "token", "--show")
+	})
+	assert.Equal(t, "prefix.secret", output)
+	assert.Equal(t, 1, asked)
+}
+
+func TestConfigSetRetries(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	require.NoError(t, runConfigCommand(t, app, "set", "retries", "4"))
+	require.NotNil(t, app.Config.Retries)
+	assert.Equal(t, 4, *app.Config.Retries)
+
+	require.NoError(t, runConfigCommand(t, app, "set", "retry-unsafe", "true"))
+	assert.True(t, app.Config.RetryUnsafe)
+
+	assert.Error(t, runConfigCommand(t, app, "set", "retries", "-2"))
+	assert.Error(t, runConfigCommand(t, app, "set", "retry-unsafe", "maybe"))
 }
 
 func TestPartialToken(t *testing.T) {
@@ -229,3 +391,28 @@ func TestPartialToken(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigSetBudget(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	require.NoError(t, runConfigCommand(t, app, "set", "budget", "--team", "ml", "--max-hourly-cents", "5000", "--min-runout", "72h"))
+	assert.Equal(t, config.Budget{MaxHourlyRate: 5000, MinRunout: "72h"}, app.Config.Budgets["ml"])
+
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Budget{MaxHourlyRate: 5000, MinRunout: "72h"}, saved.Budgets["ml"])
+
+	cmd, err := getCommand(app, configCommands, "get.budget", map[string]string{"team": "ml"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"max_hourly_rate": 5000, "min_runout": "72h"}`, executeCommand(t, cmd))
+
+	// Removing both limits removes the budget
+	require.NoError(t, runConfigCommand(t, app, "set", "budget", "--team", "ml", "--max-hourly-cents", "0"))
+	assert.Equal(t, config.Budget{MinRunout: "72h"}, app.Config.Budgets["ml"])
+	require.NoError(t, runConfigCommand(t, app, "set", "budget", "--team", "ml", "--min-runout", "0"))
+	assert.NotContains(t, app.Config.Budgets, "ml")
+
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "budget", "--team", "ml"), "missing budget")
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "budget", "--team", "ml", "--min-runout", "3 days"), "invalid min-runout")
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "budget", "--team", "ml", "--max-hourly-cents", "-1"), "invalid max-hourly-cents")
+}
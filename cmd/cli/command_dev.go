@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/urfave/cli/v3"
+)
+
+var devCommands = commandDef{
+	Name:   "dev",
+	Usage:  "Tools for developing against the API.",
+	Hidden: true,
+	Commands: []commandDef{
+		{
+			Name:  "fake-server",
+			Usage: "Serve an in-memory fake of the API for offline development.",
+			Flags: []flagDef{
+				{Name: "listen", Usage: "Address to listen on", Value: "127.0.0.1:8080"},
+				// Not named team, which gets the default team of the config
+				{Name: "team-handle", Usage: "Handle of the team to create", Value: "fake-team"},
+				{Name: "credits", Usage: "Credits of the team in cents", Value: "100000"},
+				{Name: "token", Usage: "API token to accept", Value: fakeapi.DefaultToken},
+			},
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				credits, err := strconv.ParseInt(cmd.String("credits"), 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid credits: %w", err)
+				}
+				fake := fakeapi.New(fakeapi.WithToken(cmd.String("token")))
+				team := cmd.String("team-handle")
+				fake.AddTeam(client.Team{Handle: team, Name: team}, credits)
+
+				ln, err := net.Listen("tcp", cmd.String("listen"))
+				if err != nil {
+					return err
+				}
+				baseURL := "http://" + ln.Addr().String() + "/api"
+				printErrorf("Fake API listening on %s with team %s. Use it with:\n\n", baseURL, team)
+				printErrorf("  HOTAISLE_API_TOKEN=%s hotaisle config profiles create --base-url %s fake\n", fake.Token(), baseURL)
+				printErrorf("  hotaisle --profile fake vm list --team %s\n\n", team)
+				return serveFakeAPI(ctx, ln, fake)
+			},
+		},
+	},
+}
+
+// serveFakeAPI serves the fake API on ln until ctx is canceled
+func serveFakeAPI(ctx context.Context, ln net.Listener, fake *fakeapi.Server) error {
+	srv := &http.Server{Handler: fake, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	printErrorf("Fake API stopped\n")
+	return nil
+}
+
+func newCommandDev(app *App) *cli.Command {
+	return buildCommand(app, devCommands)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevCommandIsHidden(t *testing.T) {
+	app, _ := setupTestApp(t)
+	assert.True(t, newCommandDev(app).Hidden)
+}
+
+func TestFakeServerTeamIgnoresDefaultTeam(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Config.DefaultTeam = "ml-research"
+
+	cmd, err := getCommand(app, devCommands, "fake-server", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fake-team", cmd.String("team-handle"))
+}
+
+func TestServeFakeAPI(t *testing.T) {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "fake-team", Name: "Fake"}, 1000_00)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveFakeAPI(ctx, ln, fake) }()
+
+	app.Client = api.NewClient(fake.Token(), "1.0.0", client.WithBaseURL("http://"+ln.Addr().String()+"/api"))
+	cmd, err := getCommand(app, virtualMachineCommands, "provision", map[string]string{"team": "fake-team", "cpu-cores": "2"})
+	require.NoError(t, err)
+	output := executeCommand(t, cmd)
+
+	var vm client.VirtualMachineDetails
+	require.NoError(t, json.Unmarshal([]byte(output), &vm))
+	assert.NotEmpty(t, vm.Name)
+	assert.Equal(t, uint64(2), *vm.CPUCores)
+
+	cancel()
+	assert.NoError(t, <-served)
+}
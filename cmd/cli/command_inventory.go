@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/pricing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// defaultInventoryParallel is the default number of requests the inventory makes at once
+const defaultInventoryParallel = "8"
+
+var inventoryCommands = commandDef{
+	Name:    "inventory",
+	Aliases: []string{"ps"},
+	Usage:   "List the VMs and bare metal servers of every team with their hourly burn, and the balance and hourly burn of each team.",
+	Flags: []flagDef{
+		{Name: "parallel", Usage: "Number of requests to make at once", Value: defaultInventoryParallel},
+	},
+	Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+		parallel, err := strconv.Atoi(cmd.String("parallel"))
+		if err != nil || parallel < 1 {
+			return fmt.Errorf("invalid parallel %q, must be a positive number", cmd.String("parallel"))
+		}
+		teams, err := app.Client.Api.Teams().List(ctx)
+		if err != nil {
+			return err
+		}
+
+		inv := collectInventory(ctx, app.Client.Api, teams, parallel)
+		if err := printInventory(app, inv); err != nil {
+			return err
+		}
+		if failed := inv.failedTeams(); failed > 0 {
+			return fmt.Errorf("could not list %d of %d teams", failed, len(inv.Teams))
+		}
+		return nil
+	},
+}
+
+// inventoryItem is a VM or bare metal server in the inventory
+type inventoryItem struct {
+	Team         string        `json:"team"`
+	Kind         string        `json:"kind"`
+	Name         string        `json:"name"`
+	IPAddress    string        `json:"ip_address"`
+	CPUCores     uint64        `json:"cpu_cores"`
+	RAMCapacity  uint64        `json:"ram_capacity"`
+	DiskCapacity uint64        `json:"disk_capacity"`
+	GPUs         []client.GPUs `json:"gpus,omitempty"`
+	// State is the VM state, or the OS install status of a bare metal server
+	State string `json:"state"`
+	// HourlyCost is the price in cents per hour of the available type with
+	// the specs of the resource, or nil if no type has them
+	HourlyCost  *int64 `json:"hourly_cost,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// inventoryTeam sums up a team in the inventory. Error is set when some of its
+// resources could not be listed.
+type inventoryTeam struct {
+	Team                string     `json:"team"`
+	VirtualMachines     int        `json:"virtual_machines"`
+	BareMetalServers    int        `json:"bare_metal_servers"`
+	AvailableBalance    int64      `json:"available_balance"`
+	HourlyRate          int64      `json:"hourly_rate"`
+	EstimatedRunoutTime *time.Time `json:"estimated_runout_time,omitempty"`
+	Error               string     `json:"error,omitempty"`
+}
+
+type inventory struct {
+	Resources []inventoryItem `json:"resources"`
+	Teams     []inventoryTeam `json:"teams"`
+}
+
+func (inv inventory) failedTeams() int {
+	failed := 0
+	for _, t := range inv.Teams {
+		if t.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// teamInventory is what the requests for one team return. Each request sets
+// its own fields, so they can run at the same time.
+type teamInventory struct {
+	handle      string
+	vms         []inventoryItem
+	vmsErr      error
+	servers     []inventoryItem
+	serversErr  error
+	balance     *client.BalanceInfo
+	balanceErr  error
+	vmTypes     []client.AvailableVirtualMachineTypes
+	serverTypes []client.AvailableBareMetalTypes
+}
+
+// requestPool runs functions at most limit at once. The functions may add more
+// functions to the pool.
+type requestPool struct {
+	wg    sync.WaitGroup
+	slots chan struct{}
+}
+
+func newRequestPool(limit int) *requestPool {
+	return &requestPool{slots: make(chan struct{}, limit)}
+}
+
+// Go runs fn once a slot is free
+func (p *requestPool) Go(fn func()) {
+	p.wg.Go(func() {
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+		fn()
+	})
+}
+
+// Wait waits for all the functions, including the ones they added
+func (p *requestPool) Wait() {
+	p.wg.Wait()
+}
+
+// collectInventory lists the resources and balance of every team, making at
+// most parallel requests at once. Failures are recorded per team.
+func collectInventory(ctx context.Context, api *client.Client, teams []client.UserTeam, parallel int) inventory {
+	var results []*teamInventory
+	for _, t := range teams {
+		if t.Invitation {
+			continue
+		}
+		results = append(results, &teamInventory{handle: t.Handle})
+	}
+
+	pool := newRequestPool(parallel)
+	for _, r := range results {
+		pool.Go(func() { r.vms, r.vmsErr = listInventoryVMs(ctx, api, pool, r.handle) })
+		pool.Go(func() { r.servers, r.serversErr = listInventoryServers(ctx, api, r.handle) })
+		pool.Go(func() { r.balance, r.balanceErr = api.Teams().GetBalance(ctx, r.handle) })
+		pool.Go(func() {
+			var err error
+			if r.vmTypes, err = api.VirtualMachines().GetAvailable(ctx, r.handle); err != nil {
+				slog.Debug("Could not get the VM prices", "team", r.handle, "error", err)
+			}
+		})
+		pool.Go(func() {
+			var err error
+			if r.serverTypes, err = api.BareMetal().GetAvailable(ctx, r.handle); err != nil {
+				slog.Debug("Could not get the bare metal prices", "team", r.handle, "error", err)
+			}
+		})
+	}
+	pool.Wait()
+
+	inv := inventory{Resources: []inventoryItem{}, Teams: []inventoryTeam{}}
+	for _, r := range results {
+		summary := inventoryTeam{Team: r.handle, VirtualMachines: len(r.vms), BareMetalServers: len(r.servers)}
+		if r.balance != nil {
+			summary.AvailableBalance = r.balance.AvailableBalance
+			summary.HourlyRate = r.balance.HourlyRate
+			summary.EstimatedRunoutTime = r.balance.EstimatedRunoutTime
+		}
+		for _, err := range []error{r.vmsErr, r.serversErr, r.balanceErr} {
+			if err != nil && summary.Error == "" {
+				slog.Warn("Could not list team", "team", r.handle, "error", errorMessage(err))
+				summary.Error = errorMessage(err)
+			}
+		}
+		for i := range r.vms {
+			r.vms[i].HourlyCost = vmHourlyCost(r.vmTypes, r.vms[i])
+		}
+		for i := range r.servers {
+			r.servers[i].HourlyCost = serverHourlyCost(r.serverTypes, r.servers[i])
+		}
+		inv.Teams = append(inv.Teams, summary)
+		inv.Resources = append(inv.Resources, r.vms...)
+		inv.Resources = append(inv.Resources, r.servers...)
+	}
+	return inv
+}
+
+// vmHourlyCost returns the price of the available VM type with the specs of a
+// VM, or nil if no type has them
+func vmHourlyCost(types []client.AvailableVirtualMachineTypes, vm inventoryItem) *int64 {
+	price, err := pricing.MatchVM(types, client.VirtualMachineSpecs{
+		CPUCores:     nonZero(vm.CPUCores),
+		RAMCapacity:  nonZero(vm.RAMCapacity),
+		DiskCapacity: nonZero(vm.DiskCapacity),
+		GPUs:         vm.GPUs,
+	})
+	if err != nil {
+		return nil
+	}
+	return &price.Hourly
+}
+
+// serverHourlyCost returns the price of the available bare metal type with the
+// specs of a server, or nil if no type has them
+func serverHourlyCost(types []client.AvailableBareMetalTypes, server inventoryItem) *int64 {
+	price, err := pricing.MatchBareMetal(types, client.BareMetalServerSpecs{
+		CPUCores:     server.CPUCores,
+		RAMCapacity:  server.RAMCapacity,
+		DiskCapacity: server.DiskCapacity,
+		GPUs:         server.GPUs,
+	})
+	if err != nil {
+		return nil
+	}
+	return &price.Hourly
+}
+
+// nonZero returns a pointer to v, or nil if v is zero
+func nonZero(v uint64) *uint64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+// listInventoryVMs lists the VMs of a team, and adds the requests for their
+// state to the pool. A VM whose state cannot be read is listed with an unknown
+// state. The states are only set once the pool is done.
+func listInventoryVMs(ctx context.Context, api *client.Client, pool *requestPool, team string) ([]inventoryItem, error) {
+	vms, err := api.VirtualMachines().List(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("listing VMs: %w", err)
+	}
+	items := make([]inventoryItem, len(vms))
+	for i, vm := range vms {
+		items[i] = inventoryItem{
+			Team:        team,
+			Kind:        kindVM,
+			Name:        vm.Name,
+			IPAddress:   vm.IPAddress,
+			GPUs:        vm.GPUs,
+			State:       "unknown",
+			Description: vm.Description,
+		}
+		if vm.CPUCores != nil {
+			items[i].CPUCores = *vm.CPUCores
+		}
+		if vm.RAMCapacity != nil {
+			items[i].RAMCapacity = *vm.RAMCapacity
+		}
+		if vm.DiskCapacity != nil {
+			items[i].DiskCapacity = *vm.DiskCapacity
+		}
+		pool.Go(func() {
+			state, err := api.VirtualMachines().GetState(ctx, team, vm.Name)
+			if err != nil {
+				slog.Debug("Could not get VM state", "team", team, "vm", vm.Name, "error", err)
+				return
+			}
+			items[i].State = state.State
+		})
+	}
+	return items, nil
+}
+
+func listInventoryServers(ctx context.Context, api *client.Client, team string) ([]inventoryItem, error) {
+	servers, err := api.BareMetal().List(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("listing bare metal servers: %w", err)
+	}
+	items := make([]inventoryItem, len(servers))
+	for i, s := range servers {
+		items[i] = inventoryItem{
+			Team:         team,
+			Kind:         kindBareMetal,
+			Name:         s.Name,
+			IPAddress:    s.IPAddress,
+			CPUCores:     s.CPUCores,
+			RAMCapacity:  s.RAMCapacity,
+			DiskCapacity: s.DiskCapacity,
+			GPUs:         s.GPUs,
+			State:        formatOSStatus(s.OSStatus),
+			Description:  s.Description,
+		}
+	}
+	return items, nil
+}
+
+// printInventory prints the inventory, as a table of resources followed by a
+// table of teams for the table formats
+func printInventory(app *App, inv inventory) error {
+	name, _, err := parseOutput(app.Output)
+	if err != nil {
+		return err
+	}
+	if name != outputTable && name != outputWide {
+		return printOutput(app, inv)
+	}
+	if err := renderTable(os.Stdout, inv.Resources, name == outputWide); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(os.Stdout)
+	return renderTable(os.Stdout, inv.Teams, name == outputWide)
+}
+
+func newCommandInventory(app *App) *cli.Command {
+	return buildCommand(app, inventoryCommands)
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryCommand(t *testing.T) {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New(fakeapi.WithTransitionTime(0), fakeapi.WithInstallStageTime(0))
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1_000_000_00)
+	fake.AddTeam(client.Team{Handle: "web", Name: "Web"}, 1000_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Client = api.NewClient(fake.Token(), "1.0.0", client.WithBaseURL(srv.URL+"/api"))
+
+	ctx := context.Background()
+	var req client.VMProvisionRequest
+	cores := uint64(2)
+	req.CPUCores = &cores
+	vm, err := app.Client.Api.VirtualMachines().Provision(ctx, "web", req)
+	require.NoError(t, err)
+	server, err := app.Client.Api.BareMetal().Reserve(ctx, "ml", client.BareMetalServerReservation{
+		Specs: client.BareMetalServerSpecs{CPUCores: 104},
+	})
+	require.NoError(t, err)
+
+	cmd, err := getCommand(app, inventoryCommands, "inventory", nil)
+	require.NoError(t, err)
+	output := executeCommand(t, cmd)
+
+	var inv inventory
+	require.NoError(t, json.Unmarshal([]byte(output), &inv))
+	require.Len(t, inv.Resources, 2)
+	// The prices of the types of the fake API
+	serverCost, vmCost := int64(1592), int64(10)
+	assert.Equal(t, inventoryItem{
+		Team: "ml", Kind: kindBareMetal, Name: server.Name, IPAddress: server.IPAddress,
+		CPUCores: 104, RAMCapacity: 2048 << 30, DiskCapacity: 30720 << 30, GPUs: server.GPUs,
+		State: client.OSStatusInstalled, HourlyCost: &serverCost,
+	}, inv.Resources[0])
+	assert.Equal(t, inventoryItem{
+		Team: "web", Kind: kindVM, Name: vm.Name, IPAddress: vm.IPAddress,
+		CPUCores: 2, RAMCapacity: 8 << 30, DiskCapacity: 100 << 30, State: client.VMStateRunning,
+		HourlyCost: &vmCost,
+	}, inv.Resources[1])
+
+	require.Len(t, inv.Teams, 2)
+	assert.Equal(t, "ml", inv.Teams[0].Team)
+	assert.Equal(t, 1, inv.Teams[0].BareMetalServers)
+	assert.Equal(t, int64(1592), inv.Teams[0].HourlyRate)
+	assert.Equal(t, "web", inv.Teams[1].Team)
+	assert.Equal(t, 1, inv.Teams[1].VirtualMachines)
+	assert.Equal(t, int64(10), inv.Teams[1].HourlyRate)
+	assert.NotNil(t, inv.Teams[1].EstimatedRunoutTime)
+}
+
+func TestInventoryCommand_Alias(t *testing.T) {
+	app, _ := setupTestApp(t)
+	assert.Equal(t, []string{"ps"}, newCommandInventory(app).Aliases)
+}
+
+// inventoryHandler serves teams a and b, and an invitation to c. The balance of b is forbidden.
+func inventoryHandler(t *testing.T, inFlight, maxInFlight *atomic.Int32) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// Give the other workers time to start their requests
+		time.Sleep(5 * time.Millisecond)
+
+		switch path := req.URL.Path; {
+		case path == "/api/teams/":
+			return test.NewJSONResponse(t, http.StatusOK, []client.UserTeam{
+				{Team: client.Team{Handle: "a"}},
+				{Team: client.Team{Handle: "b"}},
+				{Team: client.Team{Handle: "c"}, Invitation: true},
+			}), nil
+		case path == "/api/teams/b/balance/":
+			return test.NewJSONResponse(t, http.StatusForbidden, "missing role"), nil
+		case strings.HasSuffix(path, "/balance/"):
+			return test.NewJSONResponse(t, http.StatusOK, client.BalanceInfo{AvailableBalance: 500_00, HourlyRate: 10}), nil
+		case strings.HasSuffix(path, "/virtual_machines/"):
+			return test.NewJSONResponse(t, http.StatusOK, []client.VirtualMachineDetails{
+				{VirtualMachine: client.VirtualMachine{Name: "vm-1", IPAddress: "10.0.0.1"}},
+			}), nil
+		case strings.HasSuffix(path, "/available/"):
+			return test.NewJSONResponse(t, http.StatusOK, []any{}), nil
+		case strings.HasSuffix(path, "/virtual_machines/vm-1/state/"):
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStatePaused}), nil
+		case strings.HasSuffix(path, "/bare_metal/"):
+			return test.NewJSONResponse(t, http.StatusOK, []client.BareMetalServerDetails{}), nil
+		}
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return test.NewEmptyResponse(http.StatusNotFound), nil
+	}
+}
+
+func TestInventoryCommand_TeamFailure(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Output = outputTable
+	var inFlight, maxInFlight atomic.Int32
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(inventoryHandler(t, &inFlight, &maxInFlight))))
+
+	cmd, err := getCommand(app, inventoryCommands, "inventory", map[string]string{"parallel": "2"})
+	require.NoError(t, err)
+
+	var runErr error
+	output := test.CaptureStdout(t, func() error {
+		runErr = cmd.Action(context.Background(), cmd)
+		return nil
+	})
+	assert.EqualError(t, runErr, "could not list 1 of 2 teams")
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 7)
+	assert.Regexp(t, `^TEAM\s+KIND\s+NAME\s+IP\s+CPUS\s+RAM\s+DISK\s+GPUS\s+STATE\s+BURN/HR$`, lines[0])
+	assert.Regexp(t, `^a\s+vm\s+vm-1\s+10\.0\.0\.1\s+0\s+0B\s+0B\s+-\s+paused\s+-$`, lines[1])
+	assert.Regexp(t, `^b\s+vm\s+vm-1\s`, lines[2])
+	assert.Equal(t, "", lines[3])
+	assert.Regexp(t, `^TEAM\s+VMS\s+BARE METAL\s+BALANCE\s+BURN/HR\s+RUNOUT\s+ERROR$`, lines[4])
+	assert.Regexp(t, `^a\s+1\s+0\s+\$500\.00\s+\$0\.10\s+-\s+-$`, lines[5])
+	assert.Regexp(t, `^b\s+1\s+0\s+\$0\.00\s+\$0\.00\s+-\s+missing role`, lines[6])
+	assert.NotContains(t, output, "\nc ")
+}
+
+func TestCollectInventory_Parallel(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	api := api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(inventoryHandler(t, &inFlight, &maxInFlight))))
+	teams := []client.UserTeam{{Team: client.Team{Handle: "a"}}, {Team: client.Team{Handle: "b"}}}
+
+	inv := collectInventory(context.Background(), api.Api, teams, 8)
+	assert.Len(t, inv.Resources, 2)
+	assert.Equal(t, "", inv.Teams[0].Error)
+	assert.Contains(t, inv.Teams[1].Error, "missing role (HTTP 403 on GET /teams/b/balance/)")
+	// The requests of each team run at the same time
+	assert.Greater(t, maxInFlight.Load(), int32(2))
+}
+
+func TestCollectInventory_ParallelStates(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	handler := func(req *http.Request) (*http.Response, error) {
+		if !strings.HasSuffix(req.URL.Path, "/state/") {
+			return test.NewJSONResponse(t, http.StatusOK, []client.VirtualMachineDetails{
+				{VirtualMachine: client.VirtualMachine{Name: "vm-1"}},
+				{VirtualMachine: client.VirtualMachine{Name: "vm-2"}},
+				{VirtualMachine: client.VirtualMachine{Name: "vm-3"}},
+				{VirtualMachine: client.VirtualMachine{Name: "vm-4"}},
+				{VirtualMachine: client.VirtualMachine{Name: "vm-5"}},
+				{VirtualMachine: client.VirtualMachine{Name: "vm-6"}},
+			}), nil
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStateRunning}), nil
+	}
+	api := api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(handler)))
+
+	pool := newRequestPool(4)
+	vms, err := listInventoryVMs(context.Background(), api.Api, pool, "a")
+	require.NoError(t, err)
+	pool.Wait()
+	require.Len(t, vms, 6)
+	for _, vm := range vms {
+		assert.Equal(t, client.VMStateRunning, vm.State)
+	}
+	// The states are read at the same time, as many as the pool allows
+	assert.Equal(t, int32(4), maxInFlight.Load())
+}
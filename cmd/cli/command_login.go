@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+
+	"github.com/urfave/cli/v3"
+)
+
+var loginCommands = commandDef{
+	Name:  "login",
+	Usage: "Log in with an API key read from stdin or asked for, and save it to the profile in use.",
+	Flags: []flagDef{
+		{Name: "mint", Usage: "Create a new API key with the given one, and save the new key instead", Bool: true},
+		{Name: "label", Usage: "Label of the minted API key, by default hotaisle-cli on the host name"},
+		{Name: "user-role", Usage: "User role of the minted API key (owner or user)", Value: "user"},
+		{Name: "team", Usage: "Team the minted API key can reach, as handle or handle=role,role. Without roles it gets your roles on the team. Repeat for more teams, or leave out for all your teams with your roles on them", Multiple: true},
+	},
+	Action: login,
+}
+
+var logoutCommands = commandDef{
+	Name:  "logout",
+	Usage: "Delete the API key minted by login, and remove the API key from the profile in use.",
+	Flags: []flagDef{
+		{Name: "keep-key", Usage: "Do not delete the minted API key", Bool: true},
+	},
+	Action: logout,
+}
+
+// loginSummary is what login prints about who you are
+type loginSummary struct {
+	Profile string      `json:"profile"`
+	Name    string      `json:"name"`
+	Email   string      `json:"email"`
+	Key     string      `json:"key"`
+	Minted  bool        `json:"minted"`
+	Teams   []loginTeam `json:"teams"`
+}
+
+// loginTeam is a team the saved API key can reach, with its roles on it
+type loginTeam struct {
+	Handle string   `json:"handle"`
+	Roles  []string `json:"roles"`
+}
+
+func login(app *App, ctx context.Context, cmd *cli.Command) error {
+	mint := cmd.Bool("mint")
+	if !mint && (cmd.IsSet("team") || cmd.IsSet("label")) {
+		return errors.New("--team and --label need --mint")
+	}
+	token, err := readLoginToken()
+	if err != nil {
+		return err
+	}
+
+	c := app.newAPIClient()
+	c.Api.SetToken(token)
+	user, err := c.Api.User().Get(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	summary := loginSummary{Profile: app.Config.ProfileName(), Key: maskToken(token), Minted: mint}
+	var loginKey string
+	if mint {
+		teams, err := parseKeyTeams(cmd.StringSlice("team"), user.Teams)
+		if err != nil {
+			return err
+		}
+		label := cmd.String("label")
+		if label == "" {
+			label = defaultKeyLabel()
+		}
+		key, err := c.Api.User().CreateAPIKey(ctx, client.UserAPIKeyRequest{
+			Label:    label,
+			UserRole: cmd.String("user-role"),
+			Teams:    teams,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mint an API key: %w", err)
+		}
+		if app.dryRun {
+			return nil
+		}
+		token, loginKey = key.Token, key.Prefix
+		summary.Key = key.Prefix
+
+		// The new key may reach fewer teams than the one it was minted with
+		verify := app.newAPIClient()
+		verify.Api.SetToken(token)
+		if user, err = verify.Api.User().Get(ctx); err != nil {
+			// The key is not saved, so it must not stay live
+			err = fmt.Errorf("failed to log in with the minted API key %s: %w", key.Prefix, err)
+			if deleteErr := c.Api.User().DeleteAPIKey(ctx, key.Prefix); deleteErr != nil {
+				return fmt.Errorf("%w, and deleting it failed: %w", err, deleteErr)
+			}
+			return fmt.Errorf("%w, deleted it", err)
+		}
+	}
+
+	if app.Config.LoginKey != "" && app.Config.LoginKey != loginKey {
+		deleteLoginKey(ctx, app)
+	}
+	if app.dryRun {
+		return nil
+	}
+	app.Config.ApiToken = token
+	app.Config.LoginKey = loginKey
+	if err := config.Save(app.Config); err != nil {
+		return err
+	}
+	app.Client = app.newAPIClient()
+
+	summary.Name = user.User.Name
+	summary.Email = user.User.Email
+	summary.Teams = []loginTeam{}
+	for _, t := range user.Teams {
+		if !t.Invitation && len(t.EffectiveRoles) > 0 {
+			summary.Teams = append(summary.Teams, loginTeam{Handle: t.Handle, Roles: t.EffectiveRoles})
+		}
+	}
+	return printOutput(app, summary)
+}
+
+func logout(app *App, ctx context.Context, cmd *cli.Command) error {
+	name := app.Config.ProfileName()
+	if app.Config.Token() == "" && app.Config.LoginKey == "" {
+		return fmt.Errorf("profile %s is not logged in", name)
+	}
+
+	if prefix := app.Config.LoginKey; prefix != "" && !cmd.Bool("keep-key") {
+		err := app.Client.Api.User().DeleteAPIKey(ctx, prefix)
+		switch {
+		case err == nil:
+			fmt.Printf("API key %s deleted\n", prefix)
+		case errors.Is(err, client.ErrNotFound), errors.Is(err, client.ErrUnauthorized):
+			// Already deleted
+		case errors.Is(err, client.ErrForbidden):
+			printErrorf("Warning: API key %s needs user role owner to delete itself, delete it with another key: hotaisle user api-keys delete --prefix %s\n", prefix, prefix)
+		default:
+			return fmt.Errorf("failed to delete the API key %s, pass --keep-key to log out anyway: %w", prefix, err)
+		}
+	}
+	if app.dryRun {
+		return nil
+	}
+
+	app.Config.ApiToken = ""
+	app.Config.LoginKey = ""
+	if err := config.Save(app.Config); err != nil {
+		return err
+	}
+	app.Client = app.newAPIClient()
+	fmt.Printf("Logged out of profile %s\n", name)
+	return nil
+}
+
+// readLoginToken reads the API key to log in with from stdin, or asks for it
+// when stdin is a terminal
+func readLoginToken() (string, error) {
+	var token string
+	if stdinIsTerminal() {
+		var err error
+		token, err = askSecret("API key: ")
+		if err != nil {
+			return "", err
+		}
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the API key from stdin: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return "", errors.New("missing API key, pipe it to stdin or type it when asked")
+	}
+	return token, nil
+}
+
+// parseKeyTeams parses the --team flags of login into the teams of a new API
+// key. A team without roles gets the roles of the user on it. Without flags,
+// the key gets every team of the user, since the API does not document what a
+// key without teams can reach.
+func parseKeyTeams(values []string, userTeams []client.UserTeam) ([]client.UserAPIKeyTeamRoles, error) {
+	var teams []client.UserAPIKeyTeamRoles
+	if len(values) == 0 {
+		for _, t := range userTeams {
+			if !t.Invitation && len(t.EffectiveRoles) > 0 {
+				teams = append(teams, client.UserAPIKeyTeamRoles{Team: t.Handle, Roles: t.EffectiveRoles})
+			}
+		}
+		return teams, nil
+	}
+	for _, value := range values {
+		handle, roles, hasRoles := strings.Cut(value, "=")
+		i := slices.IndexFunc(userTeams, func(t client.UserTeam) bool { return t.Handle == handle })
+		if i < 0 || userTeams[i].Invitation {
+			return nil, fmt.Errorf("invalid team %q, you are not a member of it", handle)
+		}
+		team := client.UserAPIKeyTeamRoles{Team: handle, Roles: userTeams[i].EffectiveRoles}
+		if hasRoles {
+			team.Roles = strings.Split(roles, ",")
+		}
+		if len(team.Roles) == 0 {
+			return nil, fmt.Errorf("invalid team %q, missing roles", value)
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// defaultKeyLabel labels minted API keys with the machine they were minted for
+func defaultKeyLabel() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "hotaisle-cli"
+	}
+	return "hotaisle-cli on " + host
+}
+
+// deleteLoginKey deletes the API key minted by a previous login, which is
+// being replaced. Failing to is only a warning.
+func deleteLoginKey(ctx context.Context, app *App) {
+	prefix := app.Config.LoginKey
+	if err := app.Client.Api.User().DeleteAPIKey(ctx, prefix); err != nil && !errors.Is(err, client.ErrNotFound) {
+		slog.Warn("Could not delete the API key of the previous login", "prefix", prefix, "error", errorMessage(err))
+	}
+}
+
+func newCommandLogin(app *App) *cli.Command {
+	return buildCommand(app, loginCommands)
+}
+
+func newCommandLogout(app *App) *cli.Command {
+	return buildCommand(app, logoutCommands)
+}
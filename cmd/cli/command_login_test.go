@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoginTestApp returns an app logged out of a fake API where the user owns
+// team ml and is invited to team web
+func newLoginTestApp(t *testing.T) (*App, *fakeapi.Server) {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1000_00)
+	fake.AddInvitation(client.Team{Handle: "web", Name: "Web"}, fakeapi.RoleUser)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Config.BaseURL = srv.URL + "/api"
+	app.Client = app.newAPIClient()
+	return app, fake
+}
+
+// runLogin runs login or logout with stdin holding input, returning its stdout
+func runLogin(t *testing.T, app *App, def commandDef, input string, args ...string) (string, error) {
+	withStdin(t, input)
+	var runErr error
+	output := test.CaptureStdout(t, func() error {
+		runErr = buildCommand(app, def).Run(context.Background(), append([]string{def.Name}, args...))
+		return nil
+	})
+	return output, runErr
+}
+
+func TestLogin(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+
+	output, err := runLogin(t, app, loginCommands, fake.Token()+"\n")
+	require.NoError(t, err)
+
+	var summary loginSummary
+	require.NoError(t, json.Unmarshal([]byte(output), &summary))
+	assert.Equal(t, "default", summary.Profile)
+	assert.NotEmpty(t, summary.Email)
+	assert.False(t, summary.Minted)
+	assert.Equal(t, []loginTeam{{Handle: "ml", Roles: []string{fakeapi.RoleOwner}}}, summary.Teams)
+	assert.NotContains(t, output, fake.Token())
+
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, fake.Token(), saved.ApiToken)
+	assert.Empty(t, saved.LoginKey)
+}
+
+func TestLogin_MintScopedKey(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+
+	output, err := runLogin(t, app, loginCommands, fake.Token(), "--mint", "--label", "laptop", "--team", "ml=operator")
+	require.NoError(t, err)
+	var summary loginSummary
+	require.NoError(t, json.Unmarshal([]byte(output), &summary))
+	assert.True(t, summary.Minted)
+	assert.Equal(t, []loginTeam{{Handle: "ml", Roles: []string{fakeapi.RoleOperator}}}, summary.Teams)
+
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, fake.Token(), saved.ApiToken)
+	assert.Equal(t, summary.Key, saved.LoginKey)
+
+	keys, err := app.Client.Api.User().GetAPIKeys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "laptop", keys[1].Label)
+	assert.Equal(t, summary.Key, keys[1].Prefix)
+
+	// A key with user role user cannot delete itself, so logout only warns
+	stderr := test.CaptureStderr(t, func() error {
+		output, err = runLogin(t, app, logoutCommands, "")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Logged out of profile default\n", output)
+	assert.Contains(t, stderr, "needs user role owner to delete itself")
+	assert.Empty(t, app.Config.ApiToken)
+	assert.Empty(t, app.Config.LoginKey)
+}
+
+func TestLogin_MintKeyForAllTeams(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+
+	_, err := runLogin(t, app, loginCommands, fake.Token(), "--mint")
+	require.NoError(t, err)
+
+	// The teams are listed in the request, not left to the API
+	keys, err := app.Client.Api.User().GetAPIKeys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Len(t, keys[1].Teams, 1, "the invitation to web is left out")
+	assert.Equal(t, "ml", keys[1].Teams[0].Team.Handle)
+	assert.Equal(t, []string{fakeapi.RoleOwner}, keys[1].Teams[0].Roles)
+}
+
+func TestLogout_DeletesMintedKey(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+
+	_, err := runLogin(t, app, loginCommands, fake.Token(), "--mint", "--user-role", "owner")
+	require.NoError(t, err)
+	prefix := app.Config.LoginKey
+	require.NotEmpty(t, prefix)
+
+	output, err := runLogin(t, app, logoutCommands, "")
+	require.NoError(t, err)
+	assert.Equal(t, "API key "+prefix+" deleted\nLogged out of profile default\n", output)
+
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	keys, err := app.Client.Api.User().GetAPIKeys(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	app.Config.ApiToken = ""
+	_, err = runLogin(t, app, logoutCommands, "")
+	assert.EqualError(t, err, "profile default is not logged in")
+}
+
+func TestLogin_Errors(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+
+	tests := []struct {
+		input string
+		args  []string
+		want  string
+	}{
+		{fake.Token(), []string{"--team", "ml"}, "--team and --label need --mint"},
+		{"", nil, "missing API key, pipe it to stdin or type it when asked"},
+		{fake.Token(), []string{"--mint", "--team", "web"}, `invalid team "web", you are not a member of it`},
+		{"wrong-token", nil, "failed to log in: "},
+	}
+	for _, tt := range tests {
+		_, err := runLogin(t, app, loginCommands, tt.input, tt.args...)
+		assert.ErrorContains(t, err, tt.want)
+	}
+	assert.Empty(t, app.Config.ApiToken)
+}
+
+func TestLogin_DeletesMintedKeyThatFails(t *testing.T) {
+	app, _ := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1000_00)
+	// The user cannot be read with any key but the one logged in with
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/user/" && !strings.HasSuffix(r.Header.Get("Authorization"), fake.Token()) {
+			http.Error(w, `"forbidden"`, http.StatusForbidden)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	app.Config.BaseURL = srv.URL + "/api"
+
+	_, err := runLogin(t, app, loginCommands, fake.Token(), "--mint")
+	assert.ErrorIs(t, err, client.ErrForbidden)
+	assert.ErrorContains(t, err, ", deleted it")
+	assert.Empty(t, app.Config.ApiToken)
+
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	keys, err := app.Client.Api.User().GetAPIKeys(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, keys, 1, "only the key logged in with is left")
+}
+
+func TestLogin_DryRunSavesNothing(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+	app.dryRun = true
+
+	_, err := runLogin(t, app, loginCommands, fake.Token())
+	require.NoError(t, err)
+	assert.Empty(t, app.Config.ApiToken)
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Empty(t, saved.ApiToken)
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/urfave/cli/v3"
+)
+
+var planCommands = commandDef{
+	Name:  "plan",
+	Usage: "Show the changes needed to match a fleet manifest.",
+	Flags: []flagDef{
+		{Name: "file", Aliases: []string{"f"}, Usage: "Manifest file (YAML or JSON), - for stdin", Required: true},
+		{Name: "prune", Usage: "Delete VMs and servers that are not in the manifest", Bool: true},
+	},
+	Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+		plan, err := planManifest(app, ctx, cmd)
+		if err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			slog.Info("No changes, the fleet matches the manifest")
+		}
+		return printOutput(app, plan.Changes)
+	},
+}
+
+var applyCommands = commandDef{
+	Name:  "apply",
+	Usage: "Provision, update and delete resources to match a fleet manifest.",
+	Flags: []flagDef{
+		{Name: "file", Aliases: []string{"f"}, Usage: "Manifest file (YAML or JSON), - for stdin", Required: true},
+		{Name: "prune", Usage: "Delete VMs and servers that are not in the manifest", Bool: true},
+	},
+	Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+		plan, err := planManifest(app, ctx, cmd)
+		if err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			slog.Info("No changes, the fleet matches the manifest")
+			return printOutput(app, plan.Changes)
+		}
+
+		if !app.skipConfirmation() {
+			if err := renderTable(os.Stderr, plan.Changes, false); err != nil {
+				return err
+			}
+			ok, err := confirm(app, fmt.Sprintf("Apply %d changes?", len(plan.Changes)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("apply canceled")
+			}
+		}
+
+		if app.dryRun {
+			// The requests are printed but not sent, so the changes are only
+			// planned and created resources get no name
+			for i := range plan.Changes {
+				change := &plan.Changes[i]
+				slog.Info("Would apply", "action", change.Action, "team", change.Team, "kind", change.Kind, "name", change.Name)
+				if err := applyChange(ctx, app.Client.Api, change, true); err != nil {
+					return err
+				}
+			}
+			return printOutput(app, plan.Changes)
+		}
+
+		applied := make([]planChange, 0, len(plan.Changes))
+		for i := range plan.Changes {
+			change := &plan.Changes[i]
+			slog.Info("Applying", "action", change.Action, "team", change.Team, "kind", change.Kind, "name", change.Name)
+			if err := applyChange(ctx, app.Client.Api, change, false); err != nil {
+				if len(applied) > 0 {
+					_ = printOutput(app, applied)
+				}
+				return fmt.Errorf("%s %s %s: %w (%d of %d changes applied)", change.Action, change.Kind, change.Name, err, len(applied), len(plan.Changes))
+			}
+			applied = append(applied, *change)
+		}
+		return printOutput(app, applied)
+	},
+}
+
+// planManifest loads the manifest given by --file and diffs it against the
+// existing resources, logging any warnings
+func planManifest(app *App, ctx context.Context, cmd *cli.Command) (*manifestPlan, error) {
+	m, err := loadManifest(cmd.String("file"))
+	if err != nil {
+		return nil, err
+	}
+	existing, err := fetchResources(ctx, app.Client.Api, m)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := computePlan(m, existing, cmd.Bool("prune"))
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range plan.Warnings {
+		slog.Warn(warning)
+	}
+	return plan, nil
+}
+
+func newCommandPlan(app *App) *cli.Command {
+	return buildCommand(app, planCommands)
+}
+
+func newCommandApply(app *App) *cli.Command {
+	return buildCommand(app, applyCommands)
+}
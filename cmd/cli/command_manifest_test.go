@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `
+teams:
+  - team: ml
+    vms:
+      - description: trainer
+        cpu_cores: 2
+`
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fleet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// fleetHandler serves an empty team and records the mutating requests it gets
+func fleetHandler(t *testing.T, requests *[]string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/api/teams/ml/virtual_machines/":
+			return test.NewJSONResponse(t, http.StatusOK, []client.VirtualMachineDetails{}), nil
+		case req.Method == http.MethodGet && req.URL.Path == "/api/teams/ml/bare_metal/":
+			return test.NewJSONResponse(t, http.StatusOK, []client.BareMetalServerDetails{}), nil
+		}
+		body, _ := io.ReadAll(req.Body)
+		*requests = append(*requests, req.Method+" "+req.URL.Path+" "+string(body))
+		if req.Method == http.MethodPost {
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineDetails{VirtualMachine: client.VirtualMachine{Name: "vm-new"}}), nil
+		}
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	}
+}
+
+func TestPlanCommand(t *testing.T) {
+	app, _ := setupTestApp(t)
+	var requests []string
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(fleetHandler(t, &requests))))
+
+	cmd, err := getCommand(app, planCommands, "plan", map[string]string{"file": writeManifest(t, testManifest)})
+	require.NoError(t, err)
+	output := executeCommand(t, cmd)
+
+	var changes []planChange
+	require.NoError(t, json.Unmarshal([]byte(output), &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, planChange{Action: planCreate, Team: "ml", Kind: kindVM, Description: "trainer", Details: "2 cpus"}, changes[0])
+	assert.Empty(t, requests, "plan must not change anything")
+}
+
+func TestApplyCommand(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.yes = true
+	var requests []string
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(fleetHandler(t, &requests))))
+
+	cmd, err := getCommand(app, applyCommands, "apply", map[string]string{"file": writeManifest(t, testManifest)})
+	require.NoError(t, err)
+	output := executeCommand(t, cmd)
+
+	assert.Equal(t, []string{
+		`POST /api/teams/ml/virtual_machines/ {"cpu_cores":2}`,
+		`PATCH /api/teams/ml/virtual_machines/vm-new/ {"description":"trainer"}`,
+	}, requests)
+	var changes []planChange
+	require.NoError(t, json.Unmarshal([]byte(output), &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, "vm-new", changes[0].Name)
+}
+
+func TestApplyCommand_RequiresConfirmation(t *testing.T) {
+	app, _ := setupTestApp(t)
+	var requests []string
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(fleetHandler(t, &requests))))
+
+	stdin, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	cmd, err := getCommand(app, applyCommands, "apply", map[string]string{"file": writeManifest(t, testManifest)})
+	require.NoError(t, err)
+	err = cmd.Action(t.Context(), cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pass --yes")
+	assert.Empty(t, requests)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+var copyCommands = commandDef{
+	Name:      "cp",
+	Usage:     "Copy files to and from VMs and bare metal servers with scp, using NAME:PATH for remote paths.",
+	ArgsUsage: "SOURCE... DEST",
+	Flags: append([]flagDef{
+		{Name: "team", Usage: "Team handle", Required: true},
+		{Name: "recursive", Aliases: []string{"r"}, Usage: "Copy directories recursively", Bool: true},
+	}, sshFlags...),
+	Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+		paths := cmd.Args().Slice()
+		if len(paths) < 2 {
+			return errors.New("expected at least a source and a destination")
+		}
+		targets, err := teamSSHTargets(ctx, app.Client.Api, cmd.String("team"))
+		if err != nil {
+			return err
+		}
+		paths, err = scpArgs(targets, cmd.String("user"), paths)
+		if err != nil {
+			return err
+		}
+		identity, err := sshIdentity(app, ctx, cmd)
+		if err != nil {
+			return err
+		}
+
+		args := identityArgs(identity)
+		if cmd.Bool("recursive") {
+			args = append(args, "-r")
+		}
+		return runExternal(ctx, "scp", append(args, paths...)...)
+	},
+}
+
+var sshConfigCommands = commandDef{
+	Name:  "ssh-config",
+	Usage: "Manage the ssh configuration of your machines.",
+	Commands: []commandDef{
+		{
+			Name:  "generate",
+			Usage: "Write a Host block for every machine of a team to a file to Include from ~/.ssh/config.",
+			Flags: append([]flagDef{
+				{Name: "team", Usage: "Team handle", Required: true},
+				{Name: "file", Usage: "File to write, or - for stdout (default: ~/.ssh/config.d/hotaisle-TEAM)"},
+			}, sshFlags...),
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				team := cmd.String("team")
+				targets, err := teamSSHTargets(ctx, app.Client.Api, team)
+				if err != nil {
+					return err
+				}
+				identity, err := sshIdentity(app, ctx, cmd)
+				if err != nil {
+					return err
+				}
+
+				var buf bytes.Buffer
+				writeSSHConfig(&buf, team, cmd.String("user"), identity, targets)
+
+				file := cmd.String("file")
+				if file == "-" {
+					_, err := os.Stdout.Write(buf.Bytes())
+					return err
+				}
+				if file == "" {
+					home, err := os.UserHomeDir()
+					if err != nil {
+						return err
+					}
+					file = filepath.Join(home, ".ssh", "config.d", "hotaisle-"+team)
+				}
+				if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+					return err
+				}
+				if err := os.WriteFile(file, buf.Bytes(), 0o600); err != nil {
+					return err
+				}
+				printErrorf("Wrote %d hosts to %s. Add this line to the top of ~/.ssh/config to use them:\n\n  Include %s\n", len(targets), file, file)
+				return nil
+			},
+		},
+	},
+}
+
+// sshHostAlias is the name of a machine in the generated ssh config
+func sshHostAlias(team, name string) string {
+	return name + "." + team + ".hotaisle"
+}
+
+// writeSSHConfig writes a Host block for every target
+func writeSSHConfig(w io.Writer, team, user, identity string, targets []sshTarget) {
+	_, _ = fmt.Fprintf(w, "# Generated by `hotaisle ssh-config generate --team %s`, changes are overwritten.\n", team)
+	for _, target := range targets {
+		_, _ = fmt.Fprintln(w)
+		if target.Description != "" {
+			_, _ = fmt.Fprintf(w, "# %s\n", strings.ReplaceAll(target.Description, "\n", " "))
+		}
+		_, _ = fmt.Fprintf(w, "Host %s\n", sshHostAlias(team, target.Name))
+		_, _ = fmt.Fprintf(w, "  HostName %s\n", target.Host)
+		_, _ = fmt.Fprintf(w, "  Port %d\n", target.Port)
+		_, _ = fmt.Fprintf(w, "  User %s\n", user)
+		if identity != "" {
+			_, _ = fmt.Fprintf(w, "  IdentityFile \"%s\"\n", identity)
+			_, _ = fmt.Fprintln(w, "  IdentitiesOnly yes")
+		}
+	}
+}
+
+func newCommandCopy(app *App) *cli.Command {
+	return buildCommand(app, copyCommands)
+}
+
+func newCommandSSHConfig(app *App) *cli.Command {
+	return buildCommand(app, sshConfigCommands)
+}
@@ -21,7 +21,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(teams)
+				return printOutput(app, teams)
 			},
 		},
 		{
@@ -41,7 +41,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(team)
+				return printResult(app, team)
 			},
 		},
 		{
@@ -55,7 +55,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(team)
+				return printOutput(app, team)
 			},
 		},
 		{
@@ -75,7 +75,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(team)
+				return printResult(app, team)
 			},
 		},
 		{
@@ -86,7 +86,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(invitations)
+				return printOutput(app, invitations)
 			},
 		},
 		{
@@ -100,7 +100,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(team)
+				return printResult(app, team)
 			},
 		},
 		{
@@ -114,7 +114,15 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(balance)
+				return printOutput(app, balance)
+			},
+			Commands: []commandDef{
+				{
+					Name:   "watch",
+					Usage:  "Watch the team balance and alert before it runs out.",
+					Flags:  balanceWatchFlags,
+					Action: watchBalance,
+				},
 			},
 		},
 		{
@@ -131,7 +139,7 @@ var teamCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(resp)
+				return printResult(app, resp)
 			},
 		},
 		{
@@ -149,7 +157,7 @@ var teamCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(team.Members)
+						return printOutput(app, team.Members)
 					},
 				},
 				{
@@ -163,7 +171,7 @@ var teamCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(invitations)
+						return printOutput(app, invitations)
 					},
 				},
 				{
@@ -203,12 +211,13 @@ var teamCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(member)
+						return printResult(app, member)
 					},
 				},
 				{
-					Name:  "remove",
-					Usage: "Remove a member from the team.",
+					Name:        "remove",
+					Usage:       "Remove a member from the team.",
+					Destructive: "email",
 					Flags: []flagDef{
 						{Name: "handle", Usage: "Team handle", Required: true},
 						{Name: "email", Usage: "User email", Required: true},
@@ -164,6 +164,7 @@ func TestTeamMembersInviteCommand_Success(t *testing.T) {
 
 func TestTeamMembersRemoveCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/members/user@example.com/", http.MethodDelete, 200, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
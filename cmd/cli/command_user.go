@@ -21,7 +21,7 @@ var userCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(user)
+				return printOutput(app, user)
 			},
 		},
 		{
@@ -37,7 +37,7 @@ var userCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(user)
+				return printResult(app, user)
 			},
 		},
 		{
@@ -52,7 +52,7 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(keys)
+						return printOutput(app, keys)
 					},
 				},
 				{
@@ -68,7 +68,7 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(result)
+						return printResult(app, result)
 					},
 				},
 				{
@@ -100,7 +100,7 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(keys)
+						return printOutput(app, keys)
 					},
 				},
 				{
@@ -114,7 +114,7 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(key)
+						return printOutput(app, key)
 					},
 				},
 				{
@@ -132,9 +132,15 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(key)
+						return printResult(app, key)
 					},
 				},
+				{
+					Name:   "rotate",
+					Usage:  "Replace an API key with a new one of the same label, user role and teams, then delete it. Any step that fails rolls back the ones before it.",
+					Flags:  rotateAPIKeyFlags,
+					Action: rotateAPIKey,
+				},
 				{
 					Name:  "update",
 					Usage: "Update an existing API key.",
@@ -151,12 +157,13 @@ var userCommands = commandDef{
 						if err != nil {
 							return err
 						}
-						return printJSON(key)
+						return printResult(app, key)
 					},
 				},
 				{
-					Name:  "delete",
-					Usage: "Delete an API key.",
+					Name:        "delete",
+					Usage:       "Delete an API key.",
+					Destructive: "prefix",
 					Flags: []flagDef{
 						{Name: "prefix", Usage: "API key prefix identifier", Required: true},
 					},
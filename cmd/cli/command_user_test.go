@@ -406,6 +406,7 @@ func TestUserAPIKeysUpdateCommand_Success(t *testing.T) {
 
 func TestUserAPIKeysDeleteCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/user/api_keys/abc123/", http.MethodDelete, 204, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
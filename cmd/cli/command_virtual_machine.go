@@ -3,7 +3,9 @@ package cli
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"time"
 
 	"hotaisle-cli/client"
 
@@ -25,7 +27,7 @@ var virtualMachineCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(vms)
+				return printOutput(app, vms)
 			},
 		},
 		{
@@ -40,7 +42,7 @@ var virtualMachineCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(vm)
+				return printOutput(app, vm)
 			},
 		},
 		{
@@ -54,6 +56,9 @@ var virtualMachineCommands = commandDef{
 				{Name: "disk-gb", Usage: "Disk in GB"},
 				{Name: "gpu-model", Usage: "GPU model"},
 				{Name: "user-data-url", Usage: "URL for cloud-init user data"},
+				{Name: "wait", Usage: "Wait until the VM is running", Bool: true},
+				{Name: "wait-timeout", Usage: "Maximum time to wait with --wait", Value: defaultWaitTimeout},
+				forceFlag,
 			},
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
 				var req client.VMProvisionRequest
@@ -72,7 +77,8 @@ var virtualMachineCommands = commandDef{
 					if err != nil {
 						return fmt.Errorf("invalid ram-gb: %w", err)
 					}
-					req.RAMCapacity = &ramGB
+					ramBytes := ramGB << 30
+					req.RAMCapacity = &ramBytes
 				}
 
 				if diskGBStr := cmd.String("disk-gb"); diskGBStr != "" {
@@ -80,7 +86,8 @@ var virtualMachineCommands = commandDef{
 					if err != nil {
 						return fmt.Errorf("invalid disk-gb: %w", err)
 					}
-					req.DiskCapacity = &diskGB
+					diskBytes := diskGB << 30
+					req.DiskCapacity = &diskBytes
 				}
 
 				if gpuModel := cmd.String("gpu-model"); gpuModel != "" {
@@ -102,11 +109,30 @@ var virtualMachineCommands = commandDef{
 					return fmt.Errorf("at least one specification must be provided (cpu-cores, ram-gb, disk-gb, or gpu-model)")
 				}
 
+				wait, err := vmWait(app, cmd, client.VMStateRunning, false)
+				if err != nil {
+					return err
+				}
+
+				price, err := vmPrice(ctx, app.Client.Api, cmd.String("team"), req)
+				if err := guardCost(app, ctx, cmd, cmd.String("team"), price, err); err != nil {
+					return err
+				}
+
 				resp, err := app.Client.Api.VirtualMachines().Provision(ctx, cmd.String("team"), req)
 				if err != nil {
 					return err
 				}
-				return printJSON(resp)
+				if wait != nil {
+					if err := wait(ctx, resp.Name); err != nil {
+						return err
+					}
+					resp, err = app.Client.Api.VirtualMachines().Get(ctx, cmd.String("team"), resp.Name)
+					if err != nil {
+						return err
+					}
+				}
+				return printResult(app, resp)
 			},
 		},
 		{
@@ -129,19 +155,18 @@ var virtualMachineCommands = commandDef{
 			},
 		},
 		{
-			Name:  "delete",
-			Usage: "Delete a virtual machine and its resources. Ends billing.",
-			Flags: []flagDef{
-				{Name: "team", Usage: "Team handle", Required: true},
-				{Name: "vm", Usage: "VM name", Required: true},
-			},
+			Name:        "delete",
+			Usage:       "Delete virtual machines and their resources. Ends billing.",
+			Destructive: "vm",
+			Flags:       vmBulkFlags(),
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Delete(ctx, cmd.String("team"), cmd.String("vm"))
-				if err != nil {
-					return err
-				}
-				fmt.Println("VM deleted successfully")
-				return nil
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM deleted successfully",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().Delete(ctx, cmd.String("team"), vm)
+					},
+				})
 			},
 		},
 		{
@@ -155,7 +180,7 @@ var virtualMachineCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(available)
+				return printOutput(app, available)
 			},
 		},
 		{
@@ -170,111 +195,222 @@ var virtualMachineCommands = commandDef{
 				if err != nil {
 					return err
 				}
-				return printJSON(state)
+				return printOutput(app, state)
 			},
 		},
 		{
 			Name:  "start",
-			Usage: "Start a stopped virtual machine.",
-			Flags: []flagDef{
-				{Name: "team", Usage: "Team handle", Required: true},
-				{Name: "vm", Usage: "VM name", Required: true},
-			},
+			Usage: "Start stopped virtual machines.",
+			Flags: vmBulkFlags(
+				flagDef{Name: "wait", Usage: "Wait until the VM is running", Bool: true},
+				flagDef{Name: "wait-timeout", Usage: "Maximum time to wait with --wait", Value: defaultWaitTimeout},
+			),
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Start(ctx, cmd.String("team"), cmd.String("vm"))
+				wait, err := vmWait(app, cmd, client.VMStateRunning, false)
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM start command sent")
-				return nil
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM start command sent",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().Start(ctx, cmd.String("team"), vm)
+					},
+					Wait: wait,
+				})
 			},
 		},
 		{
 			Name:  "stop",
-			Usage: "Forcefully stop a running virtual machine. Continues billing.",
-			Flags: []flagDef{
-				{Name: "team", Usage: "Team handle", Required: true},
-				{Name: "vm", Usage: "VM name", Required: true},
-			},
+			Usage: "Forcefully stop running virtual machines. Continues billing.",
+			Flags: vmBulkFlags(
+				flagDef{Name: "wait", Usage: "Wait until the VM is stopped", Bool: true},
+				flagDef{Name: "wait-timeout", Usage: "Maximum time to wait with --wait", Value: defaultWaitTimeout},
+			),
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Stop(ctx, cmd.String("team"), cmd.String("vm"))
+				wait, err := vmWait(app, cmd, client.VMStateShutOff, false)
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM stop command sent")
-				return nil
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM stop command sent",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().Stop(ctx, cmd.String("team"), vm)
+					},
+					Wait: wait,
+				})
 			},
 		},
 		{
 			Name:  "shutdown",
-			Usage: "Gracefully shutdown a virtual machine.",
-			Flags: []flagDef{
-				{Name: "team", Usage: "Team handle", Required: true},
-				{Name: "vm", Usage: "VM name", Required: true},
-			},
+			Usage: "Gracefully shutdown virtual machines.",
+			Flags: vmBulkFlags(
+				flagDef{Name: "wait", Usage: "Wait until the VM is stopped", Bool: true},
+				flagDef{Name: "wait-timeout", Usage: "Maximum time to wait with --wait", Value: defaultWaitTimeout},
+			),
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Shutdown(ctx, cmd.String("team"), cmd.String("vm"))
+				wait, err := vmWait(app, cmd, client.VMStateShutOff, false)
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM shutdown command sent")
-				return nil
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM shutdown command sent",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().Shutdown(ctx, cmd.String("team"), vm)
+					},
+					Wait: wait,
+				})
 			},
 		},
 		{
 			Name:  "reboot",
-			Usage: "Gracefully reboot a virtual machine.",
+			Usage: "Gracefully reboot virtual machines.",
+			Flags: vmBulkFlags(
+				flagDef{Name: "wait", Usage: "Wait until the VM is running again after the reboot", Bool: true},
+				flagDef{Name: "wait-timeout", Usage: "Maximum time to wait with --wait", Value: defaultWaitTimeout},
+			),
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				wait, err := vmWait(app, cmd, client.VMStateRunning, true)
+				if err != nil {
+					return err
+				}
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM reboot command sent",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().Reboot(ctx, cmd.String("team"), vm)
+					},
+					Wait: wait,
+				})
+			},
+		},
+		{
+			Name:  "hard-reset",
+			Usage: "Forcefully reset virtual machines.",
+			Flags: vmBulkFlags(),
+			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
+				return forEachVM(app, ctx, cmd, bulkAction{
+					Kind: "VM",
+					Done: "VM hard-reset command sent",
+					Run: func(ctx context.Context, vm string) (string, error) {
+						return "", app.Client.Api.VirtualMachines().HardReset(ctx, cmd.String("team"), vm)
+					},
+				})
+			},
+		},
+		{
+			Name:        "rebuild",
+			Usage:       "Rebuild the virtual machine to its initial state.",
+			Destructive: "vm",
 			Flags: []flagDef{
 				{Name: "team", Usage: "Team handle", Required: true},
 				{Name: "vm", Usage: "VM name", Required: true},
+				{Name: "user-data-url", Usage: "New URL for cloud-init user data"},
 			},
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Reboot(ctx, cmd.String("team"), cmd.String("vm"))
+				err := app.Client.Api.VirtualMachines().Rebuild(ctx, cmd.String("team"), cmd.String("vm"), client.VMResetRequest{
+					UserDataURL: cmd.String("user-data-url"),
+				})
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM reboot command sent")
+				fmt.Println("VM rebuild command sent")
 				return nil
 			},
 		},
 		{
-			Name:  "hard-reset",
-			Usage: "Forcefully reset a virtual machine.",
+			Name:  "console",
+			Usage: "Attach to the serial console of a virtual machine.",
 			Flags: []flagDef{
 				{Name: "team", Usage: "Team handle", Required: true},
 				{Name: "vm", Usage: "VM name", Required: true},
+				{Name: "escape", Usage: "Key that detaches from the console, e.g. ^] or none", Value: defaultConsoleEscape},
 			},
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().HardReset(ctx, cmd.String("team"), cmd.String("vm"))
+				escape, err := parseEscapeKey(cmd.String("escape"))
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM hard-reset command sent")
-				return nil
+				console, err := app.Client.Api.VirtualMachines().Console(ctx, cmd.String("team"), cmd.String("vm"))
+				if err != nil {
+					return err
+				}
+				return runConsole(ctx, console, cmd.String("vm"), cmd.String("escape"), escape)
 			},
 		},
 		{
-			Name:  "rebuild",
-			Usage: "Rebuild the virtual machine to its initial state.",
-			Flags: []flagDef{
+			Name:      "ssh",
+			Usage:     "Connect to a virtual machine with ssh, running the command after -- if any.",
+			ArgsUsage: "[-- command...]",
+			Flags: append([]flagDef{
 				{Name: "team", Usage: "Team handle", Required: true},
 				{Name: "vm", Usage: "VM name", Required: true},
-				{Name: "user-data-url", Usage: "New URL for cloud-init user data"},
-			},
+			}, sshFlags...),
 			Action: func(app *App, ctx context.Context, cmd *cli.Command) error {
-				err := app.Client.Api.VirtualMachines().Rebuild(ctx, cmd.String("team"), cmd.String("vm"), client.VMResetRequest{
-					UserDataURL: cmd.String("user-data-url"),
-				})
+				target, err := vmSSHTarget(ctx, app.Client.Api, cmd.String("team"), cmd.String("vm"))
 				if err != nil {
 					return err
 				}
-				fmt.Println("VM rebuild command sent")
-				return nil
+				return runSSH(app, ctx, cmd, target)
 			},
 		},
 	},
 }
 
+// defaultWaitTimeout is the default of the --wait-timeout flag
+const defaultWaitTimeout = "10m"
+
+// A reboot can be over in a few seconds, so reboot --wait polls at a short
+// fixed interval, and takes a VM that is still running after rebootGrace as
+// rebooted even if no other state was seen.
+var (
+	rebootPollInterval = time.Second
+	rebootGrace        = 30 * time.Second
+)
+
+// vmWait returns the wait of a VM command for --wait, which blocks until the
+// VM is in state, logging every state it passes through, or nil without
+// --wait. With again, the VM must first leave the state, as it does when it
+// reboots, or stay in it for rebootGrace. --wait-timeout is checked here, before any VM is changed. With
+// --dry-run nothing changes, so there is nothing to wait for.
+func vmWait(app *App, cmd *cli.Command, state string, again bool) (func(ctx context.Context, vmName string) error, error) {
+	if !cmd.Bool("wait") {
+		return nil, nil
+	}
+	timeout, err := time.ParseDuration(cmd.String("wait-timeout"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid wait-timeout: %w", err)
+	}
+	if app.dryRun {
+		return nil, nil
+	}
+
+	return func(ctx context.Context, vmName string) error {
+		until := client.VMStateIs(state)
+		waitCfg := client.WaitConfig{Timeout: timeout}
+		if again {
+			until = client.VMStateReturnsTo(state, rebootGrace)
+			waitCfg.Interval = rebootPollInterval
+			waitCfg.MaxInterval = rebootPollInterval
+		}
+		start := time.Now()
+		vmState, err := app.Client.Api.VirtualMachines().WaitForState(ctx, cmd.String("team"), vmName,
+			waitCfg,
+			until,
+			func(vmState *client.VirtualMachineState) {
+				slog.Info("VM state", "vm", vmName, "state", vmState.State, "elapsed", time.Since(start).Round(time.Second))
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("waiting for VM %s: %w", vmName, err)
+		}
+		slog.Info("VM ready", "vm", vmName, "state", vmState.State, "elapsed", time.Since(start).Round(time.Second))
+		return nil
+	}, nil
+}
+
 func newCommandVirtualMachine(app *App) *cli.Command {
 	return buildCommand(app, virtualMachineCommands)
 }
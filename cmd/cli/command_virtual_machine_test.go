@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"hotaisle-cli/client"
 	"hotaisle-cli/internal/api"
 	"hotaisle-cli/test"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVMListCommand_Success(t *testing.T) {
@@ -67,6 +70,20 @@ func TestVMGetCommand_Success(t *testing.T) {
 	assert.Equal(t, "vm-1", result.Name)
 }
 
+// newCreateMockClient serves a POST to path with resp, and no available types,
+// so that the cost estimate made before creating resources is skipped
+func newCreateMockClient(t *testing.T, path string, resp any) *http.Client {
+	return test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/available/") {
+			return test.NewJSONResponse(t, http.StatusOK, []any{}), nil
+		}
+		if req.Method != http.MethodPost || req.URL.Path != path {
+			t.Errorf("Expected POST %s, got %s %s", path, req.Method, req.URL.Path)
+		}
+		return test.NewJSONResponse(t, http.StatusOK, resp), nil
+	})
+}
+
 func TestVMProvisionCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
 
@@ -76,7 +93,7 @@ func TestVMProvisionCommand_Success(t *testing.T) {
 		},
 	}
 
-	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/", http.MethodPost, 200, mockVM)
+	mockClient := newCreateMockClient(t, "/api/teams/test-team/virtual_machines/", mockVM)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
 
 	flags := map[string]string{
@@ -106,7 +123,7 @@ func TestVMProvisionCommand_WithGPU(t *testing.T) {
 		},
 	}
 
-	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/", http.MethodPost, 200, mockVM)
+	mockClient := newCreateMockClient(t, "/api/teams/test-team/virtual_machines/", mockVM)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
 
 	flags := map[string]string{
@@ -134,7 +151,7 @@ func TestVMProvisionCommand_WithPartialSpecs(t *testing.T) {
 		},
 	}
 
-	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/", http.MethodPost, 200, mockVM)
+	mockClient := newCreateMockClient(t, "/api/teams/test-team/virtual_machines/", mockVM)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
 
 	flags := map[string]string{
@@ -206,6 +223,7 @@ func TestVMUpdateCommand_Success(t *testing.T) {
 
 func TestVMDeleteCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/vm-1/", http.MethodDelete, 200, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
@@ -339,6 +357,144 @@ func TestVMRebootCommand_Success(t *testing.T) {
 	assert.Contains(t, output, "VM reboot command sent")
 }
 
+func TestVMStartCommand_Wait(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	var statePolls int
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/api/teams/test-team/virtual_machines/vm-1/start/":
+			return test.NewJSONResponse(t, http.StatusOK, nil), nil
+		case req.Method == http.MethodGet && req.URL.Path == "/api/teams/test-team/virtual_machines/vm-1/state/":
+			statePolls++
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStateRunning}), nil
+		}
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return test.NewJSONResponse(t, http.StatusNotFound, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team": "test-team",
+		"vm":   "vm-1",
+		"wait": "true",
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "start", flags)
+	assert.NoError(t, err)
+
+	output := executeCommand(t, cmd)
+	assert.Contains(t, output, "VM start command sent")
+	assert.Equal(t, 1, statePolls)
+}
+
+func TestVMRebootCommand_WaitSeesTheReboot(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	var statePolls int
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet {
+			statePolls++
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStateRunning}), nil
+		}
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":         "test-team",
+		"vm":           "vm-1",
+		"wait":         "true",
+		"wait-timeout": "10ms",
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "reboot", flags)
+	assert.NoError(t, err)
+
+	// A VM that stays running has not rebooted yet
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, client.ErrWaitTimeout)
+	assert.Equal(t, 1, statePolls)
+}
+
+func TestVMRebootCommand_WaitMissedTheReboot(t *testing.T) {
+	app, _ := setupTestApp(t)
+	restoreInterval, restoreGrace := rebootPollInterval, rebootGrace
+	t.Cleanup(func() { rebootPollInterval, rebootGrace = restoreInterval, restoreGrace })
+	rebootPollInterval, rebootGrace = time.Millisecond, 20*time.Millisecond
+
+	var statePolls int
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet {
+			statePolls++
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStateRunning}), nil
+		}
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":         "test-team",
+		"vm":           "vm-1",
+		"wait":         "true",
+		"wait-timeout": "1m",
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "reboot", flags)
+	assert.NoError(t, err)
+
+	// A reboot over between two polls is taken as done after the grace period,
+	// polling at a fixed interval until then
+	err = cmd.Action(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Greater(t, statePolls, 5)
+}
+
+func TestVMStopCommand_WaitTimeout(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet {
+			return test.NewJSONResponse(t, http.StatusOK, client.VirtualMachineState{State: client.VMStateRunning}), nil
+		}
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":         "test-team",
+		"vm":           "vm-1",
+		"wait":         "true",
+		"wait-timeout": "10ms",
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "stop", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.ErrorIs(t, err, client.ErrWaitTimeout)
+	assert.Contains(t, err.Error(), "virtual machine vm-1 is running")
+}
+
+func TestVMStartCommand_InvalidWaitTimeout(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	mockClient := test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("the VM was changed before the timeout was checked: %s %s", req.Method, req.URL.Path)
+		return test.NewJSONResponse(t, http.StatusOK, nil), nil
+	})
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	flags := map[string]string{
+		"team":         "test-team",
+		"vm":           "vm-1",
+		"wait":         "true",
+		"wait-timeout": "soon",
+	}
+	cmd, err := getCommand(app, virtualMachineCommands, "start", flags)
+	assert.NoError(t, err)
+
+	err = cmd.Action(context.Background(), cmd)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid wait-timeout")
+}
+
 func TestVMHardResetCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
 
@@ -358,6 +514,7 @@ func TestVMHardResetCommand_Success(t *testing.T) {
 
 func TestVMRebuildCommand_Success(t *testing.T) {
 	app, _ := setupTestApp(t)
+	app.yes = true
 
 	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/vm-1/rebuild/", http.MethodPost, 200, nil)
 	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
@@ -373,3 +530,30 @@ func TestVMRebuildCommand_Success(t *testing.T) {
 	output := executeCommand(t, cmd)
 	assert.Contains(t, output, "VM rebuild command sent")
 }
+
+func TestVMLifecycle_Cassette(t *testing.T) {
+	app, cassette := newCassetteApp(t, "vm_lifecycle")
+	team := cassette.Value("HOTAISLE_TEST_TEAM", "test-team")
+
+	cmd, err := getCommand(app, virtualMachineCommands, "provision", map[string]string{
+		"team":      team,
+		"gpu-count": "1",
+		"gpu-model": "MI300X",
+	})
+	require.NoError(t, err)
+	var vm client.VirtualMachineDetails
+	require.NoError(t, json.Unmarshal([]byte(executeCommand(t, cmd)), &vm))
+	require.NotEmpty(t, vm.Name)
+	assert.Equal(t, uint64(1), vm.GPUs[0].Count)
+
+	cmd, err = getCommand(app, virtualMachineCommands, "state", map[string]string{"team": team, "vm": vm.Name})
+	require.NoError(t, err)
+	var state client.VirtualMachineState
+	require.NoError(t, json.Unmarshal([]byte(executeCommand(t, cmd)), &state))
+	assert.NotEmpty(t, state.State)
+
+	app.yes = true
+	cmd, err = getCommand(app, virtualMachineCommands, "delete", map[string]string{"team": team, "vm": vm.Name})
+	require.NoError(t, err)
+	assert.Contains(t, executeCommand(t, cmd), "VM deleted successfully")
+}
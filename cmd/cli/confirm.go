@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"hotaisle-cli/internal/config"
+
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// errNotTerminal is returned when a confirmation is needed but nobody could answer it
+var errNotTerminal = errors.New("stdin is not a terminal, pass --yes to confirm")
+
+// stdinIsTerminal reports whether confirmations can be asked, replaced in tests
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// destructiveKey is the metadata key holding the Destructive flag of a command
+const destructiveKey = "destructive"
+
+// isDestructive reports whether a command was built from a Destructive commandDef
+func isDestructive(cmd *cli.Command) bool {
+	_, ok := cmd.Metadata[destructiveKey]
+	return ok
+}
+
+// ask writes a prompt to stderr and reads a line from stdin. It fails when
+// stdin is not a terminal, since nobody could answer.
+func ask(prompt string) (string, error) {
+	if !stdinIsTerminal() {
+		return "", errNotTerminal
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// askPassphrase returns the passphrase of the encrypted credentials file from
+// HOTAISLE_PASSPHRASE, or asks for it without echo when stdin is a terminal
+func askPassphrase() (string, error) {
+	if os.Getenv("HOTAISLE_PASSPHRASE") != "" || !stdinIsTerminal() {
+		return config.PassphraseFromEnv()
+	}
+	return askSecret("Passphrase for the encrypted credentials file: ")
+}
+
+// askSecret writes a prompt to stderr and reads a line from the terminal without echo
+func askSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// skipConfirmation reports whether confirmations are answered already, by
+// --yes, or are not needed since --dry-run changes nothing
+func (app *App) skipConfirmation() bool {
+	return app.yes || app.dryRun
+}
+
+// confirm asks a yes/no question, unless --yes was given
+func confirm(app *App, question string) (bool, error) {
+	if app.skipConfirmation() {
+		return true, nil
+	}
+	answer, err := ask(question + " [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// confirmName asks to type the name of the resource a destructive command
+// acts on, unless --yes was given
+func confirmName(app *App, cmd *cli.Command, name string) error {
+	if app.skipConfirmation() {
+		return nil
+	}
+	printErrorf("%s on %s cannot be undone.\n", cmd.FullName(), name)
+	answer, err := ask(fmt.Sprintf("Type %s to confirm: ", name))
+	if err != nil {
+		return err
+	}
+	if answer != name {
+		return fmt.Errorf("%s canceled", cmd.Name)
+	}
+	return nil
+}
+
+// confirmCount asks to type the number of resources a destructive bulk
+// command acts on, unless --yes was given
+func confirmCount(app *App, cmd *cli.Command, kind string, names []string) error {
+	return askCount(app, cmd, fmt.Sprintf("%s on %d %ss cannot be undone: %s\n", cmd.FullName(), len(names), kind, strings.Join(names, ", ")), len(names))
+}
+
+// confirmTargets asks to type the number of resources a bulk command changes,
+// unless --yes was given
+func confirmTargets(app *App, cmd *cli.Command, kind string, names []string) error {
+	return askCount(app, cmd, fmt.Sprintf("%s will run on %d %ss: %s\n", cmd.FullName(), len(names), kind, strings.Join(names, ", ")), len(names))
+}
+
+// askCount prints a warning and asks to type count, unless --yes was given
+func askCount(app *App, cmd *cli.Command, warning string, count int) error {
+	if app.skipConfirmation() {
+		return nil
+	}
+	printErrorf("%s", warning)
+	answer, err := ask(fmt.Sprintf("Type %d to confirm: ", count))
+	if err != nil {
+		return err
+	}
+	if answer != strconv.Itoa(count) {
+		return fmt.Errorf("%s canceled", cmd.Name)
+	}
+	return nil
+}
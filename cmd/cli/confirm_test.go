@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withStdin replaces stdin with a pipe holding input
+func withStdin(t *testing.T, input string) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString(input)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		_ = r.Close()
+	})
+}
+
+// withTerminal makes confirmations read answers from input, as if typed in a terminal
+func withTerminal(t *testing.T, input string) {
+	withStdin(t, input)
+	isTerminal := stdinIsTerminal
+	stdinIsTerminal = func() bool { return true }
+	t.Cleanup(func() { stdinIsTerminal = isTerminal })
+}
+
+// rebuildVM runs `vm rebuild` on vm-1, returning stderr and whether the API was called
+func rebuildVM(t *testing.T, app *App) (string, bool, error) {
+	called := false
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(test.NewMockHTTPClient(func(req *http.Request) (*http.Response, error) {
+		called = true
+		assert.Equal(t, "/api/teams/ml/virtual_machines/vm-1/rebuild/", req.URL.Path)
+		return test.NewEmptyResponse(http.StatusOK), nil
+	})))
+	cmd, err := getCommand(app, virtualMachineCommands, "rebuild", map[string]string{"team": "ml", "vm": "vm-1"})
+	require.NoError(t, err)
+
+	var runErr error
+	stderr := test.CaptureStderr(t, func() error {
+		test.CaptureStdout(t, func() error {
+			runErr = cmd.Action(context.Background(), cmd)
+			return nil
+		})
+		return nil
+	})
+	return stderr, called, runErr
+}
+
+func TestDestructiveCommand_TypedName(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	withTerminal(t, "vm-2\n")
+	stderr, called, err := rebuildVM(t, app)
+	assert.EqualError(t, err, "rebuild canceled")
+	assert.False(t, called)
+	assert.Contains(t, stderr, "rebuild on vm-1 cannot be undone.\nType vm-1 to confirm: ")
+
+	withTerminal(t, "vm-1\n")
+	_, called, err = rebuildVM(t, app)
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestDestructiveCommand_NotTerminal(t *testing.T) {
+	app, _ := setupTestApp(t)
+
+	_, called, err := rebuildVM(t, app)
+	assert.ErrorIs(t, err, errNotTerminal)
+	assert.False(t, called)
+
+	app.yes = true
+	_, called, err = rebuildVM(t, app)
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestDestructiveCommand_GlobalYesFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	app, err := makeApp()
+	require.NoError(t, err)
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(
+		test.NewMockHTTPClientWithAssertions(t, "/api/user/api_keys/abc/", http.MethodDelete, http.StatusNoContent, nil)))
+
+	test.CaptureStdout(t, func() error {
+		err = app.AppCli.Run(context.Background(), []string{"hotaisle", "user", "api-keys", "delete", "--prefix", "abc", "--yes"})
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestDestructiveCommands(t *testing.T) {
+	app, _ := setupTestApp(t)
+	tests := []struct {
+		def  commandDef
+		path string
+		flag string
+	}{
+		{virtualMachineCommands, "vm.delete", "vm"},
+		{virtualMachineCommands, "vm.rebuild", "vm"},
+		{bareMetalCommands, "bm.delete", "server"},
+		{bareMetalCommands, "bm.reinstall", "server"},
+		{bareMetalCommands, "bm.power.ac-reset", "server"},
+		{teamCommands, "team.members.remove", "email"},
+		{userCommands, "user.api-keys.delete", "prefix"},
+	}
+	for _, tt := range tests {
+		cmdDef := tt.def.findCommand(tt.path)
+		require.NotNil(t, cmdDef, tt.path)
+		assert.Equal(t, tt.flag, cmdDef.Destructive, tt.path)
+		assert.True(t, isDestructive(buildCommand(app, *cmdDef)), tt.path)
+	}
+	assert.False(t, isDestructive(buildCommand(app, *virtualMachineCommands.findCommand("vm.stop"))))
+}
+
+func TestBulkDelete_TypedCount(t *testing.T) {
+	env := newBulkTestEnv(t)
+
+	withTerminal(t, "3\n")
+	var err error
+	stderr := test.CaptureStderr(t, func() error {
+		_, err = env.run(t, virtualMachineCommands, "delete", "--description-match", "trainer|web")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "delete on 3 VMs cannot be undone: "+env.vms[0]+", "+env.vms[1]+", "+env.vms[2]+"\nType 3 to confirm: ")
+
+	vms, err := env.app.Client.Api.VirtualMachines().List(context.Background(), "ml")
+	require.NoError(t, err)
+	assert.Empty(t, vms)
+}
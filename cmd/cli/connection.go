@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+
+	"github.com/urfave/cli/v3"
+)
+
+// connectionSettings are the settings of how the API is reached. The global
+// flags override the ones of the profile.
+type connectionSettings struct {
+	BaseURL        string
+	RequestTimeout string
+	HTTPSProxy     string
+	CABundle       string
+	ClientCert     string
+	ClientKey      string
+}
+
+// connectionSettings returns the connection settings of the profile in use,
+// overridden by the global flags
+func (app *App) connectionSettings() connectionSettings {
+	s := connectionSettings{
+		BaseURL:        app.Config.BaseURL,
+		RequestTimeout: app.Config.RequestTimeout,
+		HTTPSProxy:     app.Config.HTTPSProxy,
+		CABundle:       app.Config.CABundle,
+		ClientCert:     app.Config.ClientCert,
+		ClientKey:      app.Config.ClientKey,
+	}
+	override := func(setting *string, flag string) {
+		if flag != "" {
+			*setting = flag
+		}
+	}
+	override(&s.BaseURL, app.connection.BaseURL)
+	override(&s.RequestTimeout, app.connection.RequestTimeout)
+	override(&s.HTTPSProxy, app.connection.HTTPSProxy)
+	override(&s.CABundle, app.connection.CABundle)
+	override(&s.ClientCert, app.connection.ClientCert)
+	override(&s.ClientKey, app.connection.ClientKey)
+	return s
+}
+
+// clientOptions checks the settings and returns the client options of all but
+// the base URL, which newAPIClient sets
+func (s connectionSettings) clientOptions() ([]client.Option, error) {
+	if s.BaseURL != "" {
+		if _, err := checkBaseURL(s.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var opts []client.Option
+	if s.RequestTimeout != "" {
+		timeout, err := parseRequestTimeout(s.RequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTimeout(timeout))
+	}
+	if s.HTTPSProxy != "" {
+		if _, err := checkProxy(s.HTTPSProxy); err != nil {
+			return nil, err
+		}
+		proxyURL, _ := url.Parse(s.HTTPSProxy)
+		opts = append(opts, client.WithProxy(proxyURL))
+	}
+	if s.CABundle != "" || s.ClientCert != "" || s.ClientKey != "" {
+		tlsConfig, err := client.LoadTLSConfig(s.CABundle, s.ClientCert, s.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTLSConfig(tlsConfig))
+	}
+	return opts, nil
+}
+
+// setConnectionSetting sets a connection setting of the profile in use to the
+// first argument, as returned by check. An empty argument removes the setting.
+func setConnectionSetting(app *App, cmd *cli.Command, name string, setting *string, check func(string) (string, error)) error {
+	if cmd.Args().Len() == 0 {
+		return fmt.Errorf("missing %s", name)
+	}
+	value := strings.TrimSpace(cmd.Args().First())
+	if value != "" {
+		var err error
+		if value, err = check(value); err != nil {
+			return err
+		}
+	}
+	*setting = value
+	if err := config.Save(app.Config); err != nil {
+		return err
+	}
+	slog.Info("Config set", name, value)
+	return nil
+}
+
+// parseRequestTimeout parses a request timeout such as 90s or 5m
+func parseRequestTimeout(s string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(s)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid request timeout %q, must be a positive duration such as 90s", s)
+	}
+	return timeout, nil
+}
+
+func checkRequestTimeout(s string) (string, error) {
+	_, err := parseRequestTimeout(s)
+	return s, err
+}
+
+func checkBaseURL(s string) (string, error) {
+	if err := checkURL(s, "http", "https"); err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", s, err)
+	}
+	return strings.TrimSuffix(s, "/"), nil
+}
+
+func checkProxy(s string) (string, error) {
+	if err := checkURL(s, "http", "https", "socks5"); err != nil {
+		return "", fmt.Errorf("invalid proxy URL %q: %w", s, err)
+	}
+	return s, nil
+}
+
+// checkFile returns the absolute path of an existing file, so that the
+// setting still holds it when run from another directory
+func checkFile(s string) (string, error) {
+	path, err := filepath.Abs(s)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// checkURL checks that s is an absolute URL with one of the schemes
+func checkURL(s string, schemes ...string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("the scheme must be %s", strings.Join(schemes, ", "))
+	}
+	return nil
+}
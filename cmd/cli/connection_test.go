@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppConnectionSettings(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Config.BaseURL = "https://staging.example.com/api"
+	app.Config.RequestTimeout = "90s"
+
+	s := app.connectionSettings()
+	assert.Equal(t, "https://staging.example.com/api", s.BaseURL)
+	assert.Equal(t, "90s", s.RequestTimeout)
+
+	app.connection.BaseURL = "http://127.0.0.1:8080/api"
+	app.connection.HTTPSProxy = "http://proxy.example.com:3128"
+	s = app.connectionSettings()
+	assert.Equal(t, "http://127.0.0.1:8080/api", s.BaseURL, "the flag overrides the profile")
+	assert.Equal(t, "90s", s.RequestTimeout)
+	assert.Equal(t, "http://proxy.example.com:3128", s.HTTPSProxy)
+
+	opts, err := s.clientOptions()
+	require.NoError(t, err)
+	assert.Len(t, opts, 2, "the timeout and the proxy, the base URL is set by newAPIClient")
+}
+
+func TestConnectionSettingsClientOptionsErrors(t *testing.T) {
+	cert := filepath.Join(t.TempDir(), "client.crt")
+	require.NoError(t, os.WriteFile(cert, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name     string
+		settings connectionSettings
+		error    string
+	}{
+		{name: "base URL without scheme", settings: connectionSettings{BaseURL: "staging.example.com/api"}, error: `invalid base URL "staging.example.com/api"`},
+		{name: "base URL of another scheme", settings: connectionSettings{BaseURL: "ftp://staging.example.com"}, error: "the scheme must be http, https"},
+		{name: "timeout without unit", settings: connectionSettings{RequestTimeout: "90"}, error: `invalid request timeout "90"`},
+		{name: "negative timeout", settings: connectionSettings{RequestTimeout: "-1s"}, error: "must be a positive duration"},
+		{name: "proxy without host", settings: connectionSettings{HTTPSProxy: "http://"}, error: "missing host"},
+		{name: "missing CA bundle", settings: connectionSettings{CABundle: "/does/not/exist.pem"}, error: "failed to read CA bundle"},
+		{name: "certificate without key", settings: connectionSettings{ClientCert: cert}, error: "must be given together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.settings.clientOptions()
+			assert.ErrorContains(t, err, tt.error)
+		})
+	}
+}
+
+func TestConfigSetConnectionSettings(t *testing.T) {
+	app, tmpDir := setupTestApp(t)
+	bundle := filepath.Join(tmpDir, "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("bundle"), 0o600))
+	t.Chdir(tmpDir)
+
+	require.NoError(t, runConfigCommand(t, app, "set", "base-url", "https://staging.example.com/api/"))
+	assert.Equal(t, "https://staging.example.com/api", app.Config.BaseURL)
+	require.NoError(t, runConfigCommand(t, app, "set", "request-timeout", "5m"))
+	assert.Equal(t, "5m", app.Config.RequestTimeout)
+	require.NoError(t, runConfigCommand(t, app, "set", "ca-bundle", "ca.pem"))
+	assert.Equal(t, bundle, app.Config.CABundle, "files are saved with their absolute path")
+
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "https-proxy", "proxy:3128"), "invalid proxy URL")
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "request-timeout", "soon"), "invalid request timeout")
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "client-cert", "missing.crt"), "no such file")
+	assert.ErrorContains(t, runConfigCommand(t, app, "set", "base-url"), "missing base-url")
+
+	// An empty value removes the setting
+	require.NoError(t, runConfigCommand(t, app, "set", "base-url", ""))
+	assert.Empty(t, app.Config.BaseURL)
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// defaultConsoleEscape is the default of the --escape flag, the same key virsh uses
+const defaultConsoleEscape = "^]"
+
+// noConsoleEscape disables detaching with an escape key
+const noConsoleEscape = -1
+
+// parseEscapeKey parses the --escape flag. It accepts a caret sequence such as
+// "^]", a single character, or "none".
+func parseEscapeKey(s string) (int, error) {
+	switch {
+	case s == "none":
+		return noConsoleEscape, nil
+	case len(s) == 2 && s[0] == '^' && (s[1] >= '@' && s[1] <= '_' || s[1] >= 'a' && s[1] <= 'z'):
+		return int(s[1] & 0x1f), nil
+	case len(s) == 1:
+		return int(s[0]), nil
+	}
+	return 0, fmt.Errorf("invalid escape key %q, use a caret sequence like ^], a single character or none", s)
+}
+
+// runConsole attaches the local terminal to a console. The terminal is put in
+// raw mode so control keys such as Ctrl-C reach the VM; typing the escape key
+// detaches.
+func runConsole(ctx context.Context, console io.ReadWriteCloser, vmName, escapeName string, escape int) error {
+	defer func() {
+		_ = console.Close()
+	}()
+
+	// Ctrl-C only raises SIGINT when stdin is not a raw terminal
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stdin := int(os.Stdin.Fd())
+	if term.IsTerminal(stdin) {
+		state, err := term.MakeRaw(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to put terminal in raw mode: %w", err)
+		}
+		defer func() {
+			_ = term.Restore(stdin, state)
+		}()
+
+		stopResize := notifyResize(func() {
+			if cols, rows, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				// A serial console cannot be told about the new size, so tell the user how to apply it
+				fmt.Fprintf(os.Stderr, "\r\n[terminal resized, run `stty cols %d rows %d` in the VM to match]\r\n", cols, rows)
+			}
+		})
+		defer stopResize()
+	}
+
+	if escape == noConsoleEscape {
+		fmt.Fprintf(os.Stderr, "Connected to %s console.\r\n", vmName)
+	} else {
+		fmt.Fprintf(os.Stderr, "Connected to %s console. Type %s to detach.\r\n", vmName, escapeName)
+	}
+	err := attachConsole(ctx, console, os.Stdin, os.Stdout, escape)
+	fmt.Fprint(os.Stderr, "\r\nDisconnected.\r\n")
+	return err
+}
+
+// attachConsole copies console output to out and in to the console until the
+// console closes, the escape byte is read from in, or ctx is done. Reaching
+// the end of in does not detach, so piped input can be followed by output.
+func attachConsole(ctx context.Context, console io.ReadWriter, in io.Reader, out io.Writer, escape int) error {
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(out, console)
+		errc <- err
+	}()
+	go func() {
+		detached, err := copyConsoleInput(console, in, escape)
+		if detached || err != nil {
+			errc <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil
+		}
+		return ctx.Err()
+	case err := <-errc:
+		return err
+	}
+}
+
+// copyConsoleInput copies in to the console, stopping before the escape byte
+func copyConsoleInput(console io.Writer, in io.Reader, escape int) (detached bool, err error) {
+	buf := make([]byte, 1024)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			for i, b := range chunk {
+				if int(b) == escape {
+					if i > 0 {
+						if _, err := console.Write(chunk[:i]); err != nil {
+							return false, err
+						}
+					}
+					return true, nil
+				}
+			}
+			if _, err := console.Write(chunk); err != nil {
+				return false, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsole reads console output from a reader and records the input written to it
+type fakeConsole struct {
+	io.Reader
+	input bytes.Buffer
+}
+
+func (c *fakeConsole) Write(p []byte) (int, error) {
+	return c.input.Write(p)
+}
+
+func TestParseEscapeKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    int
+		wantErr bool
+	}{
+		{key: "^]", want: 0x1d},
+		{key: "^c", want: 0x03},
+		{key: "^C", want: 0x03},
+		{key: "~", want: '~'},
+		{key: "none", want: noConsoleEscape},
+		{key: "^", want: '^'},
+		{key: "^1", wantErr: true},
+		{key: "ctrl-]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := parseEscapeKey(tt.key)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAttachConsoleCopiesOutputUntilClosed(t *testing.T) {
+	console := &fakeConsole{Reader: strings.NewReader("Ubuntu 24.04 LTS vm-1 ttyS0\r\n")}
+	var out bytes.Buffer
+
+	// Input reaching EOF must not detach before the output is copied
+	err := attachConsole(context.Background(), console, strings.NewReader("root\r"), &out, 0x1d)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Ubuntu 24.04 LTS vm-1 ttyS0\r\n", out.String())
+}
+
+func TestAttachConsoleDetachesOnEscape(t *testing.T) {
+	output, _ := io.Pipe()
+	console := &fakeConsole{Reader: output}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- attachConsole(context.Background(), console, strings.NewReader("ls\r\x1dreboot\r"), io.Discard, 0x1d)
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("attachConsole did not detach on the escape key")
+	}
+	assert.Equal(t, "ls\r", console.input.String())
+}
+
+func TestAttachConsoleStopsOnCancel(t *testing.T) {
+	output, _ := io.Pipe()
+	input, _ := io.Pipe()
+	console := &fakeConsole{Reader: output}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := attachConsole(ctx, console, input, io.Discard, noConsoleEscape)
+	assert.NoError(t, err)
+}
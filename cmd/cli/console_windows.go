@@ -0,0 +1,8 @@
+//go:build windows
+
+package cli
+
+// notifyResize is a no-op on Windows, which has no SIGWINCH
+func notifyResize(fn func()) (stop func()) {
+	return func() {}
+}
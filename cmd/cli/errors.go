@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"hotaisle-cli/client"
+)
+
+// Process exit codes set by Run. Usage errors, such as an unknown flag, exit
+// with exitFailure, and exit code 2 is not used.
+const (
+	exitFailure       = 1
+	exitUnauthorized  = 3
+	exitForbidden     = 4
+	exitNotFound      = 5
+	exitConflict      = 6
+	exitUnprocessable = 7
+	exitRateLimited   = 8
+	exitBalanceAlert  = 9
+)
+
+// exitCodes maps errors to process exit codes
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{client.ErrUnauthorized, exitUnauthorized},
+	{client.ErrForbidden, exitForbidden},
+	{client.ErrNotFound, exitNotFound},
+	{client.ErrConflict, exitConflict},
+	{client.ErrUnprocessable, exitUnprocessable},
+	{client.ErrRateLimited, exitRateLimited},
+	{errBalanceAlert, exitBalanceAlert},
+}
+
+// exitCode returns the process exit code for an error. Failures of programs
+// run by the CLI, like ssh, keep their exit code.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return exitFailure
+}
+
+// errorMessage formats an error for the terminal, replacing the raw API error
+// with the server message and the failed request
+func errorMessage(err error) string {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	message := strings.TrimSpace(apiErr.Message)
+	// The API sends some errors as a JSON string
+	var unquoted string
+	if json.Unmarshal([]byte(message), &unquoted) == nil {
+		message = unquoted
+	}
+	if message == "" {
+		message = http.StatusText(apiErr.StatusCode)
+	}
+	friendly := fmt.Sprintf("%s (HTTP %d)", message, apiErr.StatusCode)
+	if apiErr.Method != "" {
+		friendly = fmt.Sprintf("%s (HTTP %d on %s %s)", message, apiErr.StatusCode, apiErr.Method, apiErr.Path)
+	}
+	return strings.Replace(err.Error(), apiErr.Error(), friendly, 1)
+}
+
+// errorHint suggests how to fix an error, or returns "" if there is nothing to suggest
+func errorHint(err error) string {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	switch {
+	case errors.Is(err, client.ErrUnauthorized):
+		return "The API token is missing or invalid. Log in with: hotaisle login, or set a token with: HOTAISLE_API_TOKEN=<token> hotaisle config set token"
+	case errors.Is(err, client.ErrForbidden):
+		if permission := client.RequiredPermission(apiErr.Method, apiErr.Path); permission != "" {
+			return fmt.Sprintf("This request requires %s. Ask a team owner to grant it to your API key.", permission)
+		}
+		return "Your API key is not allowed to make this request."
+	case errors.Is(err, client.ErrNotFound):
+		return "Check the resource name and that it belongs to the given team."
+	case errors.Is(err, client.ErrRateLimited):
+		return "Too many requests. Wait a moment, or retry automatically with --retries."
+	}
+	return ""
+}
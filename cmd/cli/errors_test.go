@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"testing"
+
+	"hotaisle-cli/client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	// ssh and scp exit with the status of the remote command
+	sshErr := exec.Command("sh", "-c", "exit 42").Run()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "plain error", err: errors.New("boom"), want: exitFailure},
+		{name: "server error", err: &client.APIError{StatusCode: http.StatusInternalServerError}, want: exitFailure},
+		{name: "unauthorized", err: &client.APIError{StatusCode: http.StatusUnauthorized}, want: exitUnauthorized},
+		{name: "forbidden", err: &client.APIError{StatusCode: http.StatusForbidden}, want: exitForbidden},
+		{name: "wrapped not found", err: fmt.Errorf("waiting for VM vm-1: %w", &client.APIError{StatusCode: http.StatusNotFound}), want: exitNotFound},
+		{name: "conflict", err: &client.APIError{StatusCode: http.StatusConflict}, want: exitConflict},
+		{name: "unprocessable", err: &client.APIError{StatusCode: http.StatusUnprocessableEntity}, want: exitUnprocessable},
+		{name: "rate limited", err: &client.APIError{StatusCode: http.StatusTooManyRequests}, want: exitRateLimited},
+		{name: "balance alert", err: fmt.Errorf("%w: balance runs out in 2h0m0s, within 24h0m0s", errBalanceAlert), want: exitBalanceAlert},
+		{name: "external command", err: fmt.Errorf("ssh: %w", sshErr), want: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	apiErr := &client.APIError{
+		StatusCode: http.StatusForbidden,
+		Message:    `"Forbidden: Permission denied"`,
+		Method:     http.MethodPost,
+		Path:       "/teams/my-team/virtual_machines/vm-1/start/",
+	}
+
+	assert.Equal(t, "Forbidden: Permission denied (HTTP 403 on POST /teams/my-team/virtual_machines/vm-1/start/)", errorMessage(apiErr))
+	assert.Equal(t, "waiting for VM vm-1: Forbidden: Permission denied (HTTP 403 on POST /teams/my-team/virtual_machines/vm-1/start/)",
+		errorMessage(fmt.Errorf("waiting for VM vm-1: %w", apiErr)))
+	assert.Equal(t, "Not Found (HTTP 404)", errorMessage(&client.APIError{StatusCode: http.StatusNotFound}))
+	assert.Equal(t, "boom", errorMessage(errors.New("boom")))
+}
+
+func TestErrorHint(t *testing.T) {
+	unauthorized := &client.APIError{StatusCode: http.StatusUnauthorized, Method: http.MethodGet, Path: "/user/"}
+	assert.Contains(t, errorHint(unauthorized), "Log in with: hotaisle login, or set a token with: HOTAISLE_API_TOKEN=<token> hotaisle config set token")
+
+	forbidden := &client.APIError{StatusCode: http.StatusForbidden, Method: http.MethodPost, Path: "/teams/my-team/virtual_machines/vm-1/start/"}
+	assert.Contains(t, errorHint(forbidden), "team role: operator")
+
+	unknownEndpoint := &client.APIError{StatusCode: http.StatusForbidden, Method: http.MethodGet, Path: "/unknown/"}
+	assert.Equal(t, "Your API key is not allowed to make this request.", errorHint(unknownEndpoint))
+
+	assert.Empty(t, errorHint(&client.APIError{StatusCode: http.StatusInternalServerError}))
+	assert.Empty(t, errorHint(errors.New("boom")))
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// installBarWidth is the number of bar characters drawn per install stage
+const installBarWidth = 3
+
+// stageTiming is the time an install spent in a stage, as observed by the CLI
+type stageTiming struct {
+	Stage    string
+	Duration time.Duration
+}
+
+// installProgress renders the progress of a bare metal OS install, records the
+// time spent in each stage and warns when LastImagingUpdate stops moving
+type installProgress struct {
+	w          io.Writer
+	now        func() time.Time
+	stallAfter time.Duration
+
+	stage      string
+	stageStart time.Time
+	timings    []stageTiming
+
+	// lastUpdate is the LastImagingUpdate last reported and lastUpdateSeen is
+	// when the CLI saw it change
+	lastUpdate     time.Time
+	lastUpdateSeen time.Time
+	stalled        bool
+}
+
+func newInstallProgress(w io.Writer, stallAfter time.Duration) *installProgress {
+	return &installProgress{w: w, now: time.Now, stallAfter: stallAfter}
+}
+
+// update records a polled install status, printing a progress line whenever
+// the stage changes
+func (p *installProgress) update(status *client.BareMetalServerlOSStatus) {
+	if status == nil {
+		return
+	}
+	now := p.now()
+
+	if status.OSStatus != p.stage {
+		previous := p.stage
+		if previous != "" {
+			p.timings = append(p.timings, stageTiming{Stage: previous, Duration: now.Sub(p.stageStart)})
+		}
+		p.stage = status.OSStatus
+		p.stageStart = now
+
+		line := p.bar() + " " + p.stage
+		if previous != "" {
+			line += fmt.Sprintf(" (%s took %s)", previous, p.timings[len(p.timings)-1].Duration.Round(time.Second))
+		}
+		fmt.Fprintln(p.w, line)
+	}
+
+	if !status.LastImagingUpdate.Equal(p.lastUpdate) || p.lastUpdateSeen.IsZero() {
+		if p.stalled {
+			slog.Info("Install progressing again", "stage", p.stage)
+		}
+		p.lastUpdate = status.LastImagingUpdate
+		p.lastUpdateSeen = now
+		p.stalled = false
+		return
+	}
+	if p.stallAfter > 0 && !p.stalled && now.Sub(p.lastUpdateSeen) >= p.stallAfter {
+		p.stalled = true
+		slog.Warn("Install appears stalled", "stage", p.stage,
+			"last-update", formatTime(p.lastUpdate), "no-progress-for", now.Sub(p.lastUpdateSeen).Round(time.Second))
+	}
+}
+
+// lastStage returns the last stage the install was seen in before it failed or finished
+func (p *installProgress) lastStage() string {
+	if p.stage == client.OSStatusFailed && len(p.timings) > 0 {
+		return p.timings[len(p.timings)-1].Stage
+	}
+	return p.stage
+}
+
+// bar draws the stages completed so far, e.g. "[######---] 3/9"
+func (p *installProgress) bar() string {
+	total := len(client.OSInstallStages)
+	index := client.OSInstallStageIndex(p.lastStage())
+	if index < 0 {
+		return fmt.Sprintf("[%s] ?/%d", strings.Repeat("-", total*installBarWidth), total)
+	}
+	done := index + 1
+	return fmt.Sprintf("[%s%s] %d/%d",
+		strings.Repeat("#", done*installBarWidth),
+		strings.Repeat("-", (total-done)*installBarWidth),
+		done, total)
+}
+
+// summary prints the time spent in each completed stage
+func (p *installProgress) summary() error {
+	if len(p.timings) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tDURATION")
+	var total time.Duration
+	for _, t := range p.timings {
+		total += t.Duration
+		fmt.Fprintf(tw, "%s\t%s\n", t.Stage, t.Duration.Round(time.Second))
+	}
+	fmt.Fprintf(tw, "total\t%s\n", total.Round(time.Second))
+	return tw.Flush()
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a clock for installProgress that advances by step on every call
+func fakeClock(start time.Time, step time.Duration) func() time.Time {
+	now := start.Add(-step)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestInstallProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newInstallProgress(&buf, 0)
+	p.now = fakeClock(time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC), time.Minute)
+
+	for _, stage := range []string{client.OSStatusShuttingDown, client.OSStatusShuttingDown, client.OSStatusACReset, client.OSStatusInstalled} {
+		p.update(&client.BareMetalServerlOSStatus{OSStatus: stage})
+	}
+	require.NoError(t, p.summary())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "[###------------------------] 1/9 shutting_down", lines[0])
+	assert.Equal(t, "[######---------------------] 2/9 ac_reset (shutting_down took 2m0s)", lines[1])
+	assert.Equal(t, "[###########################] 9/9 installed (ac_reset took 1m0s)", lines[2])
+	assert.Equal(t, []string{"shutting_down", "2m0s"}, strings.Fields(lines[4]))
+	assert.Equal(t, []string{"total", "3m0s"}, strings.Fields(lines[6]))
+}
+
+func TestInstallProgressFailed(t *testing.T) {
+	var buf bytes.Buffer
+	p := newInstallProgress(&buf, 0)
+	p.now = fakeClock(time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC), time.Minute)
+
+	p.update(&client.BareMetalServerlOSStatus{OSStatus: client.OSStatusBIOSReset})
+	p.update(&client.BareMetalServerlOSStatus{OSStatus: client.OSStatusFailed})
+
+	assert.Equal(t, client.OSStatusBIOSReset, p.lastStage())
+	assert.Contains(t, buf.String(), "3/9 failed (bios_reset took 1m0s)")
+}
+
+func TestInstallProgressStall(t *testing.T) {
+	p := newInstallProgress(&bytes.Buffer{}, 10*time.Minute)
+	p.now = fakeClock(time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC), 5*time.Minute)
+
+	update := time.Date(2025, 5, 20, 9, 59, 0, 0, time.UTC)
+	status := &client.BareMetalServerlOSStatus{OSStatus: client.OSStatusInstallingOS, LastImagingUpdate: update}
+
+	p.update(status)
+	p.update(status)
+	assert.False(t, p.stalled, "5 minutes without progress is below the stall timeout")
+	p.update(status)
+	assert.True(t, p.stalled, "10 minutes without progress should be flagged")
+
+	status.LastImagingUpdate = update.Add(15 * time.Minute)
+	p.update(status)
+	assert.False(t, p.stalled, "a new imaging update clears the stall")
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment is one step of a parsed jsonpath expression
+type jsonPathSegment struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// evalJSONPathTemplate evaluates a kubectl-style jsonpath template against data.
+// Expressions are wrapped in braces and may be mixed with literal text, e.g.
+// "{.name} is {.state}". A template without braces is treated as a single expression.
+//
+// Supported syntax is a subset of jsonpath: fields (.name or ['name']),
+// array indexes ([0], [-1]) and wildcards ([*] or .*).
+func evalJSONPathTemplate(tmpl string, data any) (string, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+
+	var out strings.Builder
+	rest := tmpl
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			out.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("invalid jsonpath %q: unclosed '{'", tmpl)
+		}
+		out.WriteString(rest[:start])
+
+		expr := rest[start+1 : start+end]
+		results, err := evalJSONPath(expr, data)
+		if err != nil {
+			return "", err
+		}
+		values := make([]string, len(results))
+		for i, r := range results {
+			values[i] = formatJSONPathValue(r)
+		}
+		out.WriteString(strings.Join(values, " "))
+
+		rest = rest[start+end+1:]
+	}
+	return out.String(), nil
+}
+
+// evalJSONPath evaluates a single jsonpath expression and returns every match
+func evalJSONPath(expr string, data any) ([]any, error) {
+	segments, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []any{data}
+	for _, seg := range segments {
+		var next []any
+		for _, v := range current {
+			switch {
+			case seg.wildcard:
+				next = append(next, jsonPathChildren(v)...)
+			case seg.isIndex:
+				arr, ok := v.([]any)
+				if !ok {
+					continue
+				}
+				i := seg.index
+				if i < 0 {
+					i += len(arr)
+				}
+				if i >= 0 && i < len(arr) {
+					next = append(next, arr[i])
+				}
+			default:
+				if obj, ok := v.(map[string]any); ok {
+					if child, ok := obj[seg.field]; ok {
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// parseJSONPath splits an expression such as "$.teams[*].handle" into segments
+func parseJSONPath(expr string) ([]jsonPathSegment, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+
+	var segments []jsonPathSegment
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			if strings.HasPrefix(expr[i:], "..") {
+				return nil, fmt.Errorf("invalid jsonpath %q: recursive descent is not supported", expr)
+			}
+			i++
+		default:
+			end := strings.IndexAny(expr[i:], ".[")
+			if end < 0 {
+				end = len(expr) - i
+			}
+			name := expr[i : i+end]
+			i += end
+			if name == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else {
+				segments = append(segments, jsonPathSegment{field: name})
+			}
+		case '[':
+			end := strings.Index(expr[i:], "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed '['", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			i += end + 1
+
+			switch {
+			case inner == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, jsonPathSegment{field: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath %q: unsupported subscript [%s]", expr, inner)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+		}
+	}
+	return segments, nil
+}
+
+// jsonPathChildren returns the elements of an array or the values of an object in key order
+func jsonPathChildren(v any) []any {
+	switch t := v.(type) {
+	case []any:
+		return t
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		children := make([]any, len(keys))
+		for i, k := range keys {
+			children[i] = t[k]
+		}
+		return children
+	}
+	return nil
+}
+
+// formatJSONPathValue prints scalars as plain text and everything else as compact JSON
+func formatJSONPathValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+
+	"github.com/urfave/cli/v3"
+)
+
+var rotateAPIKeyFlags = []flagDef{
+	{Name: "prefix", Usage: "API key prefix identifier", Required: true},
+	{Name: "save", Usage: "Save the new key to the profile in use", Bool: true},
+	{Name: "token-file", Usage: "Write the new key to this file"},
+	{Name: "env-file", Usage: "Set the new key in this dotenv file, as the variable given by --env-var"},
+	{Name: "env-var", Usage: "Variable set by --env-file", Value: "HOTAISLE_API_TOKEN"},
+}
+
+// rollback undoes the steps of a rotation that are done, in reverse order
+type rollback []func() error
+
+func (r *rollback) add(undo func() error) {
+	*r = append(*r, undo)
+}
+
+// run undoes the steps, returning err with any step that could not be undone
+func (r rollback) run(err error) error {
+	var failed []error
+	for i := len(r) - 1; i >= 0; i-- {
+		if undoErr := r[i](); undoErr != nil {
+			failed = append(failed, undoErr)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w, and the rollback failed: %w", err, errors.Join(failed...))
+	}
+	return fmt.Errorf("%w, rolled back", err)
+}
+
+// rotateAPIKey replaces an API key with a new one of the same label, user role
+// and teams. Both keys work until the new one is verified and written to the
+// targets, then the old one is deleted. If a step fails, the steps before it
+// are undone and the old key is kept.
+func rotateAPIKey(app *App, ctx context.Context, cmd *cli.Command) error {
+	users := app.Client.Api.User()
+	prefix := cmd.String("prefix")
+	// Deleting the key in use without saving its replacement locks the CLI out
+	inUse := prefix != "" && (prefix == app.Config.LoginKey || strings.HasPrefix(app.Config.Token(), prefix))
+	if inUse && !cmd.Bool("save") {
+		return fmt.Errorf("API key %s is the one profile %s uses, pass --save to save the new key to it", prefix, app.Config.ProfileName())
+	}
+	old, err := users.GetAPIKey(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	req := client.UserAPIKeyRequest{Label: old.Label, UserRole: old.UserRole}
+	for _, t := range old.Teams {
+		req.Teams = append(req.Teams, client.UserAPIKeyTeamRoles{Team: t.Handle, Roles: t.Roles})
+	}
+	key, err := users.CreateAPIKey(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to create the new API key: %w", err)
+	}
+	if app.dryRun {
+		return nil
+	}
+
+	var undo rollback
+	undo.add(func() error {
+		if err := users.DeleteAPIKey(ctx, key.Prefix); err != nil {
+			return fmt.Errorf("delete the new API key %s: %w", key.Prefix, err)
+		}
+		return nil
+	})
+
+	verify := app.newAPIClient()
+	verify.Api.SetToken(key.Token)
+	if _, err := verify.Api.User().Get(ctx); err != nil {
+		return undo.run(fmt.Errorf("failed to verify the new API key %s: %w", key.Prefix, err))
+	}
+
+	targets := false
+	if path := cmd.String("token-file"); path != "" {
+		targets = true
+		if err := rewriteFile(&undo, path, func([]byte) []byte { return []byte(key.Token + "\n") }); err != nil {
+			return undo.run(err)
+		}
+	}
+	if path := cmd.String("env-file"); path != "" {
+		targets = true
+		name := cmd.String("env-var")
+		if err := rewriteFile(&undo, path, func(data []byte) []byte { return setEnvVar(data, name, key.Token) }); err != nil {
+			return undo.run(err)
+		}
+	}
+	if cmd.Bool("save") {
+		targets = true
+		if err := saveRotatedKey(app, &undo, old.Prefix, key); err != nil {
+			return undo.run(err)
+		}
+	}
+
+	if err := users.DeleteAPIKey(ctx, old.Prefix); err != nil {
+		return undo.run(fmt.Errorf("failed to delete the old API key %s: %w", old.Prefix, err))
+	}
+	if cmd.Bool("save") {
+		app.Client = app.newAPIClient()
+	}
+
+	// The token is not printed when it went somewhere safer
+	if targets {
+		return printOutput(app, key.UserAPIKey)
+	}
+	return printOutput(app, key)
+}
+
+// saveRotatedKey saves the new key to the profile in use
+func saveRotatedKey(app *App, undo *rollback, oldPrefix string, key *client.UserAPIKeyWithToken) error {
+	token, loginKey := app.Config.Token(), app.Config.LoginKey
+	app.Config.ApiToken = key.Token
+	if loginKey == oldPrefix {
+		app.Config.LoginKey = key.Prefix
+	}
+	if err := config.Save(app.Config); err != nil {
+		app.Config.ApiToken, app.Config.LoginKey = token, loginKey
+		return fmt.Errorf("failed to save the new API key: %w", err)
+	}
+	undo.add(func() error {
+		app.Config.ApiToken, app.Config.LoginKey = token, loginKey
+		if err := config.Save(app.Config); err != nil {
+			return fmt.Errorf("restore the API key of the profile: %w", err)
+		}
+		return nil
+	})
+	return nil
+}
+
+// rewriteFile replaces the content of a file with update of its content, and
+// adds restoring it to the rollback
+func rewriteFile(undo *rollback, path string, update func([]byte) []byte) error {
+	data, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, update(data), 0o600); err != nil {
+		return fmt.Errorf("failed to write the new API key to %s: %w", path, err)
+	}
+	undo.add(func() error {
+		if !existed {
+			return os.Remove(path)
+		}
+		return os.WriteFile(path, data, 0o600)
+	})
+	return nil
+}
+
+// setEnvVar sets a variable in a dotenv file, replacing its line or adding one
+func setEnvVar(data []byte, name, value string) []byte {
+	line := name + "=" + value
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	found := false
+	for i, l := range lines {
+		trimmed := strings.TrimPrefix(strings.TrimSpace(l), "export ")
+		if strings.HasPrefix(trimmed, name+"=") {
+			lines[i] = strings.Replace(l, trimmed, line, 1)
+			found = true
+		}
+	}
+	if !found {
+		lines = append(lines, line)
+	}
+	var b bytes.Buffer
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+	return b.Bytes()
+}
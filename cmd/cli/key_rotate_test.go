@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/config"
+	"hotaisle-cli/test"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRotateTestKey creates the key of a CI job, that can operate team ml
+func newRotateTestKey(t *testing.T, app *App) *client.UserAPIKeyWithToken {
+	key, err := app.Client.Api.User().CreateAPIKey(context.Background(), client.UserAPIKeyRequest{
+		Label:    "ci",
+		UserRole: fakeapi.RoleOwner,
+		Teams:    []client.UserAPIKeyTeamRoles{{Team: "ml", Roles: []string{fakeapi.RoleOperator}}},
+	})
+	require.NoError(t, err)
+	return key
+}
+
+func runRotate(t *testing.T, app *App, args ...string) (string, error) {
+	cmdDef := userCommands.findCommand("user.api-keys.rotate")
+	require.NotNil(t, cmdDef)
+	var runErr error
+	output := test.CaptureStdout(t, func() error {
+		runErr = buildCommand(app, *cmdDef).Run(context.Background(), append([]string{"rotate"}, args...))
+		return nil
+	})
+	return output, runErr
+}
+
+func apiKeysByLabel(t *testing.T, app *App, label string) []client.UserAPIKey {
+	keys, err := app.Client.Api.User().GetAPIKeys(context.Background())
+	require.NoError(t, err)
+	var found []client.UserAPIKey
+	for _, k := range keys {
+		if k.Label == label {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+func TestRotateAPIKey_Files(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	old := newRotateTestKey(t, app)
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	envFile := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("REGION=us\nexport HOTAISLE_API_TOKEN="+old.Token+"\n"), 0o600))
+
+	output, err := runRotate(t, app, "--prefix", old.Prefix, "--token-file", tokenFile, "--env-file", envFile)
+	require.NoError(t, err)
+
+	keys := apiKeysByLabel(t, app, "ci")
+	require.Len(t, keys, 1)
+	assert.NotEqual(t, old.Prefix, keys[0].Prefix)
+	assert.Equal(t, old.UserRole, keys[0].UserRole)
+	assert.Equal(t, old.Teams, keys[0].Teams)
+
+	token, err := os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.NotContains(t, output, string(token[:len(token)-1]), "the token is only written to the targets")
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "REGION=us\nexport HOTAISLE_API_TOKEN="+string(token), string(env))
+
+	verify := app.newAPIClient()
+	verify.Api.SetToken(string(token[:len(token)-1]))
+	_, err = verify.Api.User().Get(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestRotateAPIKey_SaveToProfile(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	old := newRotateTestKey(t, app)
+	app.Config.ApiToken = old.Token
+	app.Config.LoginKey = old.Prefix
+	app.Client = app.newAPIClient()
+
+	output, err := runRotate(t, app, "--prefix", old.Prefix, "--save")
+	require.NoError(t, err)
+	assert.NotContains(t, output, app.Config.ApiToken)
+
+	saved, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Token, saved.ApiToken)
+	assert.Equal(t, saved.ApiToken, app.Config.ApiToken)
+	assert.NotEqual(t, old.Prefix, saved.LoginKey)
+
+	// The app uses the new key
+	keys := apiKeysByLabel(t, app, "ci")
+	require.Len(t, keys, 1)
+	assert.Equal(t, saved.LoginKey, keys[0].Prefix)
+}
+
+func TestRotateAPIKey_KeyInUse(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	old := newRotateTestKey(t, app)
+	app.Config.ApiToken = old.Token
+	app.Client = app.newAPIClient()
+
+	dir := t.TempDir()
+	_, err := runRotate(t, app, "--prefix", old.Prefix, "--token-file", filepath.Join(dir, "token"))
+	require.ErrorContains(t, err, "pass --save")
+	assert.NoFileExists(t, filepath.Join(dir, "token"))
+
+	keys := apiKeysByLabel(t, app, "ci")
+	require.Len(t, keys, 1)
+	assert.Equal(t, old.Prefix, keys[0].Prefix, "no key is created or deleted")
+}
+
+func TestRotateAPIKey_Rollback(t *testing.T) {
+	app, fake := newLoginTestApp(t)
+	app.Config.ApiToken = fake.Token()
+	app.Client = app.newAPIClient()
+	old := newRotateTestKey(t, app)
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(old.Token+"\n"), 0o600))
+	newFile := filepath.Join(dir, "new-token")
+
+	_, err := runRotate(t, app, "--prefix", old.Prefix, "--token-file", tokenFile, "--env-file", filepath.Join(dir, "missing", ".env"))
+	require.ErrorContains(t, err, "failed to write the new API key to ")
+	assert.ErrorContains(t, err, ", rolled back")
+
+	keys := apiKeysByLabel(t, app, "ci")
+	require.Len(t, keys, 1)
+	assert.Equal(t, old.Prefix, keys[0].Prefix, "the old key is kept and the new one deleted")
+	token, err := os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, old.Token+"\n", string(token))
+
+	_, err = runRotate(t, app, "--prefix", old.Prefix, "--token-file", newFile, "--env-file", filepath.Join(dir, "missing", ".env"))
+	require.Error(t, err)
+	assert.NoFileExists(t, newFile)
+
+	_, err = runRotate(t, app, "--prefix", "missing")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestSetEnvVar(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "TOKEN=new\n"},
+		{"added", "A=1", "A=1\nTOKEN=new\n"},
+		{"replaced", "A=1\nTOKEN=old\nB=2\n", "A=1\nTOKEN=new\nB=2\n"},
+		{"exported", "  export TOKEN=old\n", "  export TOKEN=new\n"},
+		{"other prefix", "TOKEN_FILE=x\n", "TOKEN_FILE=x\nTOKEN=new\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(setEnvVar([]byte(tt.data), "TOKEN", "new")))
+		})
+	}
+}
@@ -0,0 +1,492 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"hotaisle-cli/client"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan actions
+const (
+	planCreate = "create"
+	planUpdate = "update"
+	planDelete = "delete"
+)
+
+// Resource kinds in manifests, plans and the inventory
+const (
+	kindVM        = "vm"
+	kindBareMetal = "bare_metal"
+)
+
+// manifest is the desired state of the VMs and bare metal servers of one or more teams.
+//
+// Example:
+//
+//	teams:
+//	  - team: ml-research
+//	    vms:
+//	      - description: trainer
+//	        cpu_cores: 13
+//	        ram_gb: 224
+//	        disk_gb: 12288
+//	        gpus: [{count: 1, model: MI300X}]
+//	        user_data_url: https://example.com/cloud-init.yaml
+//	    bare_metal:
+//	      - description: inference
+//	        cpu_cores: 104
+//	        ram_gb: 2048
+//	        disk_gb: 30720
+//
+// Resources are matched to existing ones by name when it is given, and by
+// description otherwise.
+type manifest struct {
+	Teams []manifestTeam `yaml:"teams"`
+}
+
+type manifestTeam struct {
+	Team      string              `yaml:"team"`
+	VMs       []manifestVM        `yaml:"vms"`
+	BareMetal []manifestBareMetal `yaml:"bare_metal"`
+}
+
+type manifestVM struct {
+	Name        string        `yaml:"name,omitempty"`
+	Description string        `yaml:"description,omitempty"`
+	CPUCores    *uint64       `yaml:"cpu_cores,omitempty"`
+	RAMGB       *uint64       `yaml:"ram_gb,omitempty"`
+	DiskGB      *uint64       `yaml:"disk_gb,omitempty"`
+	GPUs        []manifestGPU `yaml:"gpus,omitempty"`
+	UserDataURL string        `yaml:"user_data_url,omitempty"`
+}
+
+type manifestGPU struct {
+	Count uint64 `yaml:"count"`
+	Model string `yaml:"model"`
+}
+
+type manifestBareMetal struct {
+	Name        string `yaml:"name,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	CPUCores    uint64 `yaml:"cpu_cores"`
+	RAMGB       uint64 `yaml:"ram_gb"`
+	DiskGB      uint64 `yaml:"disk_gb"`
+}
+
+// planChange is one API call needed to bring a team to the state of the manifest
+type planChange struct {
+	Action      string `json:"action"`
+	Team        string `json:"team"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Details     string `json:"details,omitempty"`
+
+	vm        *manifestVM
+	bareMetal *manifestBareMetal
+}
+
+// manifestPlan is the set of changes for a manifest, with warnings about
+// differences that cannot be applied in place
+type manifestPlan struct {
+	Changes  []planChange
+	Warnings []string
+}
+
+// teamResources are the existing resources of a team
+type teamResources struct {
+	VMs       []client.VirtualMachineDetails
+	BareMetal []client.BareMetalServerDetails
+}
+
+// loadManifest reads a manifest from a YAML or JSON file, or stdin for "-"
+func loadManifest(path string) (*manifest, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return parseManifest(data)
+}
+
+// parseManifest decodes and validates a manifest, rejecting unknown keys
+func parseManifest(data []byte) (*manifest, error) {
+	var m manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+func (m *manifest) validate() error {
+	if len(m.Teams) == 0 {
+		return errors.New("no teams")
+	}
+	teams := map[string]bool{}
+	for i, t := range m.Teams {
+		if t.Team == "" {
+			return fmt.Errorf("teams[%d]: missing team", i)
+		}
+		if teams[t.Team] {
+			return fmt.Errorf("team %s is listed twice", t.Team)
+		}
+		teams[t.Team] = true
+
+		keys := map[string]bool{}
+		for j, vm := range t.VMs {
+			if vm.Name == "" && vm.Description == "" {
+				return fmt.Errorf("team %s: vms[%d] needs a name or a description to be matched", t.Team, j)
+			}
+			if vm.CPUCores == nil && vm.RAMGB == nil && vm.DiskGB == nil && len(vm.GPUs) == 0 {
+				return fmt.Errorf("team %s: vms[%d] has no specification", t.Team, j)
+			}
+			key := matchKey(vm.Name, vm.Description)
+			if keys[key] {
+				return fmt.Errorf("team %s: vms[%d] duplicates %s", t.Team, j, key)
+			}
+			keys[key] = true
+		}
+
+		keys = map[string]bool{}
+		for j, bm := range t.BareMetal {
+			if bm.Name == "" && bm.Description == "" {
+				return fmt.Errorf("team %s: bare_metal[%d] needs a name or a description to be matched", t.Team, j)
+			}
+			if bm.CPUCores == 0 || bm.RAMGB == 0 || bm.DiskGB == 0 {
+				return fmt.Errorf("team %s: bare_metal[%d] needs cpu_cores, ram_gb and disk_gb", t.Team, j)
+			}
+			key := matchKey(bm.Name, bm.Description)
+			if keys[key] {
+				return fmt.Errorf("team %s: bare_metal[%d] duplicates %s", t.Team, j, key)
+			}
+			keys[key] = true
+		}
+	}
+	return nil
+}
+
+// matchKey is how a manifest resource is identified in messages
+func matchKey(name, description string) string {
+	if name != "" {
+		return "name " + name
+	}
+	return fmt.Sprintf("description %q", description)
+}
+
+// fetchResources lists the existing resources of every team in the manifest
+func fetchResources(ctx context.Context, api *client.Client, m *manifest) (map[string]teamResources, error) {
+	existing := map[string]teamResources{}
+	for _, t := range m.Teams {
+		vms, err := api.VirtualMachines().List(ctx, t.Team)
+		if err != nil {
+			return nil, fmt.Errorf("listing VMs of %s: %w", t.Team, err)
+		}
+		servers, err := api.BareMetal().List(ctx, t.Team)
+		if err != nil {
+			return nil, fmt.Errorf("listing bare metal servers of %s: %w", t.Team, err)
+		}
+		existing[t.Team] = teamResources{VMs: vms, BareMetal: servers}
+	}
+	return existing, nil
+}
+
+// computePlan diffs a manifest against the existing resources. Resources that
+// are not in the manifest are only deleted when prune is set.
+func computePlan(m *manifest, existing map[string]teamResources, prune bool) (*manifestPlan, error) {
+	p := &manifestPlan{Changes: []planChange{}}
+	for _, t := range m.Teams {
+		current := existing[t.Team]
+		if err := p.addVMChanges(t.Team, t.VMs, current.VMs, prune); err != nil {
+			return nil, err
+		}
+		if err := p.addBareMetalChanges(t.Team, t.BareMetal, current.BareMetal, prune); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *manifestPlan) addVMChanges(team string, wanted []manifestVM, current []client.VirtualMachineDetails, prune bool) error {
+	wantNames, wantDescriptions := make([]string, len(wanted)), make([]string, len(wanted))
+	for i, vm := range wanted {
+		wantNames[i], wantDescriptions[i] = vm.Name, vm.Description
+	}
+	names, descriptions := make([]string, len(current)), make([]string, len(current))
+	for i, vm := range current {
+		names[i], descriptions[i] = vm.Name, vm.Description
+	}
+	indexes, claimed, err := matchResources(kindVM, team, wantNames, wantDescriptions, names, descriptions)
+	if err != nil {
+		return err
+	}
+
+	for i, index := range indexes {
+		want := &wanted[i]
+		if index < 0 {
+			p.Changes = append(p.Changes, planChange{
+				Action: planCreate, Team: team, Kind: kindVM, Description: want.Description,
+				Details: want.specs(), vm: want,
+			})
+			continue
+		}
+		have := current[index]
+		if want.Description != "" && want.Description != have.Description {
+			p.Changes = append(p.Changes, planChange{
+				Action: planUpdate, Team: team, Kind: kindVM, Name: have.Name, Description: want.Description,
+				Details: fmt.Sprintf("description %q -> %q", have.Description, want.Description), vm: want,
+			})
+		}
+		if diff := want.specDiff(have); diff != "" {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("team %s: VM %s differs from the manifest (%s), recreate it to change its specs", team, have.Name, diff))
+		}
+	}
+	if prune {
+		for i, vm := range current {
+			if !claimed[i] {
+				p.Changes = append(p.Changes, planChange{
+					Action: planDelete, Team: team, Kind: kindVM, Name: vm.Name, Description: vm.Description,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (p *manifestPlan) addBareMetalChanges(team string, wanted []manifestBareMetal, current []client.BareMetalServerDetails, prune bool) error {
+	wantNames, wantDescriptions := make([]string, len(wanted)), make([]string, len(wanted))
+	for i, server := range wanted {
+		wantNames[i], wantDescriptions[i] = server.Name, server.Description
+	}
+	names, descriptions := make([]string, len(current)), make([]string, len(current))
+	for i, server := range current {
+		names[i], descriptions[i] = server.Name, server.Description
+	}
+	indexes, claimed, err := matchResources(kindBareMetal, team, wantNames, wantDescriptions, names, descriptions)
+	if err != nil {
+		return err
+	}
+
+	for i, index := range indexes {
+		want := &wanted[i]
+		if index < 0 {
+			p.Changes = append(p.Changes, planChange{
+				Action: planCreate, Team: team, Kind: kindBareMetal, Description: want.Description,
+				Details: want.specs(), bareMetal: want,
+			})
+			continue
+		}
+		have := current[index]
+		if want.Description != "" && want.Description != have.Description {
+			p.Changes = append(p.Changes, planChange{
+				Action: planUpdate, Team: team, Kind: kindBareMetal, Name: have.Name, Description: want.Description,
+				Details: fmt.Sprintf("description %q -> %q", have.Description, want.Description), bareMetal: want,
+			})
+		}
+		if diff := want.specDiff(have); diff != "" {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("team %s: bare metal server %s differs from the manifest (%s), release and reserve it again to change its specs", team, have.Name, diff))
+		}
+	}
+	if prune {
+		for i, server := range current {
+			if !claimed[i] {
+				p.Changes = append(p.Changes, planChange{
+					Action: planDelete, Team: team, Kind: kindBareMetal, Name: server.Name, Description: server.Description,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// matchResources finds the existing resource for every manifest entry, by
+// name or by description when no name is given. Names are matched first so a
+// description cannot take a resource that another entry names. The index is
+// -1 for entries that have to be created, and claimed reports which existing
+// resources were matched.
+func matchResources(kind, team string, wantNames, wantDescriptions, names, descriptions []string) (indexes []int, claimed []bool, err error) {
+	indexes = make([]int, len(wantNames))
+	claimed = make([]bool, len(names))
+	for i, name := range wantNames {
+		indexes[i] = -1
+		if name == "" {
+			continue
+		}
+		index := slices.Index(names, name)
+		if index < 0 {
+			return nil, nil, fmt.Errorf("team %s: %s %s does not exist, remove the name to create a new one", team, kind, name)
+		}
+		indexes[i] = index
+		claimed[index] = true
+	}
+
+	for i, description := range wantDescriptions {
+		if wantNames[i] != "" {
+			continue
+		}
+		for j, d := range descriptions {
+			if d != description || claimed[j] {
+				continue
+			}
+			if indexes[i] >= 0 {
+				return nil, nil, fmt.Errorf("team %s: %s %s and %s both have description %q, add a name to the manifest to pick one", team, kind, names[indexes[i]], names[j], description)
+			}
+			indexes[i] = j
+		}
+		if indexes[i] >= 0 {
+			claimed[indexes[i]] = true
+		}
+	}
+	return indexes, claimed, nil
+}
+
+// provisionRequest converts a manifest VM to a provision request
+func (vm *manifestVM) provisionRequest() client.VMProvisionRequest {
+	req := client.VMProvisionRequest{UserDataURL: vm.UserDataURL}
+	req.CPUCores = vm.CPUCores
+	if vm.RAMGB != nil {
+		ram := *vm.RAMGB << 30
+		req.RAMCapacity = &ram
+	}
+	if vm.DiskGB != nil {
+		disk := *vm.DiskGB << 30
+		req.DiskCapacity = &disk
+	}
+	for _, gpu := range vm.GPUs {
+		req.GPUs = append(req.GPUs, client.GPUs{Count: gpu.Count, Model: gpu.Model})
+	}
+	return req
+}
+
+func (vm *manifestVM) specs() string {
+	req := vm.provisionRequest()
+	var parts []string
+	if req.CPUCores != nil {
+		parts = append(parts, fmt.Sprintf("%d cpus", *req.CPUCores))
+	}
+	if req.RAMCapacity != nil {
+		parts = append(parts, formatBytes(*req.RAMCapacity)+" ram")
+	}
+	if req.DiskCapacity != nil {
+		parts = append(parts, formatBytes(*req.DiskCapacity)+" disk")
+	}
+	if len(req.GPUs) > 0 {
+		parts = append(parts, formatGPUs(req.GPUs))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// specDiff describes how an existing VM differs from the specs it should have
+func (vm *manifestVM) specDiff(have client.VirtualMachineDetails) string {
+	want := vm.provisionRequest()
+	var diffs []string
+	if want.CPUCores != nil && formatOptionalUint(want.CPUCores) != formatOptionalUint(have.CPUCores) {
+		diffs = append(diffs, fmt.Sprintf("cpus %s, want %d", orNone(formatOptionalUint(have.CPUCores)), *want.CPUCores))
+	}
+	if want.RAMCapacity != nil && formatOptionalUint(want.RAMCapacity) != formatOptionalUint(have.RAMCapacity) {
+		diffs = append(diffs, fmt.Sprintf("ram %s, want %s", orNone(formatOptionalBytes(have.RAMCapacity)), formatBytes(*want.RAMCapacity)))
+	}
+	if want.DiskCapacity != nil && formatOptionalUint(want.DiskCapacity) != formatOptionalUint(have.DiskCapacity) {
+		diffs = append(diffs, fmt.Sprintf("disk %s, want %s", orNone(formatOptionalBytes(have.DiskCapacity)), formatBytes(*want.DiskCapacity)))
+	}
+	if len(want.GPUs) > 0 && formatGPUs(want.GPUs) != formatGPUs(have.GPUs) {
+		diffs = append(diffs, fmt.Sprintf("gpus %s, want %s", orNone(formatGPUs(have.GPUs)), formatGPUs(want.GPUs)))
+	}
+	return strings.Join(diffs, "; ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func (bm *manifestBareMetal) reservation() client.BareMetalServerReservation {
+	return client.BareMetalServerReservation{
+		Description: bm.Description,
+		Specs: client.BareMetalServerSpecs{
+			CPUCores:     bm.CPUCores,
+			RAMCapacity:  bm.RAMGB << 30,
+			DiskCapacity: bm.DiskGB << 30,
+		},
+	}
+}
+
+func (bm *manifestBareMetal) specs() string {
+	return fmt.Sprintf("%d cpus, %s ram, %s disk", bm.CPUCores, formatBytes(bm.RAMGB<<30), formatBytes(bm.DiskGB<<30))
+}
+
+// specDiff describes how an existing server differs from the specs it should have
+func (bm *manifestBareMetal) specDiff(have client.BareMetalServerDetails) string {
+	want := bm.reservation().Specs
+	var diffs []string
+	if want.CPUCores != have.CPUCores {
+		diffs = append(diffs, fmt.Sprintf("cpus %d, want %d", have.CPUCores, want.CPUCores))
+	}
+	if want.RAMCapacity != have.RAMCapacity {
+		diffs = append(diffs, fmt.Sprintf("ram %s, want %s", formatBytes(have.RAMCapacity), formatBytes(want.RAMCapacity)))
+	}
+	if want.DiskCapacity != have.DiskCapacity {
+		diffs = append(diffs, fmt.Sprintf("disk %s, want %s", formatBytes(have.DiskCapacity), formatBytes(want.DiskCapacity)))
+	}
+	return strings.Join(diffs, "; ")
+}
+
+// applyChange makes the API calls for one change. The name of a created
+// resource is filled in. A dry run has no created resource to follow up on.
+func applyChange(ctx context.Context, api *client.Client, change *planChange, dryRun bool) error {
+	switch {
+	case change.Kind == kindVM && change.Action == planCreate:
+		vm, err := api.VirtualMachines().Provision(ctx, change.Team, change.vm.provisionRequest())
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return nil
+		}
+		change.Name = vm.Name
+		if change.Description == "" {
+			return nil
+		}
+		// Provisioning does not take a description
+		return api.VirtualMachines().Update(ctx, change.Team, vm.Name, client.VirtualMachineUpdate{Description: change.Description})
+	case change.Kind == kindVM && change.Action == planUpdate:
+		return api.VirtualMachines().Update(ctx, change.Team, change.Name, client.VirtualMachineUpdate{Description: change.Description})
+	case change.Kind == kindVM && change.Action == planDelete:
+		return api.VirtualMachines().Delete(ctx, change.Team, change.Name)
+	case change.Kind == kindBareMetal && change.Action == planCreate:
+		server, err := api.BareMetal().Reserve(ctx, change.Team, change.bareMetal.reservation())
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return nil
+		}
+		change.Name = server.Name
+		return nil
+	case change.Kind == kindBareMetal && change.Action == planUpdate:
+		return api.BareMetal().Update(ctx, change.Team, change.Name, client.BareMetalServerUpdate{Description: change.Description})
+	case change.Kind == kindBareMetal && change.Action == planDelete:
+		return api.BareMetal().Delete(ctx, change.Team, change.Name)
+	}
+	return fmt.Errorf("unsupported change %s %s", change.Action, change.Kind)
+}
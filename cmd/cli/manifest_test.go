@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"testing"
+
+	"hotaisle-cli/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestParseManifest(t *testing.T) {
+	m, err := parseManifest([]byte(`
+teams:
+  - team: ml
+    vms:
+      - description: trainer
+        cpu_cores: 13
+        ram_gb: 224
+        gpus: [{count: 1, model: MI300X}]
+    bare_metal:
+      - name: server-1
+        cpu_cores: 104
+        ram_gb: 2048
+        disk_gb: 30720
+`))
+	require.NoError(t, err)
+	require.Len(t, m.Teams, 1)
+	assert.Equal(t, "trainer", m.Teams[0].VMs[0].Description)
+	assert.Equal(t, uint64(224), *m.Teams[0].VMs[0].RAMGB)
+	assert.Nil(t, m.Teams[0].VMs[0].DiskGB)
+	assert.Equal(t, "server-1", m.Teams[0].BareMetal[0].Name)
+
+	// JSON is valid YAML
+	_, err = parseManifest([]byte(`{"teams": [{"team": "ml", "vms": [{"name": "vm-1", "cpu_cores": 2}]}]}`))
+	assert.NoError(t, err)
+}
+
+func TestParseManifest_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{name: "empty", manifest: "", want: "no teams"},
+		{name: "unknown key", manifest: "teams:\n  - team: ml\n    vm: []\n", want: "field vm not found"},
+		{name: "missing team", manifest: "teams:\n  - vms: []\n", want: "teams[0]: missing team"},
+		{name: "duplicate team", manifest: "teams:\n  - team: ml\n  - team: ml\n", want: "team ml is listed twice"},
+		{name: "unmatchable vm", manifest: "teams:\n  - team: ml\n    vms:\n      - cpu_cores: 2\n", want: "needs a name or a description"},
+		{name: "vm without specs", manifest: "teams:\n  - team: ml\n    vms:\n      - name: vm-1\n", want: "has no specification"},
+		{name: "duplicate vm", manifest: "teams:\n  - team: ml\n    vms:\n      - {description: a, cpu_cores: 2}\n      - {description: a, cpu_cores: 4}\n", want: `duplicates description "a"`},
+		{name: "incomplete bare metal", manifest: "teams:\n  - team: ml\n    bare_metal:\n      - {name: s, cpu_cores: 2}\n", want: "needs cpu_cores, ram_gb and disk_gb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest([]byte(tt.manifest))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func testFleet() map[string]teamResources {
+	return map[string]teamResources{
+		"ml": {
+			VMs: []client.VirtualMachineDetails{
+				{
+					VirtualMachine:      client.VirtualMachine{Name: "vm-1", Description: "trainer"},
+					VirtualMachineSpecs: client.VirtualMachineSpecs{CPUCores: uint64Ptr(13)},
+				},
+				{
+					VirtualMachine:      client.VirtualMachine{Name: "vm-2", Description: "old"},
+					VirtualMachineSpecs: client.VirtualMachineSpecs{CPUCores: uint64Ptr(2)},
+				},
+			},
+			BareMetal: []client.BareMetalServerDetails{
+				{
+					BareMetalServer:      client.BareMetalServer{Name: "server-1"},
+					BareMetalServerSpecs: client.BareMetalServerSpecs{CPUCores: 104, RAMCapacity: 2048 << 30, DiskCapacity: 30720 << 30},
+				},
+			},
+		},
+	}
+}
+
+func TestComputePlan(t *testing.T) {
+	m := &manifest{Teams: []manifestTeam{{
+		Team: "ml",
+		VMs: []manifestVM{
+			{Description: "trainer", CPUCores: uint64Ptr(13)},
+			{Description: "eval", CPUCores: uint64Ptr(4)},
+		},
+		BareMetal: []manifestBareMetal{
+			{Name: "server-1", Description: "inference", CPUCores: 104, RAMGB: 2048, DiskGB: 30720},
+		},
+	}}}
+
+	plan, err := computePlan(m, testFleet(), false)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Warnings)
+	require.Len(t, plan.Changes, 2)
+	assert.Equal(t, planCreate, plan.Changes[0].Action)
+	assert.Equal(t, kindVM, plan.Changes[0].Kind)
+	assert.Equal(t, "eval", plan.Changes[0].Description)
+	assert.Equal(t, "4 cpus", plan.Changes[0].Details)
+	assert.Equal(t, planUpdate, plan.Changes[1].Action)
+	assert.Equal(t, kindBareMetal, plan.Changes[1].Kind)
+	assert.Equal(t, "server-1", plan.Changes[1].Name)
+
+	plan, err = computePlan(m, testFleet(), true)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, planDelete, plan.Changes[1].Action)
+	assert.Equal(t, "vm-2", plan.Changes[1].Name)
+}
+
+func TestComputePlan_NoChanges(t *testing.T) {
+	m := &manifest{Teams: []manifestTeam{{
+		Team: "ml",
+		VMs: []manifestVM{
+			{Description: "trainer", CPUCores: uint64Ptr(13)},
+			{Name: "vm-2", CPUCores: uint64Ptr(2)},
+		},
+		BareMetal: []manifestBareMetal{
+			{Name: "server-1", CPUCores: 104, RAMGB: 2048, DiskGB: 30720},
+		},
+	}}}
+
+	plan, err := computePlan(m, testFleet(), true)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+	assert.Empty(t, plan.Warnings)
+}
+
+func TestComputePlan_SpecDriftWarns(t *testing.T) {
+	m := &manifest{Teams: []manifestTeam{{
+		Team: "ml",
+		VMs:  []manifestVM{{Name: "vm-1", CPUCores: uint64Ptr(26), RAMGB: uint64Ptr(224)}},
+	}}}
+
+	plan, err := computePlan(m, testFleet(), false)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+	require.Len(t, plan.Warnings, 1)
+	assert.Contains(t, plan.Warnings[0], "VM vm-1 differs from the manifest (cpus 13, want 26; ram none, want 224GiB)")
+}
+
+func TestComputePlan_MatchErrors(t *testing.T) {
+	fleet := testFleet()
+	ml := fleet["ml"]
+	ml.VMs[1].Description = "trainer"
+	fleet["ml"] = ml
+
+	m := &manifest{Teams: []manifestTeam{{
+		Team: "ml",
+		VMs:  []manifestVM{{Description: "trainer", CPUCores: uint64Ptr(13)}},
+	}}}
+	_, err := computePlan(m, fleet, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `vm vm-1 and vm-2 both have description "trainer"`)
+
+	// A name takes its resource out of the description match
+	m.Teams[0].VMs = append(m.Teams[0].VMs, manifestVM{Name: "vm-2", CPUCores: uint64Ptr(2)})
+	plan, err := computePlan(m, fleet, false)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+
+	m.Teams[0].VMs = []manifestVM{{Name: "vm-9", CPUCores: uint64Ptr(2)}}
+	_, err = computePlan(m, fleet, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vm vm-9 does not exist")
+}
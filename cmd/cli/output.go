@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable      = "table"
+	outputWide       = "wide"
+	outputJSON       = "json"
+	outputJSONL      = "jsonl"
+	outputYAML       = "yaml"
+	outputGoTemplate = "go-template"
+	outputJSONPath   = "jsonpath"
+
+	// defaultOutput keeps the historical behavior of printing indented JSON
+	defaultOutput = outputJSON
+)
+
+// renderer writes v to w. arg is the text after "=" in the output flag, e.g. the
+// template in "go-template={{.name}}", and is empty for formats that take none.
+type renderer func(w io.Writer, v any, arg string) error
+
+// renderers is the registry of output formats selectable with --output.
+var renderers = map[string]renderer{
+	outputTable: func(w io.Writer, v any, _ string) error {
+		return renderTable(w, v, false)
+	},
+	outputWide: func(w io.Writer, v any, _ string) error {
+		return renderTable(w, v, true)
+	},
+	outputJSON:       renderJSON,
+	outputJSONL:      renderJSONL,
+	outputYAML:       renderYAML,
+	outputGoTemplate: renderGoTemplate,
+	outputJSONPath:   renderJSONPath,
+}
+
+// outputFormats returns the registered output format names, sorted
+func outputFormats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseOutput splits an output flag value such as "jsonpath={.name}" into the
+// format name and its argument, and checks the format is registered.
+func parseOutput(output string) (string, string, error) {
+	name, arg, _ := strings.Cut(output, "=")
+	if name == "" {
+		name = defaultOutput
+	}
+	if _, ok := renderers[name]; !ok {
+		return "", "", fmt.Errorf("unknown output format %q, valid formats are: %s", name, strings.Join(outputFormats(), ", "))
+	}
+	if (name == outputGoTemplate || name == outputJSONPath) && arg == "" {
+		return "", "", fmt.Errorf("output format %s requires an expression, e.g. %s=<expression>", name, name)
+	}
+	return name, arg, nil
+}
+
+// printOutput renders a value to stdout using the format selected with --output
+func printOutput(app *App, v any) error {
+	output := defaultOutput
+	if app != nil && app.Output != "" {
+		output = app.Output
+	}
+	name, arg, err := parseOutput(output)
+	if err != nil {
+		return err
+	}
+	return renderers[name](os.Stdout, v, arg)
+}
+
+// printResult renders the result of a request that changes something. With
+// --dry-run the request was not sent, so there is no result to print.
+func printResult(app *App, v any) error {
+	if app.dryRun {
+		return nil
+	}
+	return printOutput(app, v)
+}
+
+// renderJSON writes v as pretty-printed JSON
+func renderJSON(w io.Writer, v any, _ string) error {
+	prettyJSON, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(prettyJSON)
+	return err
+}
+
+// renderJSONL writes one compact JSON document per line, one per element if v is a slice
+func renderJSONL(w io.Writer, v any, _ string) error {
+	enc := json.NewEncoder(w)
+	for _, item := range outputItems(v) {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderYAML writes v as YAML, keeping the JSON field names and their order
+func renderYAML(w io.Writer, v any, _ string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so decoding it into a node keeps the key order of the struct
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resetYAMLStyle drops the flow and quoting styles inherited from the JSON input
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// renderGoTemplate executes a text/template against the JSON representation of v,
+// so fields are addressed by their JSON names, e.g. {{range .}}{{.name}}{{end}}
+func renderGoTemplate(w io.Writer, v any, arg string) error {
+	tmpl, err := template.New("output").Parse(arg)
+	if err != nil {
+		return fmt.Errorf("invalid go-template: %w", err)
+	}
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// renderJSONPath evaluates a jsonpath template against the JSON representation of v
+func renderJSONPath(w io.Writer, v any, arg string) error {
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	out, err := evalJSONPathTemplate(arg, data)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// toGeneric converts v to the maps, slices and scalars produced by decoding its JSON
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// outputItems returns the elements of v if it is a slice, or v itself otherwise
+func outputItems(v any) []any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice {
+		return []any{v}
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// renderTable writes v as aligned columns using the column set registered for its
+// type. Types without a column set fall back to YAML.
+func renderTable(w io.Writer, v any, wide bool) error {
+	elemType := reflect.TypeOf(v)
+	for elemType != nil && (elemType.Kind() == reflect.Pointer || elemType.Kind() == reflect.Slice) {
+		elemType = elemType.Elem()
+	}
+	cols, ok := tableColumns[elemType]
+	if !ok {
+		return renderYAML(w, v, "")
+	}
+	if !wide {
+		cols = slices.DeleteFunc(slices.Clone(cols), func(c column) bool { return c.Wide })
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Header
+	}
+	_, _ = fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, item := range outputItems(v) {
+		rv := reflect.ValueOf(item)
+		if rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				continue
+			}
+			rv = rv.Elem()
+		}
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.Value(rv.Interface())
+			if row[i] == "" {
+				row[i] = "-"
+			}
+		}
+		_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// column is a single column of table output
+type column struct {
+	Header string
+	// Wide columns are only shown with --output wide
+	Wide  bool
+	Value func(any) string
+}
+
+// tableColumns maps a model type to the columns used to render it as a table
+var tableColumns = map[reflect.Type][]column{}
+
+// registerColumns sets the table columns for values and slices of T
+func registerColumns[T any](cols ...column) {
+	tableColumns[reflect.TypeFor[T]()] = cols
+}
+
+// col defines a column that is always shown
+func col[T any](header string, value func(T) string) column {
+	return column{
+		Header: header,
+		Value:  func(v any) string { return value(v.(T)) },
+	}
+}
+
+// wideCol defines a column that is only shown with --output wide
+func wideCol[T any](header string, value func(T) string) column {
+	c := col(header, value)
+	c.Wide = true
+	return c
+}
+
+func init() {
+	registerColumns[profileSummary](
+		col("NAME", func(p profileSummary) string { return p.Name }),
+		col("ACTIVE", func(p profileSummary) string {
+			if p.Active {
+				return "*"
+			}
+			return ""
+		}),
+		col("DEFAULT TEAM", func(p profileSummary) string { return p.DefaultTeam }),
+		col("BASE URL", func(p profileSummary) string { return p.BaseURL }),
+		col("TOKEN", func(p profileSummary) string { return p.Token }),
+		wideCol("TOKEN STORE", func(p profileSummary) string { return p.TokenStore }),
+		wideCol("LOG LEVEL", func(p profileSummary) string { return p.LogLevel }),
+	)
+
+	registerColumns[loginSummary](
+		col("PROFILE", func(s loginSummary) string { return s.Profile }),
+		col("NAME", func(s loginSummary) string { return s.Name }),
+		col("EMAIL", func(s loginSummary) string { return s.Email }),
+		col("KEY", func(s loginSummary) string { return s.Key }),
+		col("TEAMS", func(s loginSummary) string {
+			teams := make([]string, len(s.Teams))
+			for i, t := range s.Teams {
+				teams[i] = t.Handle + " (" + strings.Join(t.Roles, ",") + ")"
+			}
+			return strings.Join(teams, ", ")
+		}),
+		wideCol("MINTED", func(s loginSummary) string { return strconv.FormatBool(s.Minted) }),
+	)
+
+	registerColumns[planChange](
+		col("ACTION", func(c planChange) string { return c.Action }),
+		col("TEAM", func(c planChange) string { return c.Team }),
+		col("KIND", func(c planChange) string { return c.Kind }),
+		col("NAME", func(c planChange) string { return c.Name }),
+		col("DESCRIPTION", func(c planChange) string { return c.Description }),
+		col("DETAILS", func(c planChange) string { return c.Details }),
+	)
+	registerColumns[inventoryItem](
+		col("TEAM", func(i inventoryItem) string { return i.Team }),
+		col("KIND", func(i inventoryItem) string { return i.Kind }),
+		col("NAME", func(i inventoryItem) string { return i.Name }),
+		col("IP", func(i inventoryItem) string { return i.IPAddress }),
+		col("CPUS", func(i inventoryItem) string { return strconv.FormatUint(i.CPUCores, 10) }),
+		col("RAM", func(i inventoryItem) string { return formatBytes(i.RAMCapacity) }),
+		col("DISK", func(i inventoryItem) string { return formatBytes(i.DiskCapacity) }),
+		col("GPUS", func(i inventoryItem) string { return formatGPUs(i.GPUs) }),
+		col("STATE", func(i inventoryItem) string { return i.State }),
+		col("BURN/HR", func(i inventoryItem) string {
+			if i.HourlyCost == nil {
+				return ""
+			}
+			return formatCents(*i.HourlyCost)
+		}),
+		wideCol("DESCRIPTION", func(i inventoryItem) string { return i.Description }),
+	)
+	registerColumns[inventoryTeam](
+		col("TEAM", func(t inventoryTeam) string { return t.Team }),
+		col("VMS", func(t inventoryTeam) string { return strconv.Itoa(t.VirtualMachines) }),
+		col("BARE METAL", func(t inventoryTeam) string { return strconv.Itoa(t.BareMetalServers) }),
+		col("BALANCE", func(t inventoryTeam) string { return formatCents(t.AvailableBalance) }),
+		col("BURN/HR", func(t inventoryTeam) string { return formatCents(t.HourlyRate) }),
+		col("RUNOUT", func(t inventoryTeam) string {
+			if t.EstimatedRunoutTime == nil {
+				return ""
+			}
+			return formatTime(*t.EstimatedRunoutTime)
+		}),
+		col("ERROR", func(t inventoryTeam) string { return t.Error }),
+	)
+	registerColumns[bulkResult](
+		col("NAME", func(r bulkResult) string { return r.Name }),
+		col("RESULT", func(r bulkResult) string { return r.Result }),
+		col("ERROR", func(r bulkResult) string { return r.Error }),
+	)
+	registerColumns[balanceAlert](
+		col("TEAM", func(a balanceAlert) string { return a.Team }),
+		col("STATUS", func(a balanceAlert) string { return a.Status }),
+		col("BALANCE", func(a balanceAlert) string { return formatCents(a.AvailableBalance) }),
+		col("BURN/HR", func(a balanceAlert) string { return formatCents(a.HourlyRate) }),
+		col("RUNOUT", func(a balanceAlert) string {
+			if a.EstimatedRunoutTime == nil {
+				return ""
+			}
+			return formatTime(*a.EstimatedRunoutTime)
+		}),
+		col("REASONS", func(a balanceAlert) string { return strings.Join(a.Reasons, ", ") }),
+	)
+	registerColumns[client.User](
+		col("NAME", func(u client.User) string { return u.Name }),
+		col("EMAIL", func(u client.User) string { return u.Email }),
+		wideCol("CREATED", func(u client.User) string { return formatTime(u.Created) }),
+	)
+	registerColumns[client.GetUserResponse](
+		col("NAME", func(r client.GetUserResponse) string { return r.User.Name }),
+		col("EMAIL", func(r client.GetUserResponse) string { return r.User.Email }),
+		col("TEAMS", func(r client.GetUserResponse) string {
+			handles := make([]string, len(r.Teams))
+			for i, t := range r.Teams {
+				handles[i] = t.Handle
+			}
+			return strings.Join(handles, ",")
+		}),
+		wideCol("CREATED", func(r client.GetUserResponse) string { return formatTime(r.User.Created) }),
+	)
+
+	registerColumns[client.UserTeam](userTeamColumns(func(t client.UserTeam) client.UserTeam { return t })...)
+	registerColumns[client.UserTeamWithMembers](userTeamColumns(func(t client.UserTeamWithMembers) client.UserTeam { return t.UserTeam })...)
+	registerColumns[client.UserTeamDetails](userTeamColumns(func(t client.UserTeamDetails) client.UserTeam { return t.UserTeam })...)
+
+	registerColumns[client.TeamMember](
+		col("NAME", func(m client.TeamMember) string { return m.Name }),
+		col("EMAIL", func(m client.TeamMember) string { return m.Email }),
+		col("ROLES", func(m client.TeamMember) string { return strings.Join(m.Roles, ",") }),
+		col("INVITATION", func(m client.TeamMember) string { return strconv.FormatBool(m.Invitation) }),
+		wideCol("CREATED", func(m client.TeamMember) string { return formatTime(m.Created) }),
+	)
+	registerColumns[client.BalanceInfo](
+		col("BALANCE", func(b client.BalanceInfo) string { return formatCents(b.AvailableBalance) }),
+		col("HOURLY RATE", func(b client.BalanceInfo) string { return formatCents(b.HourlyRate) }),
+		col("VMS", func(b client.BalanceInfo) string { return strconv.FormatInt(b.VirtualMachineCount, 10) }),
+		col("BARE METAL", func(b client.BalanceInfo) string { return strconv.FormatInt(b.BareMetalServerCount, 10) }),
+		col("RUNOUT", func(b client.BalanceInfo) string {
+			if b.EstimatedRunoutTime == nil {
+				return ""
+			}
+			return formatTime(*b.EstimatedRunoutTime)
+		}),
+		wideCol("MINIMUM BALANCE", func(b client.BalanceInfo) string { return formatCents(b.MinimumBalance) }),
+	)
+	registerColumns[client.PurchaseTeamCreditsResponse](
+		col("CHECKOUT URL", func(r client.PurchaseTeamCreditsResponse) string { return r.CheckoutURL }),
+		col("EXPIRES AT", func(r client.PurchaseTeamCreditsResponse) string { return formatTime(r.ExpiresAt) }),
+	)
+
+	registerColumns[client.SSHKey](
+		col("TYPE", func(k client.SSHKey) string { return k.Type }),
+		col("FINGERPRINT", func(k client.SSHKey) string { return k.Fingerprint }),
+		col("COMMENT", func(k client.SSHKey) string { return k.Comment }),
+		wideCol("PUBLIC KEY", func(k client.SSHKey) string { return k.PublicKey }),
+	)
+	registerColumns[client.UserAPIKey](apiKeyColumns(func(k client.UserAPIKey) client.UserAPIKey { return k })...)
+	registerColumns[client.UserAPIKeyWithToken](append(
+		apiKeyColumns(func(k client.UserAPIKeyWithToken) client.UserAPIKey { return k.UserAPIKey }),
+		col("TOKEN", func(k client.UserAPIKeyWithToken) string { return k.Token }),
+	)...)
+
+	registerColumns[client.VirtualMachineDetails](
+		col("NAME", func(vm client.VirtualMachineDetails) string { return vm.Name }),
+		col("IP", func(vm client.VirtualMachineDetails) string { return vm.IPAddress }),
+		col("CPUS", func(vm client.VirtualMachineDetails) string { return formatOptionalUint(vm.CPUCores) }),
+		col("RAM", func(vm client.VirtualMachineDetails) string { return formatOptionalBytes(vm.RAMCapacity) }),
+		col("DISK", func(vm client.VirtualMachineDetails) string { return formatOptionalBytes(vm.DiskCapacity) }),
+		col("GPUS", func(vm client.VirtualMachineDetails) string { return formatGPUs(vm.GPUs) }),
+		wideCol("SSH", func(vm client.VirtualMachineDetails) string { return formatExternalService(vm.SSHAccess) }),
+		wideCol("DESCRIPTION", func(vm client.VirtualMachineDetails) string { return vm.Description }),
+	)
+	registerColumns[client.VirtualMachineState](
+		col("STATE", func(s client.VirtualMachineState) string { return s.State }),
+		col("HOST", func(s client.VirtualMachineState) string { return s.Host }),
+	)
+	registerColumns[client.AvailableVirtualMachineTypes](
+		col("QUANTITY", func(a client.AvailableVirtualMachineTypes) string { return strconv.FormatInt(a.Quantity, 10) }),
+		col("CPUS", func(a client.AvailableVirtualMachineTypes) string { return formatOptionalUint(a.Specs.CPUCores) }),
+		col("RAM", func(a client.AvailableVirtualMachineTypes) string { return formatOptionalBytes(a.Specs.RAMCapacity) }),
+		col("DISK", func(a client.AvailableVirtualMachineTypes) string { return formatOptionalBytes(a.Specs.DiskCapacity) }),
+		col("GPUS", func(a client.AvailableVirtualMachineTypes) string { return formatGPUs(a.Specs.GPUs) }),
+		col("PRICE/HR", func(a client.AvailableVirtualMachineTypes) string { return formatCents(a.OnDemandPrice) }),
+		wideCol("MINIMUM MINUTES", func(a client.AvailableVirtualMachineTypes) string {
+			return strconv.FormatInt(a.MinimumReservationMinutes, 10)
+		}),
+	)
+
+	registerColumns[client.BareMetalServerDetails](
+		col("NAME", func(s client.BareMetalServerDetails) string { return s.Name }),
+		col("IP", func(s client.BareMetalServerDetails) string { return s.IPAddress }),
+		col("MODEL", func(s client.BareMetalServerDetails) string { return s.Model }),
+		col("CPUS", func(s client.BareMetalServerDetails) string { return strconv.FormatUint(s.CPUCores, 10) }),
+		col("RAM", func(s client.BareMetalServerDetails) string { return formatBytes(s.RAMCapacity) }),
+		col("DISK", func(s client.BareMetalServerDetails) string { return formatBytes(s.DiskCapacity) }),
+		col("GPUS", func(s client.BareMetalServerDetails) string { return formatGPUs(s.GPUs) }),
+		col("OS STATUS", func(s client.BareMetalServerDetails) string { return formatOSStatus(s.OSStatus) }),
+		wideCol("MANUFACTURER", func(s client.BareMetalServerDetails) string { return s.Manufacturer }),
+		wideCol("SSH", func(s client.BareMetalServerDetails) string { return formatExternalService(s.SSHAccess) }),
+		wideCol("SUPPORT ACCESS", func(s client.BareMetalServerDetails) string { return strconv.FormatBool(s.SupportAccessEnabled) }),
+		wideCol("DESCRIPTION", func(s client.BareMetalServerDetails) string { return s.Description }),
+	)
+	registerColumns[client.BareMetalServerReservationResponse](
+		col("NAME", func(s client.BareMetalServerReservationResponse) string { return s.Name }),
+		col("IP", func(s client.BareMetalServerReservationResponse) string { return s.IPAddress }),
+		col("MODEL", func(s client.BareMetalServerReservationResponse) string { return s.Model }),
+		col("CPUS", func(s client.BareMetalServerReservationResponse) string { return strconv.FormatUint(s.CPUCores, 10) }),
+		col("RAM", func(s client.BareMetalServerReservationResponse) string { return formatBytes(s.RAMCapacity) }),
+		col("DISK", func(s client.BareMetalServerReservationResponse) string { return formatBytes(s.DiskCapacity) }),
+		col("GPUS", func(s client.BareMetalServerReservationResponse) string { return formatGPUs(s.GPUs) }),
+		col("OS STATUS", func(s client.BareMetalServerReservationResponse) string { return formatOSStatus(s.OSStatus) }),
+		wideCol("SSH", func(s client.BareMetalServerReservationResponse) string { return formatExternalService(s.SSHAccess) }),
+		wideCol("DESCRIPTION", func(s client.BareMetalServerReservationResponse) string { return s.Description }),
+	)
+	registerColumns[client.BareMetalServerlOSStatus](
+		col("OS", func(s client.BareMetalServerlOSStatus) string { return s.OSSelection }),
+		col("STATUS", func(s client.BareMetalServerlOSStatus) string { return s.OSStatus }),
+		col("LAST UPDATE", func(s client.BareMetalServerlOSStatus) string { return formatTime(s.LastImagingUpdate) }),
+	)
+	registerColumns[client.BareMetalServerPowerState](
+		col("STATE", func(s client.BareMetalServerPowerState) string { return s.State }),
+	)
+	registerColumns[client.BareMetalServerConsoleURL](
+		col("URL", func(c client.BareMetalServerConsoleURL) string { return c.URL }),
+	)
+	registerColumns[client.AvailableBareMetalTypes](
+		col("QUANTITY", func(a client.AvailableBareMetalTypes) string { return strconv.FormatInt(a.Quantity, 10) }),
+		col("CPUS", func(a client.AvailableBareMetalTypes) string { return strconv.FormatUint(a.Specs.CPUCores, 10) }),
+		col("RAM", func(a client.AvailableBareMetalTypes) string { return formatBytes(a.Specs.RAMCapacity) }),
+		col("DISK", func(a client.AvailableBareMetalTypes) string { return formatBytes(a.Specs.DiskCapacity) }),
+		col("GPUS", func(a client.AvailableBareMetalTypes) string { return formatGPUs(a.Specs.GPUs) }),
+		col("PRICE/HR", func(a client.AvailableBareMetalTypes) string { return formatCents(a.OnDemandPrice) }),
+		wideCol("MINIMUM MINUTES", func(a client.AvailableBareMetalTypes) string {
+			return strconv.FormatInt(a.MinimumReservationMinutes, 10)
+		}),
+	)
+}
+
+// userTeamColumns builds the team columns for any model that embeds a UserTeam
+func userTeamColumns[T any](team func(T) client.UserTeam) []column {
+	return []column{
+		col("HANDLE", func(v T) string { return team(v).Handle }),
+		col("NAME", func(v T) string { return team(v).Name }),
+		col("ROLES", func(v T) string { return strings.Join(team(v).Roles, ",") }),
+		wideCol("EFFECTIVE ROLES", func(v T) string { return strings.Join(team(v).EffectiveRoles, ",") }),
+		wideCol("MAX VMS", func(v T) string { return strconv.FormatInt(team(v).MaximumVirtualMachines, 10) }),
+		wideCol("MAX BARE METAL", func(v T) string { return strconv.FormatInt(team(v).MaximumBareMetalServers, 10) }),
+		wideCol("DESCRIPTION", func(v T) string { return team(v).Description }),
+	}
+}
+
+// apiKeyColumns builds the API key columns for any model that embeds a UserAPIKey
+func apiKeyColumns[T any](key func(T) client.UserAPIKey) []column {
+	return []column{
+		col("PREFIX", func(v T) string { return key(v).Prefix }),
+		col("LABEL", func(v T) string { return key(v).Label }),
+		col("USER ROLE", func(v T) string { return key(v).UserRole }),
+		col("TEAMS", func(v T) string {
+			teams := make([]string, len(key(v).Teams))
+			for i, t := range key(v).Teams {
+				teams[i] = t.Handle + ":" + strings.Join(t.Roles, "+")
+			}
+			return strings.Join(teams, ",")
+		}),
+	}
+}
+
+// formatCents formats an amount in US cents as dollars
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
+
+// formatBytes formats a capacity in bytes using binary units
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	value := float64(b) / float64(div)
+	suffix := "KMGTP"[exp : exp+1]
+	if value == float64(uint64(value)) {
+		return fmt.Sprintf("%d%siB", uint64(value), suffix)
+	}
+	return fmt.Sprintf("%.1f%siB", value, suffix)
+}
+
+func formatOptionalBytes(b *uint64) string {
+	if b == nil {
+		return ""
+	}
+	return formatBytes(*b)
+}
+
+func formatOptionalUint(n *uint64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatUint(*n, 10)
+}
+
+// formatGPUs formats GPUs as e.g. "8x MI300X"
+func formatGPUs(gpus []client.GPUs) string {
+	parts := make([]string, 0, len(gpus))
+	for _, g := range gpus {
+		if g.Count == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%dx %s", g.Count, g.Model))
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatExternalService formats an endpoint as host:port, preferring the DNS name
+func formatExternalService(s *client.ExternalService) string {
+	if s == nil {
+		return ""
+	}
+	host := s.IPAddress
+	if s.DNSName != "" {
+		host = s.DNSName
+	}
+	return fmt.Sprintf("%s:%d", host, s.Port)
+}
+
+func formatOSStatus(s *client.BareMetalServerlOSStatus) string {
+	if s == nil {
+		return ""
+	}
+	return s.OSStatus
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVMs() []client.VirtualMachineDetails {
+	cpuCores := uint64(8)
+	ram := uint64(32 << 30)
+	return []client.VirtualMachineDetails{
+		{
+			VirtualMachine: client.VirtualMachine{
+				Name:        "vm-1",
+				IPAddress:   "10.0.0.1",
+				Description: "training box",
+				SSHAccess:   &client.ExternalService{IPAddress: "203.0.113.10", Port: 2222},
+			},
+			VirtualMachineSpecs: client.VirtualMachineSpecs{
+				CPUCores:    &cpuCores,
+				RAMCapacity: &ram,
+				GPUs:        []client.GPUs{{Count: 1, Model: "MI300X"}},
+			},
+		},
+		{
+			VirtualMachine: client.VirtualMachine{Name: "vm-2", IPAddress: "10.0.0.2"},
+		},
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		wantName string
+		wantArg  string
+		wantErr  bool
+	}{
+		{name: "empty uses default", output: "", wantName: defaultOutput},
+		{name: "table", output: "table", wantName: outputTable},
+		{name: "wide", output: "wide", wantName: outputWide},
+		{name: "yaml", output: "yaml", wantName: outputYAML},
+		{name: "go-template with argument", output: "go-template={{.name}}", wantName: outputGoTemplate, wantArg: "{{.name}}"},
+		{name: "jsonpath keeps equals in argument", output: "jsonpath={.a}={.b}", wantName: outputJSONPath, wantArg: "{.a}={.b}"},
+		{name: "go-template without argument", output: "go-template", wantErr: true},
+		{name: "unknown format", output: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, arg, err := parseOutput(tt.output)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantArg, arg)
+		})
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderTable(&buf, testVMs(), false)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"NAME", "IP", "CPUS", "RAM", "DISK", "GPUS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"vm-1", "10.0.0.1", "8", "32GiB", "-", "1x", "MI300X"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"vm-2", "10.0.0.2", "-", "-", "-", "-"}, strings.Fields(lines[2]))
+	assert.NotContains(t, buf.String(), "DESCRIPTION")
+}
+
+func TestRenderTableWide(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderTable(&buf, testVMs(), true)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "DESCRIPTION")
+	assert.Contains(t, buf.String(), "203.0.113.10:2222")
+	assert.Contains(t, buf.String(), "training box")
+}
+
+func TestRenderTableSingleValue(t *testing.T) {
+	runout := time.Date(2025, 5, 20, 10, 30, 0, 0, time.UTC)
+	balance := &client.BalanceInfo{
+		AvailableBalance:    50000,
+		HourlyRate:          1250,
+		VirtualMachineCount: 2,
+		EstimatedRunoutTime: &runout,
+	}
+
+	var buf bytes.Buffer
+	err := renderTable(&buf, balance, false)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "$500.00")
+	assert.Contains(t, lines[1], "$12.50")
+	assert.Contains(t, lines[1], "2025-05-20T10:30:00Z")
+}
+
+func TestRenderTableFallsBackToYAML(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderTable(&buf, map[string]string{"key": "value"}, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "key: value\n", buf.String())
+}
+
+func TestRenderJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderJSONL(&buf, testVMs(), "")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var vm client.VirtualMachineDetails
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &vm))
+	assert.Equal(t, "vm-2", vm.Name)
+}
+
+func TestRenderYAML(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderYAML(&buf, client.VirtualMachineState{State: "running", Host: "vm-host-01"}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "state: running\nhost: vm-host-01\n", buf.String())
+}
+
+func TestRenderGoTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	err := renderGoTemplate(&buf, testVMs(), "{{range .}}{{.name}} {{.cpu_cores}}\n{{end}}")
+	require.NoError(t, err)
+
+	assert.Equal(t, "vm-1 8\nvm-2 <no value>\n", buf.String())
+}
+
+func TestRenderJSONPath(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{name: "wildcard field", expr: "{[*].name}", want: "vm-1 vm-2"},
+		{name: "bare expression", expr: "$[0].ip_address", want: "10.0.0.1"},
+		{name: "negative index", expr: "{[-1].name}", want: "vm-2"},
+		{name: "nested field", expr: "{[0].ssh_access.port}", want: "2222"},
+		{name: "literal text", expr: "first={[0].name}", want: "first=vm-1"},
+		{name: "quoted field", expr: "{[0]['description']}", want: "training box"},
+		{name: "object value", expr: "{[0].gpus[0]}", want: `{"count":1,"model":"MI300X"}`},
+		{name: "missing field", expr: "{[1].ssh_access.port}", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderJSONPath(&buf, testVMs(), tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestRenderJSONPathInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, renderJSONPath(&buf, testVMs(), "{[0].name"))
+	assert.Error(t, renderJSONPath(&buf, testVMs(), "{..name}"))
+	assert.Error(t, renderJSONPath(&buf, testVMs(), "{[a]}"))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512B", formatBytes(512))
+	assert.Equal(t, "2KiB", formatBytes(2048))
+	assert.Equal(t, "1.5GiB", formatBytes(3<<29))
+	assert.Equal(t, "4TiB", formatBytes(4398046511104))
+}
+
+func TestFormatCents(t *testing.T) {
+	assert.Equal(t, "$0.00", formatCents(0))
+	assert.Equal(t, "$12.50", formatCents(1250))
+	assert.Equal(t, "-$0.05", formatCents(-5))
+}
+
+func TestVMListCommand_TableOutput(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Output = outputTable
+
+	mockClient := test.NewMockHTTPClientWithAssertions(t, "/api/teams/test-team/virtual_machines/", http.MethodGet, 200, testVMs())
+	app.Client = api.NewClient("test-token", "1.0.0", client.WithHTTPClient(mockClient))
+
+	cmd, err := getCommand(app, virtualMachineCommands, "list", map[string]string{"team": "test-team"})
+	require.NoError(t, err)
+
+	output := executeCommand(t, cmd)
+
+	assert.True(t, strings.HasPrefix(output, "NAME"))
+	assert.Contains(t, output, "vm-1")
+	assert.Contains(t, output, "vm-2")
+}
+
+func TestPrintOutputInvalidFormat(t *testing.T) {
+	app, _ := setupTestApp(t)
+	app.Output = "xml"
+
+	err := printOutput(app, testVMs())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown output format")
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hotaisle-cli/client"
+
+	"github.com/urfave/cli/v3"
+)
+
+// defaultSSHUser is the user machines are set up with
+const defaultSSHUser = "hotaisle"
+
+// sshFlags are the flags shared by the commands that run ssh
+var sshFlags = []flagDef{
+	{Name: "user", Usage: "Remote user", Value: defaultSSHUser},
+	{Name: "identity", Usage: "Private key file (default: the local key registered with `user ssh-keys add`)"},
+}
+
+// sshTarget is the SSH endpoint of a VM or bare metal server
+type sshTarget struct {
+	Name        string
+	Kind        string
+	Description string
+	Host        string
+	Port        int64
+}
+
+func newSSHTarget(kind, name, description string, access *client.ExternalService) (sshTarget, error) {
+	if access == nil || access.IPAddress == "" && access.DNSName == "" {
+		return sshTarget{}, fmt.Errorf("%s %s has no SSH access", kind, name)
+	}
+	host := access.DNSName
+	if host == "" {
+		host = access.IPAddress
+	}
+	port := access.Port
+	if port == 0 {
+		port = 22
+	}
+	return sshTarget{Name: name, Kind: kind, Description: description, Host: host, Port: port}, nil
+}
+
+func vmSSHTarget(ctx context.Context, api *client.Client, team, name string) (sshTarget, error) {
+	vm, err := api.VirtualMachines().Get(ctx, team, name)
+	if err != nil {
+		return sshTarget{}, err
+	}
+	return newSSHTarget(kindVM, vm.Name, vm.Description, vm.SSHAccess)
+}
+
+func serverSSHTarget(ctx context.Context, api *client.Client, team, name string) (sshTarget, error) {
+	server, err := api.BareMetal().Get(ctx, team, name)
+	if err != nil {
+		return sshTarget{}, err
+	}
+	return newSSHTarget(kindBareMetal, server.Name, server.Description, server.SSHAccess)
+}
+
+// teamSSHTargets returns the SSH endpoints of every VM and bare metal server of
+// a team, skipping the ones without SSH access
+func teamSSHTargets(ctx context.Context, api *client.Client, team string) ([]sshTarget, error) {
+	vms, err := api.VirtualMachines().List(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := api.BareMetal().List(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []sshTarget
+	add := func(kind, name, description string, access *client.ExternalService) {
+		target, err := newSSHTarget(kind, name, description, access)
+		if err != nil {
+			slog.Debug("Skipping machine", "error", err)
+			return
+		}
+		targets = append(targets, target)
+	}
+	for _, vm := range vms {
+		add(kindVM, vm.Name, vm.Description, vm.SSHAccess)
+	}
+	for _, server := range servers {
+		add(kindBareMetal, server.Name, server.Description, server.SSHAccess)
+	}
+	return targets, nil
+}
+
+// sshFingerprint computes the SHA256 fingerprint of a public key in
+// authorized_keys format, as printed by ssh-keygen -l
+func sshFingerprint(authorizedKey string) (string, error) {
+	fields := strings.Fields(authorizedKey)
+	if len(fields) < 2 {
+		return "", errors.New("invalid public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// findIdentity returns the private key in ~/.ssh whose public key is
+// registered with the API, or "" if there is none
+func findIdentity(ctx context.Context, api *client.Client) (string, error) {
+	keys, err := api.User().GetSSHKeys(ctx)
+	if err != nil {
+		return "", err
+	}
+	registered := make([]string, len(keys))
+	for i, key := range keys {
+		registered[i] = key.Fingerprint
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	publicKeys, _ := filepath.Glob(filepath.Join(home, ".ssh", "*.pub"))
+	for _, publicKey := range publicKeys {
+		data, err := os.ReadFile(publicKey)
+		if err != nil {
+			continue
+		}
+		fingerprint, err := sshFingerprint(string(data))
+		if err != nil || !slices.Contains(registered, fingerprint) {
+			continue
+		}
+		privateKey := strings.TrimSuffix(publicKey, ".pub")
+		if _, err := os.Stat(privateKey); err == nil {
+			slog.Debug("Using SSH key", "file", privateKey, "fingerprint", fingerprint)
+			return privateKey, nil
+		}
+	}
+	return "", nil
+}
+
+// sshIdentity returns the --identity key, or the local key registered with the API
+func sshIdentity(app *App, ctx context.Context, cmd *cli.Command) (string, error) {
+	if identity := cmd.String("identity"); identity != "" {
+		return identity, nil
+	}
+	identity, err := findIdentity(ctx, app.Client.Api)
+	if err != nil {
+		return "", err
+	}
+	if identity == "" {
+		slog.Warn("None of the keys in ~/.ssh is registered, leaving the choice to ssh. Register one with `hotaisle user ssh-keys add`")
+	}
+	return identity, nil
+}
+
+// identityArgs are the ssh options to authenticate with only the given key
+func identityArgs(identity string) []string {
+	if identity == "" {
+		return nil
+	}
+	return []string{"-i", identity, "-o", "IdentitiesOnly=yes"}
+}
+
+// sshArgs are the arguments to ssh into a target, followed by the remote command if any
+func sshArgs(target sshTarget, user, identity string, command []string) []string {
+	args := identityArgs(identity)
+	args = append(args, "-p", strconv.FormatInt(target.Port, 10), user+"@"+target.Host)
+	return append(args, command...)
+}
+
+// scpURI is the scp URI of a path on a target. Relative paths are relative to
+// the home directory of the user.
+func scpURI(target sshTarget, user, path string) string {
+	return fmt.Sprintf("scp://%s@%s:%d/%s", user, target.Host, target.Port, path)
+}
+
+// scpArgs converts cp arguments to scp arguments, replacing NAME:PATH with the
+// scp URI of the machine. Arguments whose prefix is not a machine are local paths.
+func scpArgs(targets []sshTarget, user string, paths []string) ([]string, error) {
+	args := make([]string, len(paths))
+	remote := false
+	for i, path := range paths {
+		args[i] = path
+		name, remotePath, ok := strings.Cut(path, ":")
+		if !ok || name == "" {
+			continue
+		}
+		index := slices.IndexFunc(targets, func(t sshTarget) bool { return t.Name == name })
+		if index < 0 {
+			// Windows drive letters and relative paths are local
+			if len(name) == 1 || strings.ContainsAny(name, `/\`) {
+				continue
+			}
+			return nil, fmt.Errorf("no VM or bare metal server named %s with SSH access", name)
+		}
+		args[i] = scpURI(targets[index], user, remotePath)
+		remote = true
+	}
+	if !remote {
+		return nil, errors.New("no remote path, use NAME:PATH for a path on a VM or bare metal server")
+	}
+	return args, nil
+}
+
+// runExternal runs a program attached to the terminal. It is replaced in tests.
+var runExternal = func(ctx context.Context, name string, args ...string) error {
+	slog.Debug("Running", "command", name, "args", args)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// runSSH connects to a target with ssh, running the command after -- if any
+func runSSH(app *App, ctx context.Context, cmd *cli.Command, target sshTarget) error {
+	identity, err := sshIdentity(app, ctx, cmd)
+	if err != nil {
+		return err
+	}
+	return runExternal(ctx, "ssh", sshArgs(target, cmd.String("user"), identity, cmd.Args().Slice())...)
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/internal/api"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAuthorizedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl me@example.com"
+
+func TestSSHFingerprint(t *testing.T) {
+	fingerprint, err := sshFingerprint(testAuthorizedKey + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, "SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU", fingerprint)
+
+	_, err = sshFingerprint("ssh-ed25519")
+	assert.Error(t, err)
+	_, err = sshFingerprint("ssh-ed25519 !!!")
+	assert.Error(t, err)
+}
+
+// sshTestEnv is a fake API with a VM and a bare metal server in team ml, and
+// a registered key in ~/.ssh
+type sshTestEnv struct {
+	app    *App
+	home   string
+	vm     *client.VirtualMachineDetails
+	server *client.BareMetalServerReservationResponse
+	// ran records the programs run by the commands
+	ran [][]string
+}
+
+func newSSHTestEnv(t *testing.T, registerKey bool) *sshTestEnv {
+	app, home := setupTestApp(t)
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "ml", Name: "ML"}, 1_000_000_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	app.Client = api.NewClient(fake.Token(), "1.0.0", client.WithBaseURL(srv.URL+"/api"))
+
+	ctx := context.Background()
+	var req client.VMProvisionRequest
+	cores := uint64(2)
+	req.CPUCores = &cores
+	vm, err := app.Client.Api.VirtualMachines().Provision(ctx, "ml", req)
+	require.NoError(t, err)
+	server, err := app.Client.Api.BareMetal().Reserve(ctx, "ml", client.BareMetalServerReservation{
+		Specs: client.BareMetalServerSpecs{CPUCores: 104}, Description: "training",
+	})
+	require.NoError(t, err)
+
+	sshDir := filepath.Join(home, ".ssh")
+	require.NoError(t, os.MkdirAll(sshDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(sshDir, "id_other.pub"), []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGnQ other\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sshDir, "id_other"), []byte("private"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sshDir, "id_test.pub"), []byte(testAuthorizedKey+"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sshDir, "id_test"), []byte("private"), 0o600))
+	if registerKey {
+		_, err = app.Client.Api.User().AddSSHKey(ctx, client.SSHKeyRequest{AuthorizedKey: testAuthorizedKey})
+		require.NoError(t, err)
+	}
+
+	env := &sshTestEnv{app: app, home: home, vm: vm, server: server}
+	restore := runExternal
+	runExternal = func(ctx context.Context, name string, args ...string) error {
+		env.ran = append(env.ran, append([]string{name}, args...))
+		return nil
+	}
+	t.Cleanup(func() { runExternal = restore })
+	return env
+}
+
+// run runs a command with command line arguments, like the user would
+func (env *sshTestEnv) run(t *testing.T, def commandDef, path string, args ...string) error {
+	cmdDef := def.findCommand(path)
+	require.NotNil(t, cmdDef)
+	return buildCommand(env.app, *cmdDef).Run(context.Background(), append([]string{cmdDef.Name}, args...))
+}
+
+func (env *sshTestEnv) identity() string {
+	return filepath.Join(env.home, ".ssh", "id_test")
+}
+
+func TestVMSSH(t *testing.T) {
+	env := newSSHTestEnv(t, true)
+
+	err := env.run(t, virtualMachineCommands, "ssh", "--team", "ml", "--vm", env.vm.Name, "--", "nvidia-smi", "-L")
+	require.NoError(t, err)
+
+	port := strconv.FormatInt(env.vm.SSHAccess.Port, 10)
+	assert.Equal(t, [][]string{{
+		"ssh", "-i", env.identity(), "-o", "IdentitiesOnly=yes", "-p", port, "hotaisle@" + env.vm.SSHAccess.DNSName, "nvidia-smi", "-L",
+	}}, env.ran)
+}
+
+func TestVMSSH_NotFound(t *testing.T) {
+	env := newSSHTestEnv(t, true)
+
+	err := env.run(t, virtualMachineCommands, "ssh", "--team", "ml", "--vm", "missing")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.Empty(t, env.ran)
+}
+
+func TestBareMetalSSH(t *testing.T) {
+	env := newSSHTestEnv(t, false)
+
+	err := env.run(t, bareMetalCommands, "ssh", "--team", "ml", "--server", env.server.Name, "--user", "root")
+	require.NoError(t, err)
+
+	// Without a registered key, ssh picks the key
+	port := strconv.FormatInt(env.server.SSHAccess.Port, 10)
+	assert.Equal(t, [][]string{{"ssh", "-p", port, "root@" + env.server.SSHAccess.DNSName}}, env.ran)
+}
+
+func TestCopy(t *testing.T) {
+	env := newSSHTestEnv(t, true)
+
+	err := env.run(t, copyCommands, "cp", "--team", "ml", "--identity", "/keys/ml", "-r",
+		"data", "C:notes.txt", env.server.Name+":/srv/data")
+	require.NoError(t, err)
+
+	server := env.server.SSHAccess
+	assert.Equal(t, [][]string{{
+		"scp", "-i", "/keys/ml", "-o", "IdentitiesOnly=yes", "-r",
+		"data", "C:notes.txt", "scp://hotaisle@" + server.DNSName + ":" + strconv.FormatInt(server.Port, 10) + "//srv/data",
+	}}, env.ran)
+}
+
+func TestCopy_Errors(t *testing.T) {
+	env := newSSHTestEnv(t, true)
+
+	err := env.run(t, copyCommands, "cp", "--team", "ml", "a", "b")
+	assert.ErrorContains(t, err, "no remote path")
+	err = env.run(t, copyCommands, "cp", "--team", "ml", "a", "typo:b")
+	assert.ErrorContains(t, err, "no VM or bare metal server named typo")
+	err = env.run(t, copyCommands, "cp", "--team", "ml", env.vm.Name+":a")
+	assert.ErrorContains(t, err, "expected at least a source and a destination")
+	assert.Empty(t, env.ran)
+}
+
+func TestSSHConfigGenerate(t *testing.T) {
+	env := newSSHTestEnv(t, true)
+
+	err := env.run(t, sshConfigCommands, "generate", "--team", "ml")
+	require.NoError(t, err)
+
+	file := filepath.Join(env.home, ".ssh", "config.d", "hotaisle-ml")
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	vm, server := env.vm.SSHAccess, env.server.SSHAccess
+	want := "# Generated by `hotaisle ssh-config generate --team ml`, changes are overwritten.\n" +
+		"\n" +
+		"Host " + env.vm.Name + ".ml.hotaisle\n" +
+		"  HostName " + vm.DNSName + "\n" +
+		"  Port " + strconv.FormatInt(vm.Port, 10) + "\n" +
+		"  User hotaisle\n" +
+		"  IdentityFile \"" + env.identity() + "\"\n" +
+		"  IdentitiesOnly yes\n" +
+		"\n" +
+		"# training\n" +
+		"Host " + env.server.Name + ".ml.hotaisle\n" +
+		"  HostName " + server.DNSName + "\n" +
+		"  Port " + strconv.FormatInt(server.Port, 10) + "\n" +
+		"  User hotaisle\n" +
+		"  IdentityFile \"" + env.identity() + "\"\n" +
+		"  IdentitiesOnly yes\n"
+	assert.Equal(t, want, string(data))
+}
+
+func TestSSHConfigGenerate_Stdout(t *testing.T) {
+	env := newSSHTestEnv(t, false)
+
+	cmdDef := sshConfigCommands.findCommand("generate")
+	cmd := buildCommand(env.app, *cmdDef)
+	require.NoError(t, cmd.Set("team", "ml"))
+	require.NoError(t, cmd.Set("file", "-"))
+	output := executeCommand(t, cmd)
+
+	assert.Contains(t, output, "Host "+env.vm.Name+".ml.hotaisle\n")
+	assert.NotContains(t, output, "IdentityFile")
+	assert.NoDirExists(t, filepath.Join(env.home, ".ssh", "config.d"))
+}
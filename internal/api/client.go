@@ -1,7 +1,11 @@
 package api
 
 import (
+	"context"
+	"log/slog"
+
 	"hotaisle-cli/client"
+	"hotaisle-cli/internal/log"
 )
 
 type Client struct {
@@ -9,13 +13,17 @@ type Client struct {
 }
 
 func NewClient(token string, version string, opts ...client.Option) *Client {
-	// Prepare the default options
+	// Prepare the default options, the base URL defaults to client.DefaultBaseURL
 	defaultOpts := []client.Option{
-		client.WithBaseURL(client.DefaultBaseURL),
 		client.WithToken(token),
 		client.WithUserAgent("hotaisle/" + version),
 	}
 
+	// Log the requests when the configured level shows them
+	if logger := slog.Default(); logger.Enabled(context.Background(), log.LevelDebug) {
+		defaultOpts = append(defaultOpts, client.WithLogger(logger))
+	}
+
 	// Append any additional options (like WithHTTPClient for testing)
 	allOpts := append(defaultOpts, opts...)
 
@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
 
 	"hotaisle-cli/client"
+	"hotaisle-cli/internal/log"
 	"hotaisle-cli/test"
 
 	"github.com/stretchr/testify/assert"
@@ -144,3 +147,26 @@ func TestClient_TypeStructure(t *testing.T) {
 	// Verify that the Client struct has the expected field
 	assert.NotNil(t, c.Api, "expected Api field to exist and be accessible")
 }
+
+func TestNewClient_LogsRequestsAtDebugLevel(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	for _, level := range []slog.Level{log.LevelInfo, log.LevelDebug} {
+		var out strings.Builder
+		slog.SetDefault(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: level})))
+
+		c := NewClient("test-token", "1.0.0", client.WithHTTPClient(
+			test.NewMockClient(test.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return test.NewOkResponse(), nil
+			}))))
+		_, err := c.Api.User().Get(context.Background())
+		assert.NoError(t, err)
+
+		if level == log.LevelDebug {
+			assert.Contains(t, out.String(), `msg="HTTP request" method=GET path=/api/user/ status=200`)
+		} else {
+			assert.Empty(t, out.String())
+		}
+	}
+}
@@ -3,9 +3,11 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 const (
@@ -13,17 +15,98 @@ const (
 	File      string = "config.json"
 	Path      string = Directory + "/" + File
 	Pretty    string = "~/" + Path
+
+	// DefaultProfile is the profile created for new and migrated config files
+	DefaultProfile string = "default"
+	// DefaultLogLevel is used when a profile does not set a log level
+	DefaultLogLevel string = "info"
 )
 
+// Profile holds the settings of a single named profile
+type Profile struct {
+	ApiToken string `json:"api_token"`
+	// TokenRef points to the token in a credential store as backend:key, and
+	// is empty for a token kept in ApiToken
+	TokenRef string `json:"token_ref,omitempty"`
+	// LoginKey is the prefix of the API key minted by login, which logout deletes
+	LoginKey    string            `json:"login_key,omitempty"`
+	BaseURL     string            `json:"base_url,omitempty"`
+	DefaultTeam string            `json:"default_team"`
+	LogLevel    string            `json:"log_level,omitempty"`
+	Retries     *int              `json:"retries,omitempty"`
+	RetryUnsafe bool              `json:"retry_unsafe,omitempty"`
+	Budgets     map[string]Budget `json:"budgets,omitempty"`
+	// RequestTimeout is how long a request may take, its retries included, e.g. "60s"
+	RequestTimeout string `json:"request_timeout,omitempty"`
+	// HTTPSProxy is the URL of the proxy the requests are sent through
+	HTTPSProxy string `json:"https_proxy,omitempty"`
+	// CABundle is a PEM file of certificates trusted on top of the system ones
+	CABundle string `json:"ca_bundle,omitempty"`
+	// ClientCert and ClientKey are the PEM files of a certificate for mutual TLS
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+}
+
+// Budget limits what a team may spend on new VMs and bare metal servers. Zero
+// values are no limit.
+type Budget struct {
+	// MaxHourlyRate is the highest hourly rate of the team, in US cents
+	MaxHourlyRate int64 `json:"max_hourly_rate,omitempty"`
+	// MinRunout is the shortest time the balance must last, e.g. "72h"
+	MinRunout string `json:"min_runout,omitempty"`
+}
+
+// IsZero reports whether the budget sets no limit
+func (b Budget) IsZero() bool {
+	return b == Budget{}
+}
+
+// Config is the config file. The flat fields hold the settings of the profile in
+// use and are written back to that profile by Save.
 type Config struct {
-	LogLevel    string `json:"log_level,omitempty" default:"info"`
-	ApiToken    string `json:"api_token"`
-	DefaultTeam string `json:"default_team"`
+	LogLevel    string `json:"-"`
+	ApiToken    string `json:"-"`
+	TokenRef    string `json:"-"`
+	LoginKey    string `json:"-"`
+	BaseURL     string `json:"-"`
+	DefaultTeam string `json:"-"`
+	Retries     *int   `json:"-"`
+	RetryUnsafe bool   `json:"-"`
+	// Budgets maps team handles to their budget
+	Budgets map[string]Budget `json:"-"`
+	// The connection settings, see Profile
+	RequestTimeout string `json:"-"`
+	HTTPSProxy     string `json:"-"`
+	CABundle       string `json:"-"`
+	ClientCert     string `json:"-"`
+	ClientKey      string `json:"-"`
+
+	ActiveProfile string              `json:"active_profile"`
+	Profiles      map[string]*Profile `json:"profiles"`
+	// CredentialHelper is the command of the helper credential store
+	CredentialHelper string `json:"credential_helper,omitempty"`
+
+	// path is the file the config was loaded from, and is written back by Save
+	path string
+	// profile is the name of the profile the flat fields belong to
+	profile string
+	// storedToken is the token of the profile in use as read from its store
+	storedToken string
+	// tokenLoaded reports whether the token of the profile in use was read,
+	// which is only done when it is needed, see Token
+	tokenLoaded bool
+	// stores are the credential stores opened in this run
+	stores map[string]CredentialStore
 }
 
 func NewConfig() *Config {
 	return &Config{
-		LogLevel: "info",
+		LogLevel:      DefaultLogLevel,
+		ActiveProfile: DefaultProfile,
+		Profiles: map[string]*Profile{
+			DefaultProfile: {LogLevel: DefaultLogLevel},
+		},
+		profile: DefaultProfile,
 	}
 }
 
@@ -53,6 +136,8 @@ func Load(path *string) (*Config, error) {
 		path = &defaultPath
 	}
 
+	config.path = *path
+
 	slog.Debug("Loading config", "path", *path)
 	configData, err := os.ReadFile(*path)
 	if err != nil {
@@ -67,21 +152,226 @@ func Load(path *string) (*Config, error) {
 		slog.Debug("Failed to read config file", "path", *path, "error", err)
 		return nil, err
 	}
-	if err := json.Unmarshal(configData, &config); err != nil {
+	if err := parse(configData, config); err != nil {
 		slog.Debug("Failed to parse config", "path", *path, "error", err)
 		return nil, err
 	}
 	return config, nil
 }
 
+// parse decodes a config file into cfg, migrating the legacy single-profile
+// layout into the default profile, and selects the active profile.
+func parse(data []byte, cfg *Config) error {
+	cfg.Profiles = nil
+	cfg.ActiveProfile = ""
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+
+	if len(cfg.Profiles) == 0 {
+		// Files written before profiles existed keep the settings at the top level
+		var legacy Profile
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		slog.Debug("Migrating single-profile config", "profile", DefaultProfile)
+		cfg.Profiles = map[string]*Profile{DefaultProfile: &legacy}
+	}
+	for name, p := range cfg.Profiles {
+		if p == nil {
+			cfg.Profiles[name] = &Profile{}
+		}
+	}
+	if cfg.ActiveProfile == "" {
+		cfg.ActiveProfile = DefaultProfile
+	}
+
+	cfg.profile = ""
+	return cfg.UseProfile(cfg.ActiveProfile)
+}
+
+// ProfileName returns the name of the profile in use
+func (c *Config) ProfileName() string {
+	if c.profile == "" {
+		if c.ActiveProfile != "" {
+			return c.ActiveProfile
+		}
+		return DefaultProfile
+	}
+	return c.profile
+}
+
+// ProfileNames returns the names of all profiles, sorted
+func (c *Config) ProfileNames() []string {
+	c.syncProfile()
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile switches the flat settings to the named profile for this run. An
+// empty name selects the active profile.
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		name = c.ActiveProfile
+	}
+	if name == c.profile {
+		return nil
+	}
+	if c.profile != "" {
+		c.syncProfile()
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	c.TokenRef = p.TokenRef
+	c.LoginKey = p.LoginKey
+	c.BaseURL = p.BaseURL
+	c.DefaultTeam = p.DefaultTeam
+	c.LogLevel = p.LogLevel
+	c.Retries = p.Retries
+	c.RetryUnsafe = p.RetryUnsafe
+	c.Budgets = p.Budgets
+	c.RequestTimeout = p.RequestTimeout
+	c.HTTPSProxy = p.HTTPSProxy
+	c.CABundle = p.CABundle
+	c.ClientCert = p.ClientCert
+	c.ClientKey = p.ClientKey
+	if c.LogLevel == "" {
+		c.LogLevel = DefaultLogLevel
+	}
+	c.ApiToken = ""
+	c.storedToken = ""
+	c.tokenLoaded = false
+	c.profile = name
+	if c.TokenStore() == StorePlaintext {
+		// Reading a token from the config file asks for nothing
+		c.Token()
+	}
+	return nil
+}
+
+// Token returns the API token of the profile in use. A token kept outside of
+// the config file is read from its store the first time, so that a store
+// asking for a passphrase only does so for the commands that need the token.
+// A token set in ApiToken is used as is.
+func (c *Config) Token() string {
+	if !c.tokenLoaded && c.ApiToken == "" {
+		if p, ok := c.Profiles[c.ProfileName()]; ok {
+			c.ApiToken = c.loadToken(c.ProfileName(), p)
+			c.storedToken = c.ApiToken
+		}
+	}
+	c.tokenLoaded = true
+	return c.ApiToken
+}
+
+// SetActiveProfile makes the named profile the one used by default and switches to it
+func (c *Config) SetActiveProfile(name string) error {
+	if err := c.UseProfile(name); err != nil {
+		return err
+	}
+	c.ActiveProfile = name
+	return nil
+}
+
+// CreateProfile adds a new profile
+func (c *Config) CreateProfile(name string, profile Profile) error {
+	if name == "" {
+		return errors.New("missing profile name")
+	}
+	c.syncProfile()
+	if _, ok := c.Profiles[name]; ok {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	c.Profiles[name] = &profile
+	return nil
+}
+
+// DeleteProfile removes a profile and the token it keeps in a credential
+// store. The active profile and the profile in use cannot be deleted.
+func (c *Config) DeleteProfile(name string) error {
+	c.syncProfile()
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if name == c.ActiveProfile || name == c.ProfileName() {
+		return fmt.Errorf("profile %q is in use, switch to another profile first", name)
+	}
+	if backend, key := parseTokenRef(p.TokenRef, name); backend != StorePlaintext {
+		store, err := c.credentialStore(backend)
+		if err == nil {
+			err = store.Delete(key)
+		}
+		if err != nil {
+			slog.Warn("Could not delete the token of the profile", "profile", name, "store", backend, "error", err)
+		}
+	}
+	delete(c.Profiles, name)
+	return nil
+}
+
+// syncProfile copies the flat settings back into the profile they belong to
+func (c *Config) syncProfile() {
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	if c.ActiveProfile == "" {
+		c.ActiveProfile = DefaultProfile
+	}
+	name := c.ProfileName()
+	p := &Profile{
+		TokenRef:    c.TokenRef,
+		LoginKey:    c.LoginKey,
+		BaseURL:     c.BaseURL,
+		DefaultTeam: c.DefaultTeam,
+		LogLevel:    c.LogLevel,
+		Retries:     c.Retries,
+		RetryUnsafe: c.RetryUnsafe,
+		Budgets:     c.Budgets,
+
+		RequestTimeout: c.RequestTimeout,
+		HTTPSProxy:     c.HTTPSProxy,
+		CABundle:       c.CABundle,
+		ClientCert:     c.ClientCert,
+		ClientKey:      c.ClientKey,
+	}
+	if c.TokenRef == "" {
+		p.ApiToken = c.ApiToken
+	}
+	c.Profiles[name] = p
+	c.profile = name
+}
+
+// filePath returns the file the config was loaded from, or the default one
+func (c *Config) filePath() (string, error) {
+	if c.path != "" {
+		return c.path, nil
+	}
+	return defaultConfigPath()
+}
+
 func Save(cfg *Config) error {
 	if cfg == nil {
 		return errors.New("nil config")
 	}
-	path, err := defaultConfigPath()
+	path, err := cfg.filePath()
 	if err != nil {
 		return err
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	cfg.syncProfile()
+	if err := cfg.saveToken(); err != nil {
+		return err
+	}
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
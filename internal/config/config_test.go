@@ -92,6 +92,27 @@ func TestLoadCustomPath(t *testing.T) {
 	assert.Equal(t, "custom-team", cfg.DefaultTeam)
 }
 
+func TestSaveWritesToLoadedPath(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	customPath := filepath.Join(tmp, "custom", "config.json")
+
+	// A missing file gets the defaults written to it, not to the default path
+	cfg, err := Load(&customPath)
+	assert.Nil(t, err)
+	_, err = os.Stat(customPath)
+	assert.Nil(t, err)
+
+	cfg.DefaultTeam = "custom-team"
+	assert.Nil(t, Save(cfg))
+
+	cfg, err = Load(&customPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "custom-team", cfg.DefaultTeam)
+	_, err = os.Stat(filepath.Join(tmp, Path))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestLoadPartialConfig(t *testing.T) {
 	tmp := t.TempDir()
 	customPath := filepath.Join(tmp, "partial-config.json")
@@ -172,3 +193,176 @@ func TestLoadComplexConfig(t *testing.T) {
 	assert.Equal(t, "very-long-api-token-with-special-chars:!@#$%^&*()", cfg.ApiToken)
 	assert.Equal(t, "team_123/subteam", cfg.DefaultTeam)
 }
+
+func TestLoadMigratesLegacyConfig(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	dir := filepath.Join(tmp, ".hotaisle")
+	_ = os.MkdirAll(dir, 0o700)
+	path := filepath.Join(dir, "config.json")
+	legacy := `{"log_level": "warn", "api_token": "legacy-token", "default_team": "legacy-team"}`
+	err := os.WriteFile(path, []byte(legacy), 0o600)
+	assert.Nil(t, err)
+
+	cfg, err := Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultProfile, cfg.ProfileName())
+	assert.Equal(t, DefaultProfile, cfg.ActiveProfile)
+	assert.Equal(t, "legacy-token", cfg.ApiToken)
+	assert.Equal(t, "legacy-team", cfg.DefaultTeam)
+	assert.Equal(t, "warn", cfg.LogLevel)
+
+	err = Save(cfg)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"active_profile": "default",
+		"profiles": {
+			"default": {"api_token": "legacy-token", "default_team": "legacy-team", "log_level": "warn"}
+		}
+	}`, string(data))
+}
+
+func TestLoadProfiles(t *testing.T) {
+	tmp := t.TempDir()
+	customPath := filepath.Join(tmp, "profiles-config.json")
+	content := `{
+		"active_profile": "staging",
+		"profiles": {
+			"default": {"api_token": "personal-token", "default_team": "mine"},
+			"staging": {"api_token": "staging-token", "base_url": "https://staging.example.com/api", "log_level": "debug"}
+		}
+	}`
+	err := os.WriteFile(customPath, []byte(content), 0o600)
+	assert.Nil(t, err)
+
+	cfg, err := Load(&customPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "staging", cfg.ProfileName())
+	assert.Equal(t, "staging-token", cfg.ApiToken)
+	assert.Equal(t, "https://staging.example.com/api", cfg.BaseURL)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, []string{"default", "staging"}, cfg.ProfileNames())
+
+	err = cfg.UseProfile("default")
+	assert.Nil(t, err)
+	assert.Equal(t, "personal-token", cfg.ApiToken)
+	assert.Empty(t, cfg.BaseURL)
+	assert.Equal(t, "mine", cfg.DefaultTeam)
+	assert.Equal(t, "info", cfg.LogLevel)
+	// UseProfile only switches for this run
+	assert.Equal(t, "staging", cfg.ActiveProfile)
+}
+
+func TestConnectionSettingsAreSavedPerProfile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	assert.Nil(t, os.MkdirAll(filepath.Join(tmp, Directory), 0o700))
+	content := `{
+		"active_profile": "corp",
+		"profiles": {
+			"default": {},
+			"corp": {
+				"request_timeout": "90s",
+				"https_proxy": "http://proxy.corp.example:3128",
+				"ca_bundle": "/etc/corp/ca.pem",
+				"client_cert": "/etc/corp/client.crt",
+				"client_key": "/etc/corp/client.key"
+			}
+		}
+	}`
+	assert.Nil(t, os.WriteFile(filepath.Join(tmp, Path), []byte(content), 0o600))
+
+	cfg, err := Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "90s", cfg.RequestTimeout)
+	assert.Equal(t, "http://proxy.corp.example:3128", cfg.HTTPSProxy)
+	assert.Equal(t, "/etc/corp/ca.pem", cfg.CABundle)
+	assert.Equal(t, "/etc/corp/client.crt", cfg.ClientCert)
+	assert.Equal(t, "/etc/corp/client.key", cfg.ClientKey)
+
+	cfg.RequestTimeout = "5m"
+	assert.Nil(t, Save(cfg))
+	assert.Nil(t, cfg.UseProfile("default"))
+	assert.Empty(t, cfg.RequestTimeout)
+	assert.Empty(t, cfg.CABundle)
+
+	saved, err := Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "5m", saved.RequestTimeout)
+	assert.Equal(t, "/etc/corp/client.key", saved.Profiles["corp"].ClientKey)
+}
+
+func TestLoadUnknownActiveProfile(t *testing.T) {
+	tmp := t.TempDir()
+	customPath := filepath.Join(tmp, "bad-profile-config.json")
+	content := `{"active_profile": "missing", "profiles": {"default": {}}}`
+	err := os.WriteFile(customPath, []byte(content), 0o600)
+	assert.Nil(t, err)
+
+	cfg, err := Load(&customPath)
+	assert.Nil(t, cfg)
+	assert.NotNil(t, err)
+}
+
+func TestProfileChangesAreKeptWhenSwitching(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	cfg, err := Load(nil)
+	assert.Nil(t, err)
+
+	err = cfg.CreateProfile("ci", Profile{ApiToken: "ci-token"})
+	assert.Nil(t, err)
+	err = cfg.CreateProfile("ci", Profile{})
+	assert.NotNil(t, err)
+
+	cfg.DefaultTeam = "edited"
+	err = cfg.SetActiveProfile("ci")
+	assert.Nil(t, err)
+	assert.Equal(t, "ci-token", cfg.ApiToken)
+	assert.Equal(t, "edited", cfg.Profiles[DefaultProfile].DefaultTeam)
+
+	err = cfg.DeleteProfile("ci")
+	assert.NotNil(t, err)
+	err = cfg.DeleteProfile("missing")
+	assert.NotNil(t, err)
+
+	err = Save(cfg)
+	assert.Nil(t, err)
+
+	cfg, err = Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "ci", cfg.ProfileName())
+	assert.Equal(t, "edited", cfg.Profiles[DefaultProfile].DefaultTeam)
+
+	err = cfg.SetActiveProfile(DefaultProfile)
+	assert.Nil(t, err)
+	err = cfg.DeleteProfile("ci")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{DefaultProfile}, cfg.ProfileNames())
+}
+
+func TestBudgetsAreSavedPerProfile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	cfg, err := Load(nil)
+	assert.Nil(t, err)
+	cfg.Budgets = map[string]Budget{"ml": {MaxHourlyRate: 50_00, MinRunout: "72h"}}
+	err = Save(cfg)
+	assert.Nil(t, err)
+
+	cfg, err = Load(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Budget{MaxHourlyRate: 50_00, MinRunout: "72h"}, cfg.Budgets["ml"])
+
+	err = cfg.CreateProfile("ci", Profile{})
+	assert.Nil(t, err)
+	err = cfg.UseProfile("ci")
+	assert.Nil(t, err)
+	assert.True(t, cfg.Budgets["ml"].IsZero())
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Credential store backends, as used in token references and by SetTokenStore
+const (
+	// StorePlaintext keeps the token in the config file, which is the default
+	StorePlaintext string = "plaintext"
+	// StoreEncryptedFile keeps the tokens in a file encrypted with a passphrase
+	StoreEncryptedFile string = "encrypted-file"
+	// StoreHelper runs an external credential helper, like git credential helpers
+	StoreHelper string = "helper"
+	// StoreSecretService keeps the tokens in the desktop keyring over D-Bus
+	StoreSecretService string = "secret-service"
+)
+
+// StoreNames are the credential store backends, in the order they are documented
+var StoreNames = []string{StorePlaintext, StoreEncryptedFile, StoreHelper, StoreSecretService}
+
+// ErrCredentialNotFound is returned by a credential store that has no token under a key
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore keeps API tokens outside of the profiles, which then only
+// hold a reference to them. Tokens are stored under the name of their profile.
+type CredentialStore interface {
+	// Get returns the token stored under a key, or ErrCredentialNotFound
+	Get(key string) (string, error)
+	// Set stores a token under a key, replacing any previous one
+	Set(key, token string) error
+	// Delete removes the token stored under a key. Deleting a missing token is not an error.
+	Delete(key string) error
+}
+
+// Passphrase returns the passphrase of the encrypted credentials file. It may
+// be replaced to ask for it.
+var Passphrase = PassphraseFromEnv
+
+// PassphraseFromEnv returns the passphrase of the encrypted credentials file
+// from HOTAISLE_PASSPHRASE
+func PassphraseFromEnv() (string, error) {
+	if passphrase := os.Getenv("HOTAISLE_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", errors.New("missing passphrase for the encrypted credentials file, set HOTAISLE_PASSPHRASE")
+}
+
+// plaintextStore keeps the tokens in the profiles of the config file
+type plaintextStore struct {
+	profiles map[string]*Profile
+}
+
+func (s plaintextStore) Get(key string) (string, error) {
+	p, ok := s.profiles[key]
+	if !ok || p.ApiToken == "" {
+		return "", ErrCredentialNotFound
+	}
+	return p.ApiToken, nil
+}
+
+func (s plaintextStore) Set(key, token string) error {
+	p, ok := s.profiles[key]
+	if !ok {
+		return fmt.Errorf("profile %q does not exist", key)
+	}
+	p.ApiToken = token
+	return nil
+}
+
+func (s plaintextStore) Delete(key string) error {
+	if p, ok := s.profiles[key]; ok {
+		p.ApiToken = ""
+	}
+	return nil
+}
+
+// TokenStore returns the backend holding the token of the profile in use
+func (c *Config) TokenStore() string {
+	backend, _ := parseTokenRef(c.TokenRef, c.ProfileName())
+	return backend
+}
+
+// SetTokenStore moves the token of the profile in use to another backend, to
+// be written by Save
+func (c *Config) SetTokenStore(backend string) error {
+	name := c.ProfileName()
+	oldBackend, oldKey := parseTokenRef(c.TokenRef, name)
+	if backend == oldBackend {
+		return nil
+	}
+	c.syncProfile()
+	newStore, err := c.credentialStore(backend)
+	if err != nil {
+		return err
+	}
+	oldStore, err := c.credentialStore(oldBackend)
+	if err != nil {
+		return err
+	}
+
+	if token := c.Token(); token != "" {
+		if err := newStore.Set(name, token); err != nil {
+			return fmt.Errorf("failed to store the token in %s: %w", backend, err)
+		}
+	}
+	if err := oldStore.Delete(oldKey); err != nil {
+		slog.Warn("Could not delete the token from the previous store", "store", oldBackend, "error", err)
+	}
+	c.TokenRef = formatTokenRef(backend, name)
+	c.storedToken = c.ApiToken
+	return nil
+}
+
+// loadToken reads the token of a profile from the store its reference points to.
+// A store that cannot be read leaves the token empty, so that the settings of
+// the profile can still be changed.
+func (c *Config) loadToken(name string, p *Profile) string {
+	backend, key := parseTokenRef(p.TokenRef, name)
+	store, err := c.credentialStore(backend)
+	if err == nil {
+		var token string
+		token, err = store.Get(key)
+		if err == nil {
+			return token
+		}
+	}
+	if !errors.Is(err, ErrCredentialNotFound) {
+		slog.Warn("Could not read the API token", "profile", name, "store", backend, "error", err)
+	}
+	return ""
+}
+
+// saveToken writes the token of the profile in use to its store, if it changed
+func (c *Config) saveToken() error {
+	if c.ApiToken == c.storedToken {
+		return nil
+	}
+	backend, key := parseTokenRef(c.TokenRef, c.ProfileName())
+	store, err := c.credentialStore(backend)
+	if err != nil {
+		return err
+	}
+	if c.ApiToken == "" {
+		err = store.Delete(key)
+	} else {
+		err = store.Set(key, c.ApiToken)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store the token in %s: %w", backend, err)
+	}
+	c.storedToken = c.ApiToken
+	return nil
+}
+
+// credentialStore returns the store of a backend, reusing it within a run so
+// that a passphrase or an unlock is only asked for once
+func (c *Config) credentialStore(backend string) (CredentialStore, error) {
+	switch backend {
+	case StorePlaintext:
+		return plaintextStore{profiles: c.Profiles}, nil
+	case StoreHelper:
+		if c.CredentialHelper == "" {
+			return nil, errors.New("missing credential_helper in the config file")
+		}
+		return &HelperStore{Command: c.CredentialHelper, Host: apiHost(c.BaseURL)}, nil
+	}
+	if store, ok := c.stores[backend]; ok {
+		return store, nil
+	}
+
+	var store CredentialStore
+	switch backend {
+	case StoreEncryptedFile:
+		path, err := c.credentialsPath()
+		if err != nil {
+			return nil, err
+		}
+		store = &EncryptedFileStore{Path: path, Passphrase: Passphrase}
+	case StoreSecretService:
+		store = &SecretServiceStore{}
+	default:
+		return nil, fmt.Errorf("unknown credential store %q, must be one of %s", backend, strings.Join(StoreNames, ", "))
+	}
+	if c.stores == nil {
+		c.stores = map[string]CredentialStore{}
+	}
+	c.stores[backend] = store
+	return store, nil
+}
+
+// parseTokenRef splits a token reference of the form backend:key. Profiles
+// without a reference keep their token in plaintext.
+func parseTokenRef(ref, profile string) (backend, key string) {
+	if ref == "" {
+		return StorePlaintext, profile
+	}
+	backend, key, _ = strings.Cut(ref, ":")
+	if key == "" {
+		key = profile
+	}
+	return backend, key
+}
+
+// formatTokenRef returns the reference to the token of a profile in a backend
+func formatTokenRef(backend, profile string) string {
+	if backend == StorePlaintext {
+		return ""
+	}
+	return backend + ":" + profile
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CredentialsFile is the encrypted credentials file, next to the config file
+const CredentialsFile string = "credentials.enc"
+
+// credentialsIterations is the number of PBKDF2 iterations for new
+// credentials files, as recommended by OWASP for PBKDF2-HMAC-SHA256
+var credentialsIterations = 600_000
+
+// encryptedFile is the layout of the encrypted credentials file. Data is the
+// JSON map of keys to tokens, sealed with AES-256-GCM under a key derived from
+// the passphrase with PBKDF2-HMAC-SHA256.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// EncryptedFileStore keeps the tokens in a file encrypted with a passphrase
+type EncryptedFileStore struct {
+	// Path is the credentials file, created by the first Set
+	Path string
+	// Passphrase returns the passphrase, and is only called once
+	Passphrase func() (string, error)
+
+	passphrase string
+	// salt and key are the last derived key, which is slow to derive
+	salt []byte
+	key  []byte
+}
+
+// credentialsPath returns the credentials file next to the config file
+func (c *Config) credentialsPath() (string, error) {
+	path, err := c.filePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), CredentialsFile), nil
+}
+
+func (s *EncryptedFileStore) Get(key string) (string, error) {
+	tokens, _, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	token, ok := tokens[key]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return token, nil
+}
+
+func (s *EncryptedFileStore) Set(key, token string) error {
+	tokens, file, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = token
+	return s.write(tokens, file)
+}
+
+func (s *EncryptedFileStore) Delete(key string) error {
+	tokens, file, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+	delete(tokens, key)
+	return s.write(tokens, file)
+}
+
+// read decrypts the credentials file. A missing file has no tokens.
+func (s *EncryptedFileStore) read() (map[string]string, *encryptedFile, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file %s: %w", s.Path, err)
+	}
+	if file.Version != 1 {
+		return nil, nil, fmt.Errorf("unsupported credentials file version %d", file.Version)
+	}
+	aead, err := s.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("wrong passphrase for %s", s.Path)
+	}
+	tokens := map[string]string{}
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, nil, fmt.Errorf("invalid credentials file %s: %w", s.Path, err)
+	}
+	return tokens, &file, nil
+}
+
+// write encrypts the tokens into the credentials file, keeping the salt of an
+// existing file so that its key can be derived again
+func (s *EncryptedFileStore) write(tokens map[string]string, file *encryptedFile) error {
+	if file == nil {
+		file = &encryptedFile{Version: 1, Iterations: credentialsIterations, Salt: make([]byte, 16)}
+		if _, err := rand.Read(file.Salt); err != nil {
+			return err
+		}
+	}
+	aead, err := s.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0o600)
+}
+
+// cipher returns the cipher of a file, deriving its key from the passphrase
+func (s *EncryptedFileStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	if s.key == nil || !bytes.Equal(salt, s.salt) {
+		key, err := s.deriveKey(salt, iterations)
+		if err != nil {
+			return nil, err
+		}
+		s.salt, s.key = salt, key
+	}
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives a key from the passphrase, asking for it the first time
+func (s *EncryptedFileStore) deriveKey(salt []byte, iterations int) ([]byte, error) {
+	if s.passphrase == "" {
+		passphrase, err := s.Passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, errors.New("empty passphrase for the encrypted credentials file")
+		}
+		s.passphrase = passphrase
+	}
+	return pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, 32)
+}
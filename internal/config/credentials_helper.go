@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// defaultAPIHost is the host of the default API base URL, given to credential helpers
+const defaultAPIHost string = "admin.hotaisle.app"
+
+// HelperStore runs an external credential helper that speaks the git
+// credential helper protocol: the helper is run with get, store or erase, and
+// reads protocol, host, username and for store password attributes from
+// stdin. get prints the password attribute. A helper named without spaces or
+// slashes, like "pass", runs hotaisle-credential-pass, while any other command
+// is run by the shell with the action appended, like "git credential-store".
+type HelperStore struct {
+	// Command is the credential helper
+	Command string
+	// Host is the API host the tokens are for
+	Host string
+}
+
+func (s *HelperStore) Get(key string) (string, error) {
+	out, err := s.run("get", key, "")
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok && password != "" {
+			return password, nil
+		}
+	}
+	return "", ErrCredentialNotFound
+}
+
+func (s *HelperStore) Set(key, token string) error {
+	_, err := s.run("store", key, token)
+	return err
+}
+
+func (s *HelperStore) Delete(key string) error {
+	_, err := s.run("erase", key, "")
+	return err
+}
+
+// run runs the helper with an action, returning its output
+func (s *HelperStore) run(action, key, token string) ([]byte, error) {
+	var input strings.Builder
+	fmt.Fprintf(&input, "protocol=https\nhost=%s\nusername=%s\n", s.Host, key)
+	if token != "" {
+		fmt.Fprintf(&input, "password=%s\n", token)
+	}
+	input.WriteString("\n")
+
+	command := s.Command
+	if !strings.ContainsAny(command, ` /\`) {
+		command = "hotaisle-credential-" + command
+	}
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, command+" "+action)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %q %s failed: %w", s.Command, action, err)
+	}
+	return out, nil
+}
+
+// apiHost returns the host of an API base URL, given to credential helpers
+func apiHost(baseURL string) string {
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return defaultAPIHost
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName       = "org.freedesktop.secrets"
+	secretServicePath       = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretServiceCollection = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretServiceInterface  = "org.freedesktop.Secret.Service"
+	secretItemInterface     = "org.freedesktop.Secret.Item"
+	secretPromptInterface   = "org.freedesktop.Secret.Prompt"
+	// noPrompt is the prompt path returned when no prompt is needed
+	noPrompt = dbus.ObjectPath("/")
+)
+
+// secret is the Secret structure of the Secret Service API
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// SecretServiceStore keeps the tokens in the default collection of the
+// Secret Service, like GNOME Keyring or KWallet, over the D-Bus session bus.
+// Locked items are unlocked, which may show a prompt of the keyring.
+type SecretServiceStore struct{}
+
+// secretServiceSession is an open connection to the Secret Service
+type secretServiceSession struct {
+	conn    *dbus.Conn
+	service dbus.BusObject
+	path    dbus.ObjectPath
+}
+
+func (s *SecretServiceStore) Get(key string) (string, error) {
+	session, err := openSecretService()
+	if err != nil {
+		return "", err
+	}
+	defer session.close()
+
+	items, err := session.search(key)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrCredentialNotFound
+	}
+	var value secret
+	if err := session.conn.Object(secretServiceName, items[0]).Call(secretItemInterface+".GetSecret", 0, session.path).Store(&value); err != nil {
+		return "", fmt.Errorf("failed to read the secret: %w", err)
+	}
+	return string(value.Value), nil
+}
+
+func (s *SecretServiceStore) Set(key, token string) error {
+	session, err := openSecretService()
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	if err := session.unlock([]dbus.ObjectPath{secretServiceCollection}); err != nil {
+		return err
+	}
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("Hot Aisle API token (" + key + ")"),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(secretAttributes(key)),
+	}
+	value := secret{Session: session.path, Value: []byte(token), ContentType: "text/plain"}
+	var (
+		item   dbus.ObjectPath
+		prompt dbus.ObjectPath
+	)
+	err = session.conn.Object(secretServiceName, secretServiceCollection).
+		Call("org.freedesktop.Secret.Collection.CreateItem", 0, properties, value, true).Store(&item, &prompt)
+	if err != nil {
+		return fmt.Errorf("failed to store the secret: %w", err)
+	}
+	return session.prompt(prompt)
+}
+
+func (s *SecretServiceStore) Delete(key string) error {
+	session, err := openSecretService()
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	items, err := session.search(key)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := session.conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt); err != nil {
+			return fmt.Errorf("failed to delete the secret: %w", err)
+		}
+		if err := session.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretAttributes are the lookup attributes of the token of a profile
+func secretAttributes(key string) map[string]string {
+	return map[string]string{"application": "hotaisle", "profile": key}
+}
+
+// openSecretService connects to the session bus and opens a session with
+// plain transfer of secrets, which only travel over the local bus
+func openSecretService() (*secretServiceSession, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("the secret service is not available: %w", err)
+	}
+	session := &secretServiceSession{conn: conn, service: conn.Object(secretServiceName, secretServicePath)}
+	var output dbus.Variant
+	if err := session.service.Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session.path); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("the secret service is not available: %w", err)
+	}
+	return session, nil
+}
+
+func (s *secretServiceSession) close() {
+	_ = s.conn.Object(secretServiceName, s.path).Call("org.freedesktop.Secret.Session.Close", 0).Err
+	_ = s.conn.Close()
+}
+
+// search returns the items holding the token of a profile, unlocking them if needed
+func (s *secretServiceSession) search(key string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := s.service.Call(secretServiceInterface+".SearchItems", 0, secretAttributes(key)).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("failed to search the secret service: %w", err)
+	}
+	if len(locked) > 0 {
+		if err := s.unlock(locked); err != nil {
+			return nil, err
+		}
+	}
+	return append(unlocked, locked...), nil
+}
+
+// unlock unlocks collections or items, prompting if the keyring asks to
+func (s *secretServiceSession) unlock(objects []dbus.ObjectPath) error {
+	var (
+		unlocked []dbus.ObjectPath
+		prompt   dbus.ObjectPath
+	)
+	if err := s.service.Call(secretServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock the secret service: %w", err)
+	}
+	return s.prompt(prompt)
+}
+
+// prompt shows a prompt of the keyring and waits for it to complete
+func (s *secretServiceSession) prompt(path dbus.ObjectPath) error {
+	if path == noPrompt || path == "" {
+		return nil
+	}
+	if err := s.conn.AddMatchSignal(dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(secretPromptInterface)); err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 1)
+	s.conn.Signal(signals)
+	defer s.conn.RemoveSignal(signals)
+
+	if err := s.conn.Object(secretServiceName, path).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("failed to prompt: %w", err)
+	}
+	for signal := range signals {
+		if signal.Path != path || signal.Name != secretPromptInterface+".Completed" || len(signal.Body) == 0 {
+			continue
+		}
+		if dismissed, _ := signal.Body[0].(bool); dismissed {
+			return errors.New("the secret service prompt was dismissed")
+		}
+		return nil
+	}
+	return errors.New("the secret service connection was closed")
+}
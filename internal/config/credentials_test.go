@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	credentialsIterations = 1000
+	t.Cleanup(func() { credentialsIterations = 600_000 })
+	path := filepath.Join(t.TempDir(), CredentialsFile)
+	passphrase := func(p string) func() (string, error) {
+		return func() (string, error) { return p, nil }
+	}
+
+	store := &EncryptedFileStore{Path: path, Passphrase: passphrase("correct horse")}
+	_, err := store.Get("default")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+	require.NoError(t, store.Set("default", "abc.secret"))
+	require.NoError(t, store.Set("ci", "def.secret"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	store = &EncryptedFileStore{Path: path, Passphrase: passphrase("correct horse")}
+	token, err := store.Get("default")
+	require.NoError(t, err)
+	assert.Equal(t, "abc.secret", token)
+	require.NoError(t, store.Delete("ci"))
+	require.NoError(t, store.Delete("ci"))
+	_, err = store.Get("ci")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	store = &EncryptedFileStore{Path: path, Passphrase: passphrase("wrong")}
+	_, err = store.Get("default")
+	assert.EqualError(t, err, "wrong passphrase for "+path)
+}
+
+func TestHelperStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell script")
+	}
+	dir := t.TempDir()
+	// The helper keeps the last stored input in a file, like a tiny credential-store
+	helper := filepath.Join(dir, "hotaisle-credential-test")
+	script := `#!/bin/sh
+case "$1" in
+get) cat "$0.db" 2>/dev/null || true ;;
+store) cat > "$0.db" ;;
+erase) rm -f "$0.db" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(helper, []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	store := &HelperStore{Command: "test", Host: "api.example.com"}
+	_, err := store.Get("default")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	require.NoError(t, store.Set("default", "abc.secret"))
+	data, err := os.ReadFile(helper + ".db")
+	require.NoError(t, err)
+	assert.Equal(t, "protocol=https\nhost=api.example.com\nusername=default\npassword=abc.secret\n\n", string(data))
+
+	token, err := store.Get("default")
+	require.NoError(t, err)
+	assert.Equal(t, "abc.secret", token)
+
+	require.NoError(t, store.Delete("default"))
+	_, err = store.Get("default")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	store = &HelperStore{Command: filepath.Join(dir, "missing")}
+	assert.ErrorContains(t, store.Set("default", "abc.secret"), "credential helper")
+}
+
+func TestSecretServiceStore_Unavailable(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "missing"))
+
+	_, err := (&SecretServiceStore{}).Get("default")
+	assert.ErrorContains(t, err, "the secret service is not available")
+}
+
+func TestTokenRefInConfigFile(t *testing.T) {
+	credentialsIterations = 1000
+	t.Cleanup(func() { credentialsIterations = 600_000 })
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("HOTAISLE_PASSPHRASE", "correct horse")
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	cfg.ApiToken = "abc.secret"
+	require.NoError(t, cfg.SetTokenStore(StoreEncryptedFile))
+	require.NoError(t, cfg.CreateProfile("ci", Profile{ApiToken: "ci.token"}))
+	require.NoError(t, Save(cfg))
+
+	data, err := os.ReadFile(filepath.Join(tmp, Path))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "abc.secret")
+	assert.Contains(t, string(data), "ci.token", "other profiles keep their store")
+
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc.secret", cfg.Token())
+	assert.Equal(t, "encrypted-file:default", cfg.TokenRef)
+
+	// A changed token is written to the store
+	cfg.ApiToken = "abc.rotated"
+	require.NoError(t, Save(cfg))
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc.rotated", cfg.Token())
+
+	// A store that cannot be read leaves the token empty, and does not lose it
+	t.Setenv("HOTAISLE_PASSPHRASE", "wrong")
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Token())
+	cfg.DefaultTeam = "ml"
+	require.NoError(t, Save(cfg))
+	t.Setenv("HOTAISLE_PASSPHRASE", "correct horse")
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc.rotated", cfg.Token())
+	assert.Equal(t, "ml", cfg.DefaultTeam)
+}
+
+func TestTokenIsReadWhenNeeded(t *testing.T) {
+	credentialsIterations = 1000
+	t.Cleanup(func() { credentialsIterations = 600_000 })
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	asked := 0
+	passphrase := Passphrase
+	Passphrase = func() (string, error) {
+		asked++
+		return "correct horse", nil
+	}
+	t.Cleanup(func() { Passphrase = passphrase })
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	cfg.ApiToken = "abc.secret"
+	require.NoError(t, cfg.SetTokenStore(StoreEncryptedFile))
+	require.NoError(t, Save(cfg))
+	asked = 0
+
+	// Settings are read and changed without the token
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, cfg.ProfileNames())
+	cfg.DefaultTeam = "ml"
+	require.NoError(t, Save(cfg))
+	assert.Equal(t, 0, asked)
+
+	assert.Equal(t, "abc.secret", cfg.Token())
+	assert.Equal(t, 1, asked)
+}
+
+func TestDeleteProfileDeletesItsToken(t *testing.T) {
+	credentialsIterations = 1000
+	t.Cleanup(func() { credentialsIterations = 600_000 })
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("HOTAISLE_PASSPHRASE", "correct horse")
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	require.NoError(t, cfg.CreateProfile("ci", Profile{}))
+	require.NoError(t, cfg.UseProfile("ci"))
+	cfg.ApiToken = "ci.token"
+	require.NoError(t, cfg.SetTokenStore(StoreEncryptedFile))
+	require.NoError(t, Save(cfg))
+
+	require.NoError(t, cfg.UseProfile(DefaultProfile))
+	require.NoError(t, cfg.DeleteProfile("ci"))
+	require.NoError(t, Save(cfg))
+
+	store := &EncryptedFileStore{Path: filepath.Join(tmp, Directory, CredentialsFile), Passphrase: PassphraseFromEnv}
+	_, err = store.Get("ci")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	// A new profile of the same name does not get the old token
+	require.NoError(t, cfg.CreateProfile("ci", Profile{TokenRef: "encrypted-file:ci"}))
+	require.NoError(t, cfg.UseProfile("ci"))
+	assert.Empty(t, cfg.Token())
+}
+
+func TestParseTokenRef(t *testing.T) {
+	tests := []struct {
+		ref, backend, key string
+	}{
+		{"", StorePlaintext, "default"},
+		{"secret-service:work", StoreSecretService, "work"},
+		{"helper", StoreHelper, "default"},
+	}
+	for _, tt := range tests {
+		backend, key := parseTokenRef(tt.ref, "default")
+		assert.Equal(t, tt.backend, backend, tt.ref)
+		assert.Equal(t, tt.key, key, tt.ref)
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// header marks the generated files, which the drift test compares with swagger.json
+const header = "// Code generated by clientgen from swagger.json. DO NOT EDIT.\n\n"
+
+// modelsFile is the file of the definitions of swagger.json
+const modelsFile = "models.go"
+
+// permissionsFile is the file of the x-requires-permissions of the operations
+const permissionsFile = "permissions.go"
+
+var httpMethods = map[string]string{
+	"GET":    "http.MethodGet",
+	"POST":   "http.MethodPost",
+	"PUT":    "http.MethodPut",
+	"PATCH":  "http.MethodPatch",
+	"DELETE": "http.MethodDelete",
+}
+
+// generate returns the generated files of the client package by name
+func generate(specPath string) (map[string][]byte, error) {
+	s, err := loadSpec(specPath)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	if files[modelsFile], err = generateModels(s); err != nil {
+		return nil, err
+	}
+
+	ops, err := s.operations()
+	if err != nil {
+		return nil, err
+	}
+	if files[permissionsFile], err = generatePermissions(ops); err != nil {
+		return nil, err
+	}
+	for _, svc := range services {
+		if files[svc.File], err = generateService(svc, ops); err != nil {
+			return nil, err
+		}
+		for _, m := range svc.Methods {
+			delete(ops, m.OperationID)
+		}
+	}
+	if len(ops) > 0 {
+		missing := make([]string, 0, len(ops))
+		for id, op := range ops {
+			missing = append(missing, fmt.Sprintf("%s (%s %s)", id, op.Method, op.Path))
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("operations without a method in services: %s", strings.Join(missing, ", "))
+	}
+	return files, nil
+}
+
+func generateModels(s *spec) ([]byte, error) {
+	var b strings.Builder
+	for _, name := range sortedKeys(s.Definitions) {
+		if err := writeModel(&b, name, s.Definitions[name]); err != nil {
+			return nil, fmt.Errorf("definition %s: %w", name, err)
+		}
+	}
+	imports := []string{}
+	if strings.Contains(b.String(), "time.Time") {
+		imports = append(imports, "time")
+	}
+	return formatFile(imports, b.String())
+}
+
+func writeModel(b *strings.Builder, name string, d *schema) error {
+	writeDoc(b, "", typeDoc(name, d.Description))
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	parts := d.AllOf
+	if len(parts) == 0 {
+		parts = []*schema{d}
+	}
+	for _, part := range parts {
+		if part.Ref != "" {
+			fmt.Fprintf(b, "\t%s\n", refName(part.Ref))
+			continue
+		}
+		if part.Type != "object" {
+			return fmt.Errorf("unsupported schema of type %q", part.Type)
+		}
+		for _, prop := range propertyOrder(part) {
+			if err := writeField(b, name, prop, part.Properties[prop], slices.Contains(part.Required, prop)); err != nil {
+				return fmt.Errorf("property %s: %w", prop, err)
+			}
+		}
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
+
+func writeField(b *strings.Builder, model, prop string, p *schema, required bool) error {
+	typ, ok := fieldTypes[model+"."+prop]
+	if !ok {
+		var err error
+		if typ, err = goType(p); err != nil {
+			return err
+		}
+		// Optional structs and times are pointers, so that they can be left out
+		if !required && (p.Ref != "" || typ == "time.Time") {
+			typ = "*" + typ
+		}
+	}
+	tag := prop
+	if !required || strings.HasPrefix(typ, "*") {
+		tag += ",omitempty"
+	}
+	name := p.GoName
+	if name == "" {
+		name = goName(prop)
+	}
+	writeDoc(b, "\t", p.Description)
+	fmt.Fprintf(b, "\t%s %s `json:%q`\n", name, typ, tag)
+	return nil
+}
+
+// propertyOrder returns the properties of a schema in the order of their
+// fields. The required list keeps the order of the fields of the API, and the
+// optional properties follow in the order of the document, which is sorted.
+func propertyOrder(s *schema) []string {
+	var props []string
+	for _, prop := range s.Required {
+		if _, ok := s.Properties[prop]; ok {
+			props = append(props, prop)
+		}
+	}
+	for _, prop := range sortedKeys(s.Properties) {
+		if !slices.Contains(props, prop) {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// goType returns the Go type of a schema
+func goType(p *schema) (string, error) {
+	if p.Ref != "" {
+		return refName(p.Ref), nil
+	}
+	switch p.Type {
+	case "string":
+		if p.Format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if p.Format == "uint64" {
+			return "uint64", nil
+		}
+		return "int64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if p.Items == nil {
+			return "", fmt.Errorf("array without items")
+		}
+		item, err := goType(p.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	}
+	return "", fmt.Errorf("unsupported type %q", p.Type)
+}
+
+func generatePermissions(ops map[string]*operation) ([]byte, error) {
+	sorted := make([]*operation, 0, len(ops))
+	for _, op := range ops {
+		if op.Permissions != "" {
+			sorted = append(sorted, op)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	var b strings.Builder
+	b.WriteString("// endpoint identifies an API operation by method and path template\ntype endpoint struct {\n\tMethod string\n\tPath   string\n}\n\n")
+	b.WriteString("// requiredPermissions holds the x-requires-permissions of every operation in swagger.json\nvar requiredPermissions = map[endpoint]string{\n")
+	for _, op := range sorted {
+		fmt.Fprintf(&b, "\t{%q, %q}: %q,\n", op.Method, op.Path, op.Permissions)
+	}
+	b.WriteString("}\n")
+	return formatFile(nil, b.String())
+}
+
+func generateService(svc service, ops map[string]*operation) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s\ntype %s struct {\n\tclient *Client\n}\n\n", svc.Type, svc.Doc, svc.Type)
+	fmt.Fprintf(&b, "// %s returns a new %s\nfunc (c *Client) %s() *%s {\n\treturn &%s{client: c}\n}\n\n",
+		svc.Accessor, svc.Type, svc.Accessor, svc.Type, svc.Type)
+
+	imports := []string{"context"}
+	for _, m := range svc.Methods {
+		op, ok := ops[m.OperationID]
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: operation %s is not in swagger.json", svc.Type, m.Name, m.OperationID)
+		}
+		if len(op.Tags) == 0 || op.Tags[0] != svc.Tag {
+			return nil, fmt.Errorf("%s.%s: operation %s is not tagged %s", svc.Type, m.Name, m.OperationID, svc.Tag)
+		}
+		uses, err := writeMethod(&b, svc, m, op, m.QueryName == "")
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", svc.Type, m.Name, err)
+		}
+		if m.QueryName != "" {
+			withQuery := m
+			withQuery.Name = m.QueryName
+			more, err := writeMethod(&b, svc, withQuery, op, true)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", svc.Type, m.QueryName, err)
+			}
+			uses = append(uses, more...)
+		}
+		for _, pkg := range uses {
+			if !slices.Contains(imports, pkg) {
+				imports = append(imports, pkg)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return formatFile(imports, b.String())
+}
+
+// writeMethod writes the method of an operation, and returns the packages it
+// uses. Without query, the method takes and sends no query parameters.
+func writeMethod(b *strings.Builder, svc service, m method, op *operation, query bool) ([]string, error) {
+	var (
+		pathParams, queryParams []parameter
+		body                    *parameter
+	)
+	for i, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			if query {
+				queryParams = append(queryParams, p)
+			}
+		case "body":
+			body = &op.Parameters[i]
+		default:
+			return nil, fmt.Errorf("unsupported parameter %s in %s", p.Name, p.In)
+		}
+	}
+	// Parameters follow their order in the path
+	sort.SliceStable(pathParams, func(i, j int) bool {
+		return strings.Index(op.Path, "{"+pathParams[i].Name+"}") < strings.Index(op.Path, "{"+pathParams[j].Name+"}")
+	})
+
+	var args []string
+	for i, p := range pathParams {
+		arg := paramName(p.Name)
+		if i == len(pathParams)-1 {
+			arg += " string"
+		}
+		args = append(args, arg)
+	}
+	var bodyArg string
+	if body != nil {
+		if body.Schema == nil || body.Schema.Ref == "" {
+			return nil, fmt.Errorf("unsupported body of parameter %s", body.Name)
+		}
+		typ := refName(body.Schema.Ref)
+		bodyArg = "req"
+		if strings.HasSuffix(typ, "Update") {
+			bodyArg = "update"
+		}
+		args = append(args, bodyArg+" "+typ)
+	}
+	for _, p := range queryParams {
+		typ, err := goType(&schema{Type: p.Type})
+		if err != nil {
+			return nil, fmt.Errorf("query parameter %s: %w", p.Name, err)
+		}
+		args = append(args, paramName(p.Name)+" "+typ)
+	}
+
+	uses := []string{}
+	results := "error"
+	var result string
+	if m.WebSocket {
+		results = "(io.ReadWriteCloser, error)"
+		uses = append(uses, "io")
+	} else {
+		var err error
+		if result, err = resultType(op); err != nil {
+			return nil, err
+		}
+		if result != "" {
+			results = "(" + result + ", error)"
+		}
+		uses = append(uses, "net/http")
+	}
+
+	doc := m.Doc
+	if doc == "" {
+		doc = lowerFirst(strings.TrimSpace(strings.Split(op.Description, "\n---")[0]))
+	}
+	doc = m.Name + " " + doc
+	if m.WebSocket {
+		doc += "\n\nReads return what the server sends and writes are sent to it."
+	}
+	if !query && m.QueryName != "" {
+		doc += "\n\nUse " + m.QueryName + " to send the query parameters."
+	}
+	if op.Permissions != "" {
+		doc += "\n\nRequires " + op.Permissions
+	}
+	writeDoc(b, "", doc)
+	fmt.Fprintf(b, "func (s *%s) %s(%s) %s {\n", svc.Type, m.Name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), results)
+
+	path := fmt.Sprintf("%q", op.Path)
+	if len(pathParams) > 0 {
+		fmt.Fprintf(b, "\tpath := buildPath(%q, map[string]string{\n", op.Path)
+		for _, p := range pathParams {
+			fmt.Fprintf(b, "\t\t%q: %s,\n", p.Name, paramName(p.Name))
+		}
+		b.WriteString("\t})\n")
+		path = "path"
+	}
+	if len(queryParams) > 0 {
+		b.WriteString("\tquery := url.Values{}\n")
+		for _, p := range queryParams {
+			name := paramName(p.Name)
+			switch p.Type {
+			case "boolean":
+				fmt.Fprintf(b, "\tif %s {\n\t\tquery.Set(%q, \"true\")\n\t}\n", name, p.Name)
+			case "string":
+				fmt.Fprintf(b, "\tif %s != \"\" {\n\t\tquery.Set(%q, %s)\n\t}\n", name, p.Name, name)
+			case "integer":
+				fmt.Fprintf(b, "\tif %s != 0 {\n\t\tquery.Set(%q, strconv.FormatInt(%s, 10))\n\t}\n", name, p.Name, name)
+				uses = append(uses, "strconv")
+			default:
+				return nil, fmt.Errorf("unsupported query parameter %s of type %q", p.Name, p.Type)
+			}
+		}
+		path = "withQuery(" + path + ", query)"
+		uses = append(uses, "net/url")
+	}
+
+	if m.WebSocket {
+		fmt.Fprintf(b, "\treturn s.client.dialWebSocket(ctx, %s)\n}\n\n", path)
+		return uses, nil
+	}
+	bodyExpr := "nil"
+	if body != nil {
+		bodyExpr = bodyArg
+	}
+	method := httpMethods[op.Method]
+	switch {
+	case result == "":
+		fmt.Fprintf(b, "\treturn s.client.doRequest(ctx, %s, %s, %s, nil)\n", method, path, bodyExpr)
+	case strings.HasPrefix(result, "[]"):
+		fmt.Fprintf(b, "\tvar result %s\n\terr := s.client.doRequest(ctx, %s, %s, %s, &result)\n\treturn result, err\n", result, method, path, bodyExpr)
+	default:
+		fmt.Fprintf(b, "\tvar result %s\n\terr := s.client.doRequest(ctx, %s, %s, %s, &result)\n\treturn &result, err\n", strings.TrimPrefix(result, "*"), method, path, bodyExpr)
+	}
+	b.WriteString("}\n\n")
+	return uses, nil
+}
+
+// resultType returns the Go type of the response of an operation, from the
+// first successful response with a body, or "" if there is none
+func resultType(op *operation) (string, error) {
+	for _, code := range sortedKeys(op.Responses) {
+		r := op.Responses[code]
+		if !strings.HasPrefix(code, "2") || r.Schema == nil {
+			continue
+		}
+		typ, err := goType(r.Schema)
+		if err != nil {
+			return "", fmt.Errorf("response %s: %w", code, err)
+		}
+		if r.Schema.Ref != "" {
+			typ = "*" + typ
+		}
+		return typ, nil
+	}
+	return "", nil
+}
+
+// identifier matches a Go type name at the start of a description
+var identifier = regexp.MustCompile(`^[A-Z][a-z]+[A-Z]\w*\b`)
+
+// typeDoc returns the doc comment of a definition. Some descriptions start
+// with another name than their definition, which is replaced.
+func typeDoc(name, description string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return name + " is the " + name + " definition of swagger.json"
+	}
+	return identifier.ReplaceAllLiteralString(description, name)
+}
+
+// writeDoc writes text as a comment, indented by indent
+func writeDoc(b *strings.Builder, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// goName returns the Go name of a property, like SSHAccess for ssh_access
+func goName(prop string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(prop, func(r rune) bool { return r == '_' || r == '-' }) {
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// paramName returns the Go name of a parameter
+func paramName(name string) string {
+	if n, ok := paramNames[name]; ok {
+		return n
+	}
+	return lowerFirst(goName(name))
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func formatFile(imports []string, body string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("package client\n\n")
+	if len(imports) == 1 {
+		fmt.Fprintf(&b, "import %q\n\n", imports[0])
+	} else if len(imports) > 1 {
+		b.WriteString("import (\n")
+		for _, pkg := range imports {
+			fmt.Fprintf(&b, "\t%q\n", pkg)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body)
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated code: %w", err)
+	}
+	return src, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,30 @@
+// Command clientgen generates the models and services of the client package
+// from swagger.json. It is run by go generate in the client package:
+//
+//	go generate ./client
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "swagger.json", "Swagger 2.0 document of the API")
+	outDir := flag.String("out", ".", "Directory of the client package")
+	flag.Parse()
+
+	files, err := generate(*specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "clientgen: %v\n", err)
+		os.Exit(1)
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(*outDir, name), data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "clientgen: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clientDir = "../../../client"
+
+// TestGeneratedClientIsUpToDate fails when the client package drifts from
+// swagger.json, either because swagger.json was updated without running go
+// generate ./client, or because a generated file was edited by hand
+func TestGeneratedClientIsUpToDate(t *testing.T) {
+	files, err := generate("../../../swagger.json")
+	require.NoError(t, err)
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(clientDir, name))
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(want, got), "client/%s is out of date, run go generate ./client", name)
+	}
+
+	// Files that are no longer generated must be removed
+	paths, err := filepath.Glob(filepath.Join(clientDir, "*.go"))
+	require.NoError(t, err)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		if _, ok := files[filepath.Base(path)]; !ok && bytes.HasPrefix(data, []byte(header)) {
+			t.Errorf("client/%s is no longer generated, remove it", filepath.Base(path))
+		}
+	}
+}
+
+// TestGenerateNewOperation checks that new endpoints must be named in services
+func TestGenerateNewOperation(t *testing.T) {
+	data, err := os.ReadFile("../../../swagger.json")
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	doc["paths"].(map[string]any)["/user/sessions/"] = map[string]any{
+		"get": map[string]any{"operationId": "getSessionsReq", "tags": []string{"user"}},
+	}
+	data, err = json.Marshal(doc)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err = generate(path)
+	assert.EqualError(t, err, "operations without a method in services: getSessionsReq (GET /user/sessions/)")
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"ssh_access":     "SSHAccess",
+		"os_status":      "OSStatus",
+		"cpus":           "CPUs",
+		"api_key_prefix": "APIKeyPrefix",
+		"Quantity":       "Quantity",
+		"user-data":      "UserData",
+	}
+	for prop, want := range tests {
+		assert.Equal(t, want, goName(prop), prop)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+// service is a service of the client, generated from the operations of a tag
+type service struct {
+	Tag      string
+	Type     string
+	Accessor string
+	File     string
+	Doc      string
+	Methods  []method
+}
+
+// method names an operation of swagger.json in the client. The operationIds
+// are not Go names, so every operation must be listed here, and the generator
+// fails on operations it does not know about.
+type method struct {
+	OperationID string
+	Name        string
+	// Doc replaces the description of the operation, after the method name
+	Doc string
+	// WebSocket operations are upgraded to a WebSocket connection
+	WebSocket bool
+	// QueryName is set for operations whose query parameters were added to
+	// swagger.json after the method. The method of Name then keeps its
+	// signature and sends no query parameters, and the method of QueryName
+	// takes them.
+	QueryName string
+}
+
+var services = []service{
+	{
+		Tag: "teams", Type: "TeamsService", Accessor: "Teams", File: "teams_service.go",
+		Doc: "handles team-related API operations",
+		Methods: []method{
+			{OperationID: "getUserTeamsReq", Name: "List"},
+			{OperationID: "createTeamReq", Name: "Create"},
+			{OperationID: "getUserTeamDetailReq", Name: "Get"},
+			{OperationID: "updateTeamReq", Name: "Update"},
+			{OperationID: "getUserTeamsInvitationsReq", Name: "GetInvitations"},
+			{OperationID: "acceptTeamInvitationReq", Name: "AcceptInvitation", Doc: "accepts a pending invitation to join a team."},
+			{OperationID: "getTeamBalanceReq", Name: "GetBalance"},
+			{OperationID: "purchaseTeamCredits", Name: "PurchaseCredits"},
+			{OperationID: "getTeamInvitationsReq", Name: "GetTeamInvitations"},
+			{OperationID: "createTeamInvitationReq", Name: "InviteMember"},
+			{OperationID: "updateTeamMemberReq", Name: "UpdateMember"},
+			{OperationID: "removeTeamMemberReq", Name: "RemoveMember"},
+		},
+	},
+	{
+		Tag: "bare_metal", Type: "BareMetalService", Accessor: "BareMetal", File: "bare_metal_service.go",
+		Doc: "handles bare metal server-related API operations",
+		Methods: []method{
+			{OperationID: "getBaremetalServersReq", Name: "List"},
+			{OperationID: "getBaremetalServerReq", Name: "Get"},
+			{OperationID: "reserveBaremetalServerReq", Name: "Reserve"},
+			{OperationID: "updateBaremetalServerReq", Name: "Update"},
+			{OperationID: "deleteBaremetalServerReq", Name: "Delete", QueryName: "DeleteWithForce"},
+			{OperationID: "getAvailableBaremetalServersReq", Name: "GetAvailable"},
+			{OperationID: "getServerPowerReq", Name: "GetPowerState"},
+			{OperationID: "serverPowerOnReq", Name: "PowerOn"},
+			{OperationID: "serverGracefulShutdownReq", Name: "GracefulShutdown"},
+			{OperationID: "serverForceShutdownReq", Name: "ForceShutdown"},
+			{OperationID: "serverWarmRebootReq", Name: "WarmReboot"},
+			{OperationID: "serverColdRebootReq", Name: "ColdReboot"},
+			{OperationID: "serverACResetReq", Name: "ACReset"},
+			{OperationID: "serverReinstallReq", Name: "Reinstall"},
+			{OperationID: "getBaremetalConsoleReq", Name: "GetConsoleURL"},
+			{OperationID: "enableSupportAccessReq", Name: "EnableSupportAccess"},
+			{OperationID: "disableSupportAccessReq", Name: "DisableSupportAccess"},
+		},
+	},
+	{
+		Tag: "virtual_machines", Type: "VirtualMachinesService", Accessor: "VirtualMachines", File: "virtual_machine_service.go",
+		Doc: "handles virtual machine-related API operations",
+		Methods: []method{
+			{OperationID: "getVirtualMachinesReq", Name: "List"},
+			{OperationID: "getVirtualMachineReq", Name: "Get"},
+			{OperationID: "assignAvailableVirtualMachineReq", Name: "Provision"},
+			{OperationID: "updateVirtualMachineReq", Name: "Update"},
+			{OperationID: "deleteVMReq", Name: "Delete", QueryName: "DeleteWithForce"},
+			{OperationID: "getAvailableVirtualMachinesReq", Name: "GetAvailable"},
+			{OperationID: "getVMStateReq", Name: "GetState"},
+			{OperationID: "startVMReq", Name: "Start"},
+			{OperationID: "stopVMReq", Name: "Stop"},
+			{OperationID: "shutdownVMReq", Name: "Shutdown"},
+			{OperationID: "rebootVMReq", Name: "Reboot"},
+			{OperationID: "hardResetVMReq", Name: "HardReset"},
+			{OperationID: "rebuildVMReq", Name: "Rebuild"},
+			{OperationID: "getVMConsoleReq", Name: "Console", WebSocket: true},
+		},
+	},
+	{
+		Tag: "user", Type: "UserService", Accessor: "User", File: "user_service.go",
+		Doc: "handles user-related API operations",
+		Methods: []method{
+			{OperationID: "getUserReq", Name: "Get"},
+			{OperationID: "updateUserReq", Name: "Update"},
+			{OperationID: "getUserSSHKeysReq", Name: "GetSSHKeys"},
+			{OperationID: "addSSHKeyReq", Name: "AddSSHKey"},
+			{OperationID: "deleteSSHKeyReq", Name: "DeleteSSHKey"},
+			{OperationID: "getUserAPIKeysReq", Name: "GetAPIKeys"},
+			{OperationID: "getUserAPIKeyDetailReq", Name: "GetAPIKey"},
+			{OperationID: "createAPIKeyReq", Name: "CreateAPIKey"},
+			{OperationID: "updateAPIKeyReq", Name: "UpdateAPIKey"},
+			{OperationID: "deleteAPIKeyReq", Name: "DeleteAPIKey"},
+		},
+	},
+}
+
+// paramNames are the Go names of path parameters that are not their own name
+var paramNames = map[string]string{
+	"team":   "teamHandle",
+	"server": "serverName",
+	"vm":     "vmName",
+}
+
+// fieldTypes overrides the Go type of properties, by definition and property
+// name. VMProvisionRequest embeds VirtualMachineSpecs, where every spec is
+// then optional, and the specs of available types are always sent.
+var fieldTypes = map[string]string{
+	"VirtualMachineSpecs.cpu_cores":      "*uint64",
+	"VirtualMachineSpecs.ram_capacity":   "*uint64",
+	"VirtualMachineSpecs.disk_capacity":  "*uint64",
+	"AvailableBareMetalTypes.Specs":      "BareMetalServerSpecs",
+	"AvailableVirtualMachineTypes.Specs": "VirtualMachineSpecs",
+}
+
+// initialisms are the words of property names that Go spells in capitals
+var initialisms = map[string]string{
+	"api":  "API",
+	"cpu":  "CPU",
+	"cpus": "CPUs",
+	"dns":  "DNS",
+	"gpu":  "GPU",
+	"gpus": "GPUs",
+	"id":   "ID",
+	"ip":   "IP",
+	"os":   "OS",
+	"ram":  "RAM",
+	"ssh":  "SSH",
+	"url":  "URL",
+}
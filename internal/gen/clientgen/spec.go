@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// spec is the part of a Swagger 2.0 document that the client is generated from
+type spec struct {
+	Definitions map[string]*schema               `json:"definitions"`
+	Paths       map[string]map[string]*operation `json:"paths"`
+}
+
+type schema struct {
+	Ref         string             `json:"$ref"`
+	Type        string             `json:"type"`
+	Format      string             `json:"format"`
+	Description string             `json:"description"`
+	Required    []string           `json:"required"`
+	Properties  map[string]*schema `json:"properties"`
+	Items       *schema            `json:"items"`
+	AllOf       []*schema          `json:"allOf"`
+	GoName      string             `json:"x-go-name"`
+}
+
+type operation struct {
+	OperationID string              `json:"operationId"`
+	Description string              `json:"description"`
+	Tags        []string            `json:"tags"`
+	Parameters  []parameter         `json:"parameters"`
+	Responses   map[string]response `json:"responses"`
+	Permissions string              `json:"x-requires-permissions"`
+
+	// Method and Path are filled in from the paths object
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type response struct {
+	Schema *schema `json:"schema"`
+}
+
+func loadSpec(path string) (*spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for path, methods := range s.Paths {
+		for method, op := range methods {
+			op.Method = strings.ToUpper(method)
+			op.Path = path
+		}
+	}
+	return &s, nil
+}
+
+// operations returns the operations of the spec by operationId
+func (s *spec) operations() (map[string]*operation, error) {
+	ops := map[string]*operation{}
+	for _, methods := range s.Paths {
+		for _, op := range methods {
+			if _, ok := ops[op.OperationID]; ok {
+				return nil, fmt.Errorf("duplicate operationId %s", op.OperationID)
+			}
+			ops[op.OperationID] = op
+		}
+	}
+	return ops, nil
+}
+
+// refName returns the definition a $ref points to
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
@@ -0,0 +1,122 @@
+// Package pricing estimates what a new VM or bare metal server costs, from the
+// prices of the available types and the balance of the team.
+package pricing
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// ErrNoMatch is returned when no available type has the requested specs
+var ErrNoMatch = errors.New("no available type matches the requested specs")
+
+// Price is the on-demand price of a type, in US cents per hour, and the
+// minimum time it is billed for
+type Price struct {
+	Hourly         int64
+	MinimumMinutes int64
+}
+
+// candidate is an available type that matches the request
+type candidate struct {
+	price    Price
+	quantity int64
+}
+
+// pick returns the most expensive price among the types in stock, or among all
+// types if none is in stock, so that estimates err on the side of caution
+func pick(candidates []candidate) (Price, error) {
+	if len(candidates) == 0 {
+		return Price{}, ErrNoMatch
+	}
+	if inStock := slices.DeleteFunc(slices.Clone(candidates), func(c candidate) bool { return c.quantity <= 0 }); len(inStock) > 0 {
+		candidates = inStock
+	}
+	best := slices.MaxFunc(candidates, func(a, b candidate) int { return cmp.Compare(a.price.Hourly, b.price.Hourly) })
+	return best.price, nil
+}
+
+// MatchVM returns the price of the available VM type with the requested specs.
+// Specs left out of the request match any type.
+func MatchVM(types []client.AvailableVirtualMachineTypes, want client.VirtualMachineSpecs) (Price, error) {
+	var candidates []candidate
+	for _, t := range types {
+		if !optionalMatch(want.CPUCores, t.Specs.CPUCores) ||
+			!optionalMatch(want.RAMCapacity, t.Specs.RAMCapacity) ||
+			!optionalMatch(want.DiskCapacity, t.Specs.DiskCapacity) ||
+			!gpusMatch(want.GPUs, t.Specs.GPUs) {
+			continue
+		}
+		candidates = append(candidates, candidate{Price{t.OnDemandPrice, t.MinimumReservationMinutes}, t.Quantity})
+	}
+	return pick(candidates)
+}
+
+// MatchBareMetal returns the price of the available bare metal type with the
+// requested specs. Zero specs match any type.
+func MatchBareMetal(types []client.AvailableBareMetalTypes, want client.BareMetalServerSpecs) (Price, error) {
+	var candidates []candidate
+	for _, t := range types {
+		if want.CPUCores != 0 && want.CPUCores != t.Specs.CPUCores ||
+			want.RAMCapacity != 0 && want.RAMCapacity != t.Specs.RAMCapacity ||
+			want.DiskCapacity != 0 && want.DiskCapacity != t.Specs.DiskCapacity ||
+			!gpusMatch(want.GPUs, t.Specs.GPUs) {
+			continue
+		}
+		candidates = append(candidates, candidate{Price{t.OnDemandPrice, t.MinimumReservationMinutes}, t.Quantity})
+	}
+	return pick(candidates)
+}
+
+func optionalMatch(want, have *uint64) bool {
+	return want == nil || have != nil && *want == *have
+}
+
+// gpusMatch reports whether every requested GPU model is in have with the requested count
+func gpusMatch(want, have []client.GPUs) bool {
+	for _, w := range want {
+		if !slices.ContainsFunc(have, func(h client.GPUs) bool {
+			return strings.EqualFold(w.Model, h.Model) && (w.Count == 0 || w.Count == h.Count)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Estimate is what a team spends once a new resource is added
+type Estimate struct {
+	// HourlyCost is the price of the new resource in cents per hour
+	HourlyCost int64 `json:"hourly_cost"`
+	// MinimumMinutes and MinimumCost are the commitment made by creating it
+	MinimumMinutes int64 `json:"minimum_minutes"`
+	MinimumCost    int64 `json:"minimum_cost"`
+	// HourlyRate is the hourly rate of the team with the new resource
+	HourlyRate       int64 `json:"hourly_rate"`
+	AvailableBalance int64 `json:"available_balance"`
+	// RunoutTime is when the balance reaches the minimum balance at the new
+	// rate, or nil if the team spends nothing
+	RunoutTime *time.Time `json:"estimated_runout_time,omitempty"`
+}
+
+// NewEstimate estimates the spending of a team after adding a resource with the given price
+func NewEstimate(price Price, balance client.BalanceInfo, now time.Time) Estimate {
+	e := Estimate{
+		HourlyCost:       price.Hourly,
+		MinimumMinutes:   price.MinimumMinutes,
+		MinimumCost:      (price.Hourly*price.MinimumMinutes + 59) / 60,
+		HourlyRate:       balance.HourlyRate + price.Hourly,
+		AvailableBalance: balance.AvailableBalance,
+	}
+	if e.HourlyRate > 0 {
+		spendable := max(balance.AvailableBalance-balance.MinimumBalance, 0)
+		runout := now.Add(time.Duration(float64(spendable) / float64(e.HourlyRate) * float64(time.Hour)))
+		e.RunoutTime = &runout
+	}
+	return e
+}
@@ -0,0 +1,121 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+var vmTypes = []client.AvailableVirtualMachineTypes{
+	{
+		Quantity: 8, MinimumReservationMinutes: 60, OnDemandPrice: 199,
+		Specs: client.VirtualMachineSpecs{
+			CPUCores: uint64Ptr(13), RAMCapacity: uint64Ptr(224 << 30), DiskCapacity: uint64Ptr(12288 << 30),
+			GPUs: []client.GPUs{{Count: 1, Model: "MI300X"}},
+		},
+	},
+	{
+		Quantity: 16, MinimumReservationMinutes: 30, OnDemandPrice: 10,
+		Specs: client.VirtualMachineSpecs{CPUCores: uint64Ptr(2), RAMCapacity: uint64Ptr(8 << 30), DiskCapacity: uint64Ptr(100 << 30)},
+	},
+	{
+		Quantity: 0, MinimumReservationMinutes: 30, OnDemandPrice: 15,
+		Specs: client.VirtualMachineSpecs{CPUCores: uint64Ptr(2), RAMCapacity: uint64Ptr(16 << 30), DiskCapacity: uint64Ptr(100 << 30)},
+	},
+}
+
+func TestMatchVM(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    client.VirtualMachineSpecs
+		price   Price
+		wantErr error
+	}{
+		{
+			name:  "by GPU",
+			want:  client.VirtualMachineSpecs{GPUs: []client.GPUs{{Count: 1, Model: "mi300x"}}},
+			price: Price{Hourly: 199, MinimumMinutes: 60},
+		},
+		{
+			name:  "all specs",
+			want:  client.VirtualMachineSpecs{CPUCores: uint64Ptr(2), RAMCapacity: uint64Ptr(8 << 30), DiskCapacity: uint64Ptr(100 << 30)},
+			price: Price{Hourly: 10, MinimumMinutes: 30},
+		},
+		{
+			name:  "sold out types are left out",
+			want:  client.VirtualMachineSpecs{CPUCores: uint64Ptr(2)},
+			price: Price{Hourly: 10, MinimumMinutes: 30},
+		},
+		{
+			name:  "sold out type when it is the only match",
+			want:  client.VirtualMachineSpecs{RAMCapacity: uint64Ptr(16 << 30)},
+			price: Price{Hourly: 15, MinimumMinutes: 30},
+		},
+		{
+			name:  "most expensive of several",
+			want:  client.VirtualMachineSpecs{},
+			price: Price{Hourly: 199, MinimumMinutes: 60},
+		},
+		{
+			name:    "no match",
+			want:    client.VirtualMachineSpecs{CPUCores: uint64Ptr(4)},
+			wantErr: ErrNoMatch,
+		},
+		{
+			name:    "wrong GPU count",
+			want:    client.VirtualMachineSpecs{GPUs: []client.GPUs{{Count: 2, Model: "MI300X"}}},
+			wantErr: ErrNoMatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := MatchVM(vmTypes, tt.want)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.price, price)
+		})
+	}
+}
+
+func TestMatchBareMetal(t *testing.T) {
+	types := []client.AvailableBareMetalTypes{
+		{
+			Quantity: 2, MinimumReservationMinutes: 1440, OnDemandPrice: 1592,
+			Specs: client.BareMetalServerSpecs{CPUCores: 104, RAMCapacity: 2048 << 30, DiskCapacity: 30720 << 30},
+		},
+	}
+
+	price, err := MatchBareMetal(types, client.BareMetalServerSpecs{CPUCores: 104, RAMCapacity: 2048 << 30, DiskCapacity: 30720 << 30})
+	assert.NoError(t, err)
+	assert.Equal(t, Price{Hourly: 1592, MinimumMinutes: 1440}, price)
+
+	_, err = MatchBareMetal(types, client.BareMetalServerSpecs{CPUCores: 104, RAMCapacity: 2048})
+	assert.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestNewEstimate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e := NewEstimate(Price{Hourly: 199, MinimumMinutes: 90}, client.BalanceInfo{
+		AvailableBalance: 10_000_00, HourlyRate: 801, MinimumBalance: 2_000_00,
+	}, now)
+	assert.Equal(t, int64(199), e.HourlyCost)
+	assert.Equal(t, int64(90), e.MinimumMinutes)
+	assert.Equal(t, int64(299), e.MinimumCost, "rounded up to the cent")
+	assert.Equal(t, int64(1000), e.HourlyRate)
+	assert.Equal(t, int64(10_000_00), e.AvailableBalance)
+	// $8000 above the minimum balance at $10/hr
+	assert.Equal(t, now.Add(800*time.Hour), *e.RunoutTime)
+
+	e = NewEstimate(Price{Hourly: 100}, client.BalanceInfo{AvailableBalance: 50, MinimumBalance: 100}, now)
+	assert.Equal(t, now, *e.RunoutTime, "already below the minimum balance")
+
+	e = NewEstimate(Price{}, client.BalanceInfo{AvailableBalance: 100}, now)
+	assert.Nil(t, e.RunoutTime, "nothing is spent")
+}
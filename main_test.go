@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"hotaisle-cli/client"
+	"hotaisle-cli/test/fakeapi"
+
+	"github.com/rogpeppe/go-internal/testscript"
+)
+
+func TestMain(m *testing.M) {
+	testscript.Main(m, map[string]func(){
+		"hotaisle": main,
+	})
+}
+
+// TestScripts runs the scripts of testdata/script with the hotaisle binary.
+// Each script gets an empty home directory, HOTAISLE_CONFIG_FILE outside of it,
+// so that files written to the default location instead are caught, and a fake
+// API with the team fake-team at $FAKE_API, which accepts $FAKE_TOKEN, the API
+// key $FAKE_KEY_PREFIX.
+// Run with UPDATE_SCRIPTS=1 to update the expected output of cmp commands.
+func TestScripts(t *testing.T) {
+	testscript.Run(t, testscript.Params{
+		Dir:           filepath.Join("testdata", "script"),
+		Setup:         setupScript,
+		Cmds:          map[string]func(*testscript.TestScript, bool, []string){"exitcode": exitCode},
+		UpdateScripts: os.Getenv("UPDATE_SCRIPTS") == "1",
+	})
+}
+
+func setupScript(env *testscript.Env) error {
+	fake := fakeapi.New()
+	fake.AddTeam(client.Team{Handle: "fake-team", Name: "Fake Team"}, 1000_00)
+	srv := httptest.NewServer(fake)
+	env.Defer(srv.Close)
+
+	home := filepath.Join(env.WorkDir, "home")
+	env.Setenv("HOME", home)
+	env.Setenv("HOTAISLE_CONFIG_FILE", filepath.Join(env.WorkDir, "cfg", "config.json"))
+	env.Setenv("FAKE_API", srv.URL+"/api")
+	env.Setenv("FAKE_TOKEN", fake.Token())
+
+	api := client.NewClient(client.WithBaseURL(srv.URL+"/api"), client.WithToken(fake.Token()))
+	keys, err := api.User().GetAPIKeys(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(keys) != 1 {
+		return errors.New("the fake API should start with one API key")
+	}
+	env.Setenv("FAKE_KEY_PREFIX", keys[0].Prefix)
+	return nil
+}
+
+// exitCode runs a command that must exit with a given code:
+//
+//	exitcode 5 hotaisle vm get --vm missing
+func exitCode(ts *testscript.TestScript, neg bool, args []string) {
+	if neg || len(args) < 2 {
+		ts.Fatalf("usage: exitcode code command [args...]")
+	}
+	want, err := strconv.Atoi(args[0])
+	ts.Check(err)
+
+	got := 0
+	var exitErr *exec.ExitError
+	if err := ts.Exec(args[1], args[2:]...); errors.As(err, &exitErr) {
+		got = exitErr.ExitCode()
+	} else if err != nil {
+		ts.Fatalf("%v", err)
+	}
+	if got != want {
+		ts.Fatalf("%s exited with %d, want %d", args[1], got, want)
+	}
+}
@@ -0,0 +1,303 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RecordEnv is the environment variable that, set to 1, makes cassettes
+// record real API interactions instead of replaying them
+const RecordEnv = "HOTAISLE_RECORD"
+
+// scrubbed replaces secrets in recorded interactions
+const scrubbed = "REDACTED"
+
+// secretFields are the JSON fields whose values are never recorded, such as
+// the token of a new API key
+var secretFields = []string{"token"}
+
+// Recording reports whether cassettes record instead of replaying
+func Recording() bool {
+	return os.Getenv(RecordEnv) == "1"
+}
+
+// Interaction is a request sent to the API and the response it got
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of an interaction. Path holds the query too.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of an interaction. Bodies that are not JSON
+// are recorded as JSON strings.
+type RecordedResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+// Cassette is an http.RoundTripper that replays the interactions of a
+// cassette file. A request is answered with the first interaction not yet
+// replayed with the same method, path and body, so a flow that polls the same
+// path gets the recorded responses in order. Interactions left over when the
+// test ends fail it.
+//
+// With HOTAISLE_RECORD=1 it sends the requests to the API instead, and writes
+// them to the cassette file when the test passes. Headers are not recorded,
+// so neither is the Authorization header, and token fields and the values
+// given by Value are scrubbed from paths and bodies.
+type Cassette struct {
+	t         testing.TB
+	path      string
+	recording bool
+	// next sends the requests while recording
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+	replacements []string
+}
+
+// NewCassette replays the cassette file at path, or records it with
+// HOTAISLE_RECORD=1
+func NewCassette(t testing.TB, path string) *Cassette {
+	t.Helper()
+	c := &Cassette{t: t, path: path, recording: Recording(), next: http.DefaultTransport}
+	if c.recording {
+		t.Cleanup(c.save)
+		return c
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing cassette %s, record it with %s=1", path, RecordEnv)
+	}
+	if err != nil {
+		t.Fatalf("failed to read cassette: %v", err)
+	}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		t.Fatalf("failed to parse cassette %s: %v", path, err)
+	}
+	c.replayed = make([]bool, len(c.interactions))
+	t.Cleanup(c.checkReplayed)
+	return c
+}
+
+// Client returns an HTTP client that sends its requests through the cassette
+func (c *Cassette) Client() *http.Client {
+	return NewMockClient(c)
+}
+
+// Value returns the value of an environment variable while recording, such as
+// a real team handle, and placeholder when replaying. The recorded
+// interactions hold placeholder in place of the real value.
+func (c *Cassette) Value(env, placeholder string) string {
+	c.t.Helper()
+	if !c.recording {
+		return placeholder
+	}
+	value := os.Getenv(env)
+	if value == "" {
+		c.t.Fatalf("%s must be set to record %s", env, c.path)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replacements = append(c.replacements, value, placeholder)
+	return value
+}
+
+// RoundTrip implements http.RoundTripper
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if c.recording {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   c.scrub(req.URL.RequestURI()),
+			Body:   c.scrubBody(body),
+		},
+		Response: RecordedResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        c.scrubBody(respBody),
+		},
+	})
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path, reqBody := req.URL.RequestURI(), c.scrubBody(body)
+	for i, interaction := range c.interactions {
+		recorded := interaction.Request
+		if c.replayed[i] || recorded.Method != req.Method || recorded.Path != path || !jsonEqual(recorded.Body, reqBody) {
+			continue
+		}
+		c.replayed[i] = true
+
+		var respBody []byte
+		var s string
+		if json.Unmarshal(interaction.Response.Body, &s) == nil {
+			respBody = []byte(s)
+		} else if len(interaction.Response.Body) > 0 {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, interaction.Response.Body); err != nil {
+				return nil, fmt.Errorf("invalid recorded body for %s %s: %w", req.Method, path, err)
+			}
+			respBody = compact.Bytes()
+		}
+		header := make(http.Header)
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode: interaction.Response.Status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     header,
+			Body:       io.NopCloser(bytes.NewReader(respBody)),
+			Request:    req,
+		}, nil
+	}
+	c.t.Errorf("cassette %s has no interaction left for %s %s %s", c.path, req.Method, path, body)
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, path)
+}
+
+// scrub replaces the values given by Value with their placeholders
+func (c *Cassette) scrub(s string) string {
+	if len(c.replacements) == 0 {
+		return s
+	}
+	return strings.NewReplacer(c.replacements...).Replace(s)
+}
+
+// scrubBody returns a body to record, with the secret fields of JSON bodies
+// scrubbed, and other bodies as JSON strings
+func (c *Cassette) scrubBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if json.Unmarshal(body, &v) != nil {
+		quoted, _ := json.Marshal(c.scrub(string(body)))
+		return quoted
+	}
+	scrubFields(v)
+	scrubbedBody, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(c.scrub(string(scrubbedBody)))
+}
+
+// scrubFields replaces the values of the secret fields at any depth of a
+// decoded JSON value
+func scrubFields(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if slices.Contains(secretFields, key) && value != nil && value != "" {
+				v[key] = scrubbed
+				continue
+			}
+			scrubFields(value)
+		}
+	case []any:
+		for _, value := range v {
+			scrubFields(value)
+		}
+	}
+}
+
+// jsonEqual reports whether two JSON bodies hold the same value
+func jsonEqual(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	xJSON, _ := json.Marshal(x)
+	yJSON, _ := json.Marshal(y)
+	return bytes.Equal(xJSON, yJSON)
+}
+
+// save writes the recorded interactions to the cassette file, unless the test
+// failed and they would replace a good recording
+func (c *Cassette) save() {
+	if c.t.Failed() {
+		c.t.Logf("not saving cassette %s of a failed test", c.path)
+		return
+	}
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		c.t.Errorf("failed to encode cassette: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		c.t.Errorf("failed to create cassette directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o644); err != nil {
+		c.t.Errorf("failed to write cassette: %v", err)
+	}
+}
+
+// checkReplayed fails the test if interactions of the cassette were not replayed
+func (c *Cassette) checkReplayed() {
+	for i, replayed := range c.replayed {
+		if !replayed {
+			request := c.interactions[i].Request
+			c.t.Errorf("cassette %s: %s %s was not replayed", c.path, request.Method, request.Path)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/teams/real-team/keys/":
+			_, _ = io.WriteString(w, `{"label":"ci","token":"secret-token"}`)
+		case "/api/teams/real-team/state/":
+			polls++
+			if polls == 1 {
+				_, _ = io.WriteString(w, `{"state":"starting"}`)
+			} else {
+				_, _ = io.WriteString(w, `{"state":"running"}`)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "flow.json")
+	send := func(t *testing.T, c *Cassette, team, method, path, body string) string {
+		req, err := http.NewRequest(method, srv.URL+"/api/teams/"+team+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := c.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	flow := func(t *testing.T, c *Cassette, team string) []string {
+		return []string{
+			send(t, c, team, http.MethodPost, "/keys/", `{"label": "ci"}`),
+			send(t, c, team, http.MethodGet, "/state/", ""),
+			send(t, c, team, http.MethodGet, "/state/", ""),
+		}
+	}
+
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(RecordEnv, "1")
+		t.Setenv("TEST_TEAM", "real-team")
+		c := NewCassette(t, path)
+		got := flow(t, c, c.Value("TEST_TEAM", "test-team"))
+		if got[0] != `{"label":"ci","token":"secret-token"}` || got[2] != `{"state":"running"}` {
+			t.Errorf("recording changed the responses: %q", got)
+		}
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-token", "real-team", "Authorization"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette holds %s:\n%s", secret, data)
+		}
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		t.Setenv(RecordEnv, "")
+		c := NewCassette(t, path)
+		got := flow(t, c, c.Value("TEST_TEAM", "test-team"))
+		want := []string{`{"label":"ci","token":"REDACTED"}`, `{"state":"starting"}`, `{"state":"running"}`}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("response %d is %s, want %s", i, got[i], want[i])
+			}
+		}
+	})
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a": 1, "b": [2]}`, `{"b":[2],"a":1}`, true},
+		{`{"a": 1}`, `{"a": 2}`, false},
+		{``, ``, true},
+		{`{}`, ``, false},
+		{`not json`, `not json`, true},
+	}
+	for _, tt := range tests {
+		if got := jsonEqual([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("jsonEqual(%s, %s) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// Power states reported by the API
+const (
+	powerOn  = "On"
+	powerOff = "Off"
+)
+
+// defaultOS is the OS installed on reserved servers
+const defaultOS = "ubuntu-24.04"
+
+// server is a bare metal server. Its power moves to powerTarget at powerAt,
+// and its OS install goes through a stage every installStageTime from
+// installStart.
+type server struct {
+	client.BareMetalServerDetails
+	resource
+	serverType   int
+	power        string
+	powerTarget  string
+	powerAt      time.Time
+	installStart time.Time
+	installEnded bool
+}
+
+// settle completes the power change in progress and advances the OS install
+func (sv *server) settle(now time.Time, stageTime time.Duration) {
+	if !sv.powerAt.IsZero() && !now.Before(sv.powerAt) {
+		sv.power = sv.powerTarget
+		sv.powerAt = time.Time{}
+	}
+	if sv.installEnded {
+		return
+	}
+	last := len(client.OSInstallStages) - 1
+	stage := last
+	if stageTime > 0 {
+		stage = min(int(now.Sub(sv.installStart)/stageTime), last)
+	}
+	sv.OSStatus.OSStatus = client.OSInstallStages[stage]
+	sv.OSStatus.LastImagingUpdate = sv.installStart.Add(time.Duration(stage) * stageTime)
+	sv.installEnded = stage == last
+}
+
+// startInstall starts installing the OS from the first stage
+func (sv *server) startInstall(now time.Time, stageTime time.Duration) {
+	sv.installStart = now
+	sv.installEnded = false
+	sv.power = powerOn
+	sv.powerAt = time.Time{}
+	sv.settle(now, stageTime)
+}
+
+// powerChange is a power action: whether the server has to be on, and the
+// resulting power state, reached after the transition time if delayed
+type powerChange struct {
+	needOn  bool
+	to      string
+	delayed bool
+}
+
+var powerActions = map[string]powerChange{
+	"power_on":          {to: powerOn},
+	"graceful_shutdown": {needOn: true, to: powerOff, delayed: true},
+	"force_shutdown":    {to: powerOff},
+	"warm_reboot":       {needOn: true, to: powerOn, delayed: true},
+	"cold_reboot":       {to: powerOn, delayed: true},
+	"ac_reset":          {to: powerOn, delayed: true},
+}
+
+func defaultBareMetalTypes() []client.AvailableBareMetalTypes {
+	return []client.AvailableBareMetalTypes{
+		{
+			Quantity:                  2,
+			MinimumReservationMinutes: 1440,
+			OnDemandPrice:             1592,
+			Specs: client.BareMetalServerSpecs{
+				CPUCores:     104,
+				RAMCapacity:  2048 << 30,
+				DiskCapacity: 30720 << 30,
+				GPUs:         []client.GPUs{{Count: 8, Manufacturer: "AMD", Model: "MI300X"}},
+			},
+		},
+	}
+}
+
+// matchBareMetalType returns the first type with capacity matching the
+// requested specs, or -1. soldOut reports that a type matched but has none left.
+func (s *Server) matchBareMetalType(want client.BareMetalServerSpecs) (index int, soldOut bool) {
+	for i, t := range s.bareMetalTypes {
+		if want.CPUCores != 0 && want.CPUCores != t.Specs.CPUCores ||
+			want.RAMCapacity != 0 && want.RAMCapacity != t.Specs.RAMCapacity ||
+			want.DiskCapacity != 0 && want.DiskCapacity != t.Specs.DiskCapacity ||
+			!gpusMatch(want.GPUs, t.Specs.GPUs) {
+			continue
+		}
+		if t.Quantity <= 0 {
+			soldOut = true
+			continue
+		}
+		return i, false
+	}
+	return -1, soldOut
+}
+
+// FailInstall makes the OS install in progress on a server fail
+func (s *Server) FailInstall(handle, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findTeam(handle)
+	if t == nil {
+		return fmt.Errorf("team %s not found", handle)
+	}
+	for _, sv := range t.servers {
+		if sv.Name == name {
+			now := s.now()
+			sv.settle(now, s.installStageTime)
+			if sv.installEnded {
+				return fmt.Errorf("server %s is not installing", name)
+			}
+			sv.OSStatus.OSStatus = client.OSStatusFailed
+			sv.OSStatus.LastImagingUpdate = now
+			sv.installEnded = true
+			return nil
+		}
+	}
+	return fmt.Errorf("server %s not found", name)
+}
+
+// serverFromPath looks up the server named in the path, writing a 404 if it does not exist
+func (s *Server) serverFromPath(c *call) *server {
+	name := c.r.PathValue("server")
+	for _, sv := range c.team.servers {
+		if sv.Name == name {
+			sv.settle(s.now(), s.installStageTime)
+			return sv
+		}
+	}
+	writeError(c.w, http.StatusNotFound, "bare metal server %s not found", name)
+	return nil
+}
+
+func (s *Server) listServers(c *call) {
+	now := s.now()
+	servers := []client.BareMetalServerDetails{}
+	for _, sv := range c.team.servers {
+		sv.settle(now, s.installStageTime)
+		servers = append(servers, sv.details())
+	}
+	writeJSON(c.w, http.StatusOK, servers)
+}
+
+// details copies the server as the API returns it
+func (sv *server) details() client.BareMetalServerDetails {
+	details := sv.BareMetalServerDetails
+	status := *sv.OSStatus
+	details.OSStatus = &status
+	return details
+}
+
+func (s *Server) availableServers(c *call) {
+	writeJSON(c.w, http.StatusOK, s.bareMetalTypes)
+}
+
+func (s *Server) reserveServer(c *call) {
+	var req client.BareMetalServerReservation
+	if !decode(c, &req) {
+		return
+	}
+	if limit := c.team.MaximumBareMetalServers; limit > 0 && int64(len(c.team.servers)) >= limit {
+		writeError(c.w, http.StatusUnprocessableEntity, "team %s has reached its limit of %d bare metal servers", c.team.Handle, limit)
+		return
+	}
+	index, soldOut := s.matchBareMetalType(req.Specs)
+	if index < 0 {
+		if soldOut {
+			writeError(c.w, http.StatusConflict, "no capacity left for the requested bare metal server")
+		} else {
+			writeError(c.w, http.StatusUnprocessableEntity, "no bare metal server type matches the requested specs")
+		}
+		return
+	}
+	t := &s.bareMetalTypes[index]
+	now := s.now()
+	if !c.team.canAfford(now, t.OnDemandPrice) {
+		writeError(c.w, http.StatusPaymentRequired, "insufficient balance on team %s", c.team.Handle)
+		return
+	}
+
+	id := s.nextID()
+	name := fmt.Sprintf("bm-%04d", id)
+	sv := &server{
+		BareMetalServerDetails: client.BareMetalServerDetails{
+			BareMetalServer: client.BareMetalServer{
+				Name:         name,
+				IPAddress:    fmt.Sprintf("10.1.%d.%d", id/250, id%250+2),
+				Manufacturer: "Dell",
+				Model:        "PowerEdge XE9680",
+				Description:  req.Description,
+				SSHAccess:    &client.ExternalService{IPAddress: "203.0.113.20", Port: int64(22000 + id), DNSName: name + ".fake.invalid"},
+			},
+			BareMetalServerSpecs: t.Specs,
+			OSStatus:             &client.BareMetalServerlOSStatus{OSSelection: defaultOS},
+		},
+		resource:   resource{price: t.OnDemandPrice, created: now},
+		serverType: index,
+	}
+	sv.startInstall(now, s.installStageTime)
+	t.Quantity--
+	c.team.servers = append(c.team.servers, sv)
+
+	details := sv.details()
+	writeJSON(c.w, http.StatusCreated, client.BareMetalServerReservationResponse{
+		BareMetalServer: details.BareMetalServer,
+		CPUCores:        details.CPUCores,
+		RAMCapacity:     details.RAMCapacity,
+		DiskCapacity:    details.DiskCapacity,
+		CPUs:            details.CPUs,
+		Disks:           details.Disks,
+		GPUs:            details.GPUs,
+		MemoryModules:   details.MemoryModules,
+		OSStatus:        details.OSStatus,
+	})
+}
+
+func (s *Server) getServer(c *call) {
+	if sv := s.serverFromPath(c); sv != nil {
+		writeJSON(c.w, http.StatusOK, sv.details())
+	}
+}
+
+func (s *Server) updateServer(c *call) {
+	sv := s.serverFromPath(c)
+	if sv == nil {
+		return
+	}
+	var update client.BareMetalServerUpdate
+	if !decode(c, &update) {
+		return
+	}
+	sv.Description = update.Description
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) releaseServer(c *call) {
+	sv := s.serverFromPath(c)
+	if sv == nil {
+		return
+	}
+	c.team.spent += sv.cost(s.now())
+	c.team.servers = slices.DeleteFunc(c.team.servers, func(other *server) bool { return other == sv })
+	s.bareMetalTypes[sv.serverType].Quantity++
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serverConsole(c *call) {
+	if sv := s.serverFromPath(c); sv != nil {
+		writeJSON(c.w, http.StatusOK, client.BareMetalServerConsoleURL{
+			URL: fmt.Sprintf("https://console.fake.invalid/%s/%s?session=%d", c.team.Handle, sv.Name, s.nextID()),
+		})
+	}
+}
+
+func (s *Server) getPower(c *call) {
+	if sv := s.serverFromPath(c); sv != nil {
+		writeJSON(c.w, http.StatusOK, client.BareMetalServerPowerState{State: sv.power})
+	}
+}
+
+func (s *Server) powerAction(change powerChange) handlerFunc {
+	return func(c *call) {
+		sv := s.serverFromPath(c)
+		if sv == nil {
+			return
+		}
+		if change.needOn && sv.power != powerOn {
+			writeError(c.w, http.StatusConflict, "bare metal server %s is powered off", sv.Name)
+			return
+		}
+		if change.delayed {
+			sv.powerTarget, sv.powerAt = change.to, s.now().Add(s.transitionTime)
+		} else {
+			sv.power, sv.powerAt = change.to, time.Time{}
+		}
+		c.w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) reinstallServer(c *call) {
+	sv := s.serverFromPath(c)
+	if sv == nil {
+		return
+	}
+	if !sv.installEnded {
+		writeError(c.w, http.StatusConflict, "bare metal server %s is already installing (%s)", sv.Name, sv.OSStatus.OSStatus)
+		return
+	}
+	sv.startInstall(s.now(), s.installStageTime)
+	writeJSON(c.w, http.StatusOK, sv.details())
+}
+
+func (s *Server) setSupportAccess(enabled bool) handlerFunc {
+	return func(c *call) {
+		if sv := s.serverFromPath(c); sv != nil {
+			sv.SupportAccessEnabled = enabled
+			c.w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
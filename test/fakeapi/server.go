@@ -0,0 +1,331 @@
+// Package fakeapi is a stateful in-memory fake of the Hot Aisle API described
+// by swagger.json, for offline development and end-to-end tests.
+//
+// Server is an http.Handler serving the API under /api, so it can be used
+// with httptest:
+//
+//	fake := fakeapi.New()
+//	fake.AddTeam(client.Team{Handle: "my-team", Name: "My Team"}, 1000_00)
+//	srv := httptest.NewServer(fake)
+//	defer srv.Close()
+//
+//	c := client.NewClient(client.WithBaseURL(srv.URL+"/api"), client.WithToken(fake.Token()))
+//
+// VM state changes, bare metal power changes and OS installs take time on the
+// clock given with WithClock, so tests can step through them.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+const (
+	// DefaultToken is the token of the API key the server starts with
+	DefaultToken = "fake-token"
+	// DefaultTransitionTime is how long VM and power state changes take
+	DefaultTransitionTime = 5 * time.Second
+	// DefaultInstallStageTime is how long each OS install stage takes
+	DefaultInstallStageTime = 10 * time.Second
+)
+
+// Team and user roles
+const (
+	RoleOwner     = "owner"
+	RolePurchaser = "purchaser"
+	RoleOperator  = "operator"
+	RoleUser      = "user"
+)
+
+var teamRoles = []string{RoleOwner, RolePurchaser, RoleOperator, RoleUser}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithToken sets the token of the API key the server starts with
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithUser sets the authenticated user
+func WithUser(user client.User) Option {
+	return func(s *Server) {
+		s.user = user
+	}
+}
+
+// WithClock sets the clock used for state transitions and billing
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithTransitionTime sets how long VM and power state changes take
+func WithTransitionTime(d time.Duration) Option {
+	return func(s *Server) {
+		s.transitionTime = d
+	}
+}
+
+// WithInstallStageTime sets how long each OS install stage takes
+func WithInstallStageTime(d time.Duration) Option {
+	return func(s *Server) {
+		s.installStageTime = d
+	}
+}
+
+// WithVMTypes replaces the virtual machine types that can be provisioned
+func WithVMTypes(types ...client.AvailableVirtualMachineTypes) Option {
+	return func(s *Server) {
+		s.vmTypes = slices.Clone(types)
+	}
+}
+
+// WithBareMetalTypes replaces the bare metal server types that can be reserved
+func WithBareMetalTypes(types ...client.AvailableBareMetalTypes) Option {
+	return func(s *Server) {
+		s.bareMetalTypes = slices.Clone(types)
+	}
+}
+
+// Server is the fake API. It is safe for concurrent use.
+type Server struct {
+	mu  sync.Mutex
+	mux *http.ServeMux
+
+	now              func() time.Time
+	transitionTime   time.Duration
+	installStageTime time.Duration
+
+	token          string
+	user           client.User
+	keys           []*apiKey
+	sshKeys        []client.SSHKey
+	teams          []*team
+	vmTypes        []client.AvailableVirtualMachineTypes
+	bareMetalTypes []client.AvailableBareMetalTypes
+	lastID         int
+}
+
+// New creates a fake API with one user, an owner API key with DefaultToken
+// and no teams
+func New(opts ...Option) *Server {
+	s := &Server{
+		mux:              http.NewServeMux(),
+		now:              time.Now,
+		transitionTime:   DefaultTransitionTime,
+		installStageTime: DefaultInstallStageTime,
+		user: client.User{
+			Name:    "Fake User",
+			Email:   "fake@example.com",
+			Created: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		token:          DefaultToken,
+		vmTypes:        defaultVMTypes(),
+		bareMetalTypes: defaultBareMetalTypes(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.keys = []*apiKey{{
+		UserAPIKey: client.UserAPIKey{Prefix: tokenPrefix(s.token), Label: "default", UserRole: RoleOwner},
+		token:      s.token,
+	}}
+	s.routes()
+	return s
+}
+
+// Token returns the token of the API key the server started with
+func (s *Server) Token() string {
+	return s.token
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// access is what a caller needs for an operation, following the
+// x-requires-permissions of swagger.json
+type access int
+
+const (
+	userAny access = iota
+	userOwner
+	teamAny
+	teamPurchaser
+	teamOperator
+	teamOwner
+)
+
+// call is an authenticated request
+type call struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	key  *apiKey
+	team *team
+
+	// stream, if set, runs after the server is unlocked, for handlers that
+	// keep the connection open
+	stream func()
+}
+
+type handlerFunc func(c *call)
+
+// handle registers a handler for an API operation. The pattern is relative to
+// /api and matches the path exactly.
+func (s *Server) handle(method, path string, need access, h handlerFunc) {
+	s.mux.HandleFunc(method+" /api"+path+"{$}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		c := &call{w: w, r: r}
+		if s.authorize(c, need) {
+			h(c)
+		}
+		s.mu.Unlock()
+
+		if c.stream != nil {
+			c.stream()
+		}
+	})
+}
+
+// authorize authenticates the caller and checks it has the access needed,
+// writing the error response if not
+func (s *Server) authorize(c *call, need access) bool {
+	token := strings.TrimPrefix(c.r.Header.Get("Authorization"), "Token ")
+	if token != "" {
+		c.key = s.findKey(func(k *apiKey) bool { return k.token == token })
+	}
+	if c.key == nil {
+		writeError(c.w, http.StatusUnauthorized, "invalid or missing API key")
+		return false
+	}
+
+	switch need {
+	case userAny:
+		return true
+	case userOwner:
+		if c.key.UserRole != RoleOwner {
+			writeError(c.w, http.StatusForbidden, "this API key needs user role owner")
+			return false
+		}
+		return true
+	}
+
+	handle := c.r.PathValue("team")
+	c.team = s.findTeam(handle)
+	var roles []string
+	if c.team != nil {
+		roles = s.effectiveRoles(c.key, c.team)
+	}
+	if len(roles) == 0 {
+		writeError(c.w, http.StatusNotFound, "team %s not found", handle)
+		return false
+	}
+	allowed := map[access][]string{
+		teamAny:       teamRoles,
+		teamPurchaser: {RoleOwner, RolePurchaser},
+		teamOperator:  {RoleOwner, RoleOperator},
+		teamOwner:     {RoleOwner},
+	}[need]
+	for _, role := range roles {
+		if slices.Contains(allowed, role) {
+			return true
+		}
+	}
+	writeError(c.w, http.StatusForbidden, "this API key needs team role %s on team %s", allowed[len(allowed)-1], c.team.Handle)
+	return false
+}
+
+func (s *Server) routes() {
+	s.handle(http.MethodGet, "/user/", userAny, s.getUser)
+	s.handle(http.MethodPatch, "/user/", userOwner, s.updateUser)
+	s.handle(http.MethodGet, "/user/ssh_keys/", userAny, s.listSSHKeys)
+	s.handle(http.MethodPost, "/user/ssh_keys/", userOwner, s.addSSHKey)
+	s.handle(http.MethodDelete, "/user/ssh_keys/{fingerprint}/", userOwner, s.deleteSSHKey)
+	s.handle(http.MethodGet, "/user/api_keys/", userAny, s.listAPIKeys)
+	s.handle(http.MethodPost, "/user/api_keys/", userOwner, s.createAPIKey)
+	s.handle(http.MethodGet, "/user/api_keys/{prefix}/", userAny, s.getAPIKey)
+	s.handle(http.MethodPatch, "/user/api_keys/{prefix}/", userOwner, s.updateAPIKey)
+	s.handle(http.MethodDelete, "/user/api_keys/{prefix}/", userOwner, s.deleteAPIKey)
+
+	s.handle(http.MethodGet, "/teams/", userAny, s.listTeams)
+	s.handle(http.MethodPost, "/teams/", userOwner, s.createTeam)
+	s.handle(http.MethodGet, "/teams/invitations/", userAny, s.listInvitations)
+	s.handle(http.MethodGet, "/teams/{team}/", teamAny, s.getTeam)
+	s.handle(http.MethodPatch, "/teams/{team}/", teamOwner, s.updateTeam)
+	s.handle(http.MethodPost, "/teams/{team}/accept-invitation/", userOwner, s.acceptInvitation)
+	s.handle(http.MethodGet, "/teams/{team}/balance/", teamAny, s.getBalance)
+	s.handle(http.MethodPost, "/teams/{team}/purchase-credits/", teamPurchaser, s.purchaseCredits)
+	s.handle(http.MethodGet, "/teams/{team}/members/invitations/", teamAny, s.listTeamInvitations)
+	s.handle(http.MethodPost, "/teams/{team}/members/invitations/", teamOwner, s.inviteMember)
+	s.handle(http.MethodPatch, "/teams/{team}/members/{email}/", teamOwner, s.updateMember)
+	s.handle(http.MethodDelete, "/teams/{team}/members/{email}/", teamAny, s.removeMember)
+
+	s.handle(http.MethodGet, "/teams/{team}/virtual_machines/", teamAny, s.listVMs)
+	s.handle(http.MethodPost, "/teams/{team}/virtual_machines/", teamOperator, s.provisionVM)
+	s.handle(http.MethodGet, "/teams/{team}/virtual_machines/available/", teamAny, s.availableVMs)
+	s.handle(http.MethodGet, "/teams/{team}/virtual_machines/{vm}/", teamAny, s.getVM)
+	s.handle(http.MethodPatch, "/teams/{team}/virtual_machines/{vm}/", teamOperator, s.updateVM)
+	s.handle(http.MethodDelete, "/teams/{team}/virtual_machines/{vm}/", teamOperator, s.deleteVM)
+	s.handle(http.MethodGet, "/teams/{team}/virtual_machines/{vm}/state/", teamAny, s.getVMState)
+	s.handle(http.MethodGet, "/teams/{team}/virtual_machines/{vm}/console/", teamOperator, s.vmConsole)
+	for action, change := range vmActions {
+		s.handle(http.MethodPost, "/teams/{team}/virtual_machines/{vm}/"+action+"/", teamOperator, s.vmAction(change))
+	}
+
+	s.handle(http.MethodGet, "/teams/{team}/bare_metal/", teamAny, s.listServers)
+	s.handle(http.MethodPost, "/teams/{team}/bare_metal/", teamOperator, s.reserveServer)
+	s.handle(http.MethodGet, "/teams/{team}/bare_metal/available/", teamAny, s.availableServers)
+	s.handle(http.MethodGet, "/teams/{team}/bare_metal/{server}/", teamAny, s.getServer)
+	s.handle(http.MethodPatch, "/teams/{team}/bare_metal/{server}/", teamOperator, s.updateServer)
+	s.handle(http.MethodDelete, "/teams/{team}/bare_metal/{server}/", teamOperator, s.releaseServer)
+	s.handle(http.MethodPost, "/teams/{team}/bare_metal/{server}/console/", teamOperator, s.serverConsole)
+	s.handle(http.MethodGet, "/teams/{team}/bare_metal/{server}/power/", teamAny, s.getPower)
+	for action, change := range powerActions {
+		s.handle(http.MethodPost, "/teams/{team}/bare_metal/{server}/power/"+action+"/", teamOperator, s.powerAction(change))
+	}
+	s.handle(http.MethodPost, "/teams/{team}/bare_metal/{server}/reinstall/", teamOperator, s.reinstallServer)
+	s.handle(http.MethodPut, "/teams/{team}/bare_metal/{server}/support_access_enable/", teamOperator, s.setSupportAccess(true))
+	s.handle(http.MethodDelete, "/teams/{team}/bare_metal/{server}/support_access_enable/", teamOperator, s.setSupportAccess(false))
+}
+
+// nextID returns a new number for naming resources
+func (s *Server) nextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+// decode reads the JSON request body into v, rejecting unknown fields so that
+// requests drifting from swagger.json are noticed. It writes a 400 response
+// and returns false if the body is invalid.
+func decode(c *call, v any) bool {
+	decoder := json.NewDecoder(c.r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(c.w, http.StatusBadRequest, "invalid request body: %v", err)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error the way the API does, as a JSON string
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,438 @@
+package fakeapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// clock is a manual clock for stepping through state transitions
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// testEnv is a running fake with a client using its default API key
+type testEnv struct {
+	fake  *Server
+	srv   *httptest.Server
+	c     *client.Client
+	clock *clock
+}
+
+// newTestServer starts a fake with a team "my-team" holding $100 of credits
+func newTestServer(t *testing.T) testEnv {
+	t.Helper()
+	clk := &clock{now: time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC)}
+	fake := New(WithClock(clk.Now))
+	fake.AddTeam(client.Team{Handle: "my-team", Name: "My Team"}, 100_00)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return testEnv{fake: fake, srv: srv, c: newClient(srv, fake.Token()), clock: clk}
+}
+
+func newClient(srv *httptest.Server, token string) *client.Client {
+	return client.NewClient(
+		client.WithBaseURL(srv.URL+"/api"),
+		client.WithToken("Token "+token),
+		client.WithHTTPClient(srv.Client()),
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}),
+	)
+}
+
+func wantStatus(t *testing.T, err error, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("error = %v, want %v", err, want)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	env := newTestServer(t)
+	c := env.c
+	ctx := context.Background()
+
+	if _, err := c.User().Get(ctx); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	_, err := newClient(srv, "wrong").User().Get(ctx)
+	wantStatus(t, err, client.ErrUnauthorized)
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "\"invalid or missing API key\"\n" {
+		t.Errorf("error body = %q, want a JSON string", apiErr.Message)
+	}
+}
+
+func TestVirtualMachineLifecycle(t *testing.T) {
+	env := newTestServer(t)
+	c, clk := env.c, env.clock
+	ctx := context.Background()
+	vms := c.VirtualMachines()
+
+	vm, err := vms.Provision(ctx, "my-team", client.VMProvisionRequest{
+		VirtualMachineSpecs: client.VirtualMachineSpecs{GPUs: []client.GPUs{{Count: 1, Model: "mi300x"}}},
+	})
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	if vm.CPUCores == nil || *vm.CPUCores != 13 {
+		t.Errorf("Provision() cpu cores = %v, want the 13 of the MI300X type", vm.CPUCores)
+	}
+
+	wantState := func(want string) {
+		t.Helper()
+		state, err := vms.GetState(ctx, "my-team", vm.Name)
+		if err != nil {
+			t.Fatalf("GetState() error = %v", err)
+		}
+		if state.State != want {
+			t.Fatalf("state = %q, want %q", state.State, want)
+		}
+	}
+	wantState(vmStateProvisioning)
+	wantStatus(t, vms.Stop(ctx, "my-team", vm.Name), client.ErrConflict)
+
+	clk.Advance(DefaultTransitionTime)
+	wantState(client.VMStateRunning)
+
+	if err := vms.Shutdown(ctx, "my-team", vm.Name); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	wantState(vmStateShuttingDown)
+	clk.Advance(DefaultTransitionTime)
+	wantState(client.VMStateShutOff)
+	wantStatus(t, vms.Reboot(ctx, "my-team", vm.Name), client.ErrConflict)
+
+	if err := vms.Start(ctx, "my-team", vm.Name); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	clk.Advance(DefaultTransitionTime)
+	wantState(client.VMStateRunning)
+
+	if err := vms.Update(ctx, "my-team", vm.Name, client.VirtualMachineUpdate{Description: "trainer"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := vms.Get(ctx, "my-team", vm.Name)
+	if err != nil || got.Description != "trainer" {
+		t.Fatalf("Get() = %+v, %v, want description trainer", got, err)
+	}
+
+	if err := vms.Delete(ctx, "my-team", vm.Name); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	_, err = vms.Get(ctx, "my-team", vm.Name)
+	wantStatus(t, err, client.ErrNotFound)
+}
+
+func TestProvisionCapacityAndSpecs(t *testing.T) {
+	env := newTestServer(t)
+	c := env.c
+	ctx := context.Background()
+	vms := c.VirtualMachines()
+
+	_, err := vms.Provision(ctx, "my-team", client.VMProvisionRequest{
+		VirtualMachineSpecs: client.VirtualMachineSpecs{GPUs: []client.GPUs{{Count: 3, Model: "MI300X"}}},
+	})
+	wantStatus(t, err, client.ErrUnprocessable)
+
+	available, err := vms.GetAvailable(ctx, "my-team")
+	if err != nil {
+		t.Fatalf("GetAvailable() error = %v", err)
+	}
+	small := client.VMProvisionRequest{VirtualMachineSpecs: client.VirtualMachineSpecs{CPUCores: available[1].Specs.CPUCores}}
+	for range available[1].Quantity {
+		if _, err := vms.Provision(ctx, "my-team", small); err != nil {
+			t.Fatalf("Provision() error = %v", err)
+		}
+	}
+	_, err = vms.Provision(ctx, "my-team", small)
+	wantStatus(t, err, client.ErrConflict)
+}
+
+func TestBalance(t *testing.T) {
+	env := newTestServer(t)
+	fake, c, clk := env.fake, env.c, env.clock
+	ctx := context.Background()
+
+	if _, err := c.VirtualMachines().Provision(ctx, "my-team", client.VMProvisionRequest{
+		VirtualMachineSpecs: client.VirtualMachineSpecs{GPUs: []client.GPUs{{Count: 1}}},
+	}); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	clk.Advance(2 * time.Hour)
+
+	balance, err := c.Teams().GetBalance(ctx, "my-team")
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if balance.AvailableBalance != 100_00-2*199 || balance.HourlyRate != 199 || balance.VirtualMachineCount != 1 {
+		t.Errorf("GetBalance() = %+v, want 9602 left at 199 an hour for 1 VM", balance)
+	}
+	wantRunout := clk.Now().Add(time.Duration(9602 * int64(time.Hour) / 199)).Truncate(time.Second)
+	if balance.EstimatedRunoutTime == nil || !balance.EstimatedRunoutTime.Equal(wantRunout) {
+		t.Errorf("EstimatedRunoutTime = %v, want %v", balance.EstimatedRunoutTime, wantRunout)
+	}
+
+	if err := fake.SetMinimumBalance("my-team", 9500); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.BareMetal().Reserve(ctx, "my-team", client.BareMetalServerReservation{})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("Reserve() error = %v, want 402", err)
+	}
+
+	if _, err := c.Teams().PurchaseCredits(ctx, "my-team", client.PurchaseTeamCreditsRequest{Cents: 1000_00}); err != nil {
+		t.Fatalf("PurchaseCredits() error = %v", err)
+	}
+	if _, err := c.BareMetal().Reserve(ctx, "my-team", client.BareMetalServerReservation{}); err != nil {
+		t.Fatalf("Reserve() after purchase error = %v", err)
+	}
+}
+
+func TestBareMetalInstall(t *testing.T) {
+	env := newTestServer(t)
+	fake, c, clk := env.fake, env.c, env.clock
+	ctx := context.Background()
+	bm := c.BareMetal()
+	if _, err := c.Teams().PurchaseCredits(ctx, "my-team", client.PurchaseTeamCreditsRequest{Cents: 1000_00}); err != nil {
+		t.Fatal(err)
+	}
+
+	reserved, err := bm.Reserve(ctx, "my-team", client.BareMetalServerReservation{
+		Description: "inference",
+		Specs:       client.BareMetalServerSpecs{CPUCores: 104},
+	})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if reserved.OSStatus.OSStatus != client.OSStatusShuttingDown {
+		t.Errorf("Reserve() os status = %q, want the first install stage", reserved.OSStatus.OSStatus)
+	}
+	_, err = bm.Reinstall(ctx, "my-team", reserved.Name)
+	wantStatus(t, err, client.ErrConflict)
+
+	clk.Advance(4 * DefaultInstallStageTime)
+	server, err := bm.Get(ctx, "my-team", reserved.Name)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if server.OSStatus.OSStatus != client.OSStatusInstallingOS {
+		t.Errorf("os status = %q, want %q", server.OSStatus.OSStatus, client.OSStatusInstallingOS)
+	}
+
+	clk.Advance(time.Hour)
+	server, _ = bm.Get(ctx, "my-team", reserved.Name)
+	if server.OSStatus.OSStatus != client.OSStatusInstalled {
+		t.Fatalf("os status = %q, want installed", server.OSStatus.OSStatus)
+	}
+
+	if _, err := bm.Reinstall(ctx, "my-team", reserved.Name); err != nil {
+		t.Fatalf("Reinstall() error = %v", err)
+	}
+	clk.Advance(DefaultInstallStageTime)
+	if err := fake.FailInstall("my-team", reserved.Name); err != nil {
+		t.Fatal(err)
+	}
+	_, err = bm.WaitForInstall(ctx, "my-team", reserved.Name, time.Time{}, client.WaitConfig{Interval: time.Millisecond}, nil)
+	wantStatus(t, err, client.ErrOSInstallFailed)
+}
+
+func TestBareMetalPower(t *testing.T) {
+	env := newTestServer(t)
+	c, clk := env.c, env.clock
+	ctx := context.Background()
+	bm := c.BareMetal()
+	if _, err := c.Teams().PurchaseCredits(ctx, "my-team", client.PurchaseTeamCreditsRequest{Cents: 1000_00}); err != nil {
+		t.Fatal(err)
+	}
+	server, err := bm.Reserve(ctx, "my-team", client.BareMetalServerReservation{})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+
+	wantPower := func(want string) {
+		t.Helper()
+		power, err := bm.GetPowerState(ctx, "my-team", server.Name)
+		if err != nil || power.State != want {
+			t.Fatalf("GetPowerState() = %v, %v, want %s", power, err, want)
+		}
+	}
+	if err := bm.GracefulShutdown(ctx, "my-team", server.Name); err != nil {
+		t.Fatalf("GracefulShutdown() error = %v", err)
+	}
+	wantPower(powerOn)
+	clk.Advance(DefaultTransitionTime)
+	wantPower(powerOff)
+	wantStatus(t, bm.WarmReboot(ctx, "my-team", server.Name), client.ErrConflict)
+	if err := bm.PowerOn(ctx, "my-team", server.Name); err != nil {
+		t.Fatalf("PowerOn() error = %v", err)
+	}
+	wantPower(powerOn)
+
+	if err := bm.Delete(ctx, "my-team", server.Name); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	wantStatus(t, bm.PowerOn(ctx, "my-team", server.Name), client.ErrNotFound)
+}
+
+func TestTeamsAndInvitations(t *testing.T) {
+	env := newTestServer(t)
+	fake, c := env.fake, env.c
+	ctx := context.Background()
+	teams := c.Teams()
+
+	fake.AddInvitation(client.Team{Handle: "other", Name: "Other"}, RoleOperator)
+	invitations, err := teams.GetInvitations(ctx)
+	if err != nil || len(invitations) != 1 || !invitations[0].Invitation {
+		t.Fatalf("GetInvitations() = %+v, %v, want one invitation", invitations, err)
+	}
+	_, err = teams.Get(ctx, "other")
+	wantStatus(t, err, client.ErrNotFound)
+	if _, err := teams.AcceptInvitation(ctx, "other"); err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+	// An operator cannot invite members
+	err = teams.InviteMember(ctx, "other", client.TeamInvitationRequest{Name: "A", Email: "a@example.com", Roles: []string{RoleUser}})
+	wantStatus(t, err, client.ErrForbidden)
+
+	if _, err := teams.Create(ctx, client.Team{Handle: "my-team", Name: "Again"}); !errors.Is(err, client.ErrConflict) {
+		t.Fatalf("Create() duplicate error = %v, want conflict", err)
+	}
+	if err := teams.InviteMember(ctx, "my-team", client.TeamInvitationRequest{Name: "A", Email: "a@example.com", Roles: []string{RoleUser}}); err != nil {
+		t.Fatalf("InviteMember() error = %v", err)
+	}
+	member, err := teams.UpdateMember(ctx, "my-team", "a@example.com", client.TeamMemberUpdate{Roles: []string{RoleOperator}})
+	if err != nil || member.Roles[0] != RoleOperator {
+		t.Fatalf("UpdateMember() = %+v, %v", member, err)
+	}
+	pending, err := teams.GetTeamInvitations(ctx, "my-team")
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("GetTeamInvitations() = %+v, %v, want one", pending, err)
+	}
+	if err := teams.RemoveMember(ctx, "my-team", "a@example.com"); err != nil {
+		t.Fatalf("RemoveMember() error = %v", err)
+	}
+
+	list, err := teams.List(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List() = %+v, %v, want 2 teams", list, err)
+	}
+}
+
+func TestSSHKeys(t *testing.T) {
+	env := newTestServer(t)
+	c := env.c
+	ctx := context.Background()
+	user := c.User()
+
+	// Fingerprint as printed by ssh-keygen -l
+	authorizedKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl me@example.com"
+	key, err := user.AddSSHKey(ctx, client.SSHKeyRequest{AuthorizedKey: authorizedKey})
+	if err != nil {
+		t.Fatalf("AddSSHKey() error = %v", err)
+	}
+	if key.Type != "ssh-ed25519" || key.Comment != "me@example.com" || key.Fingerprint != "SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU" {
+		t.Errorf("AddSSHKey() = %+v", key)
+	}
+	_, err = user.AddSSHKey(ctx, client.SSHKeyRequest{AuthorizedKey: authorizedKey})
+	wantStatus(t, err, client.ErrConflict)
+	_, err = user.AddSSHKey(ctx, client.SSHKeyRequest{AuthorizedKey: "not a key"})
+	wantStatus(t, err, client.ErrUnprocessable)
+
+	if err := user.DeleteSSHKey(ctx, key.Fingerprint); err != nil {
+		t.Fatalf("DeleteSSHKey() error = %v", err)
+	}
+	keys, err := user.GetSSHKeys(ctx)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("GetSSHKeys() = %v, %v, want none", keys, err)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	env := newTestServer(t)
+	c := env.c
+	ctx := context.Background()
+
+	created, err := c.User().CreateAPIKey(ctx, client.UserAPIKeyRequest{
+		Label: "ci",
+		Teams: []client.UserAPIKeyTeamRoles{{Team: "my-team", Roles: []string{RoleUser}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	if created.Token == "" || !strings.HasPrefix(created.Token, created.Prefix) || created.UserRole != RoleUser {
+		t.Fatalf("CreateAPIKey() = %+v", created)
+	}
+
+	// The new key is limited to reading my-team
+	restricted := newClient(env.srv, created.Token)
+	if _, err := restricted.VirtualMachines().List(ctx, "my-team"); err != nil {
+		t.Fatalf("List() with restricted key error = %v", err)
+	}
+	_, err = restricted.VirtualMachines().Provision(ctx, "my-team", client.VMProvisionRequest{})
+	wantStatus(t, err, client.ErrForbidden)
+	_, err = restricted.User().CreateAPIKey(ctx, client.UserAPIKeyRequest{})
+	wantStatus(t, err, client.ErrForbidden)
+
+	if err := c.User().DeleteAPIKey(ctx, created.Prefix); err != nil {
+		t.Fatalf("DeleteAPIKey() error = %v", err)
+	}
+	_, err = restricted.User().Get(ctx)
+	wantStatus(t, err, client.ErrUnauthorized)
+}
+
+func TestVMConsoleEchoes(t *testing.T) {
+	env := newTestServer(t)
+	c := env.c
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vm, err := c.VirtualMachines().Provision(ctx, "my-team", client.VMProvisionRequest{
+		VirtualMachineSpecs: client.VirtualMachineSpecs{CPUCores: ptr[uint64](2)},
+	})
+	if err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	console, err := c.VirtualMachines().Console(ctx, "my-team", vm.Name)
+	if err != nil {
+		t.Fatalf("Console() error = %v", err)
+	}
+	defer console.Close()
+
+	if _, err := console.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := "Connected to the serial console of " + vm.Name + "\r\nhello"
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(console, got); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("console output = %q, want %q", got, want)
+	}
+}
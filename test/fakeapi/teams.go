@@ -0,0 +1,356 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"hotaisle-cli/client"
+)
+
+// team is a team with its members, resources and credits. Balance is charged
+// by the second for every resource at its hourly price.
+type team struct {
+	client.Team
+	members []*client.TeamMember
+	vms     []*vm
+	servers []*server
+
+	credits        int64
+	spent          int64
+	minimumBalance int64
+}
+
+// resource is what is billed: a VM or bare metal server
+type resource struct {
+	price   int64
+	created time.Time
+}
+
+// cost is what a resource has consumed until now, in cents
+func (r resource) cost(now time.Time) int64 {
+	return r.price * int64(now.Sub(r.created)/time.Second) / 3600
+}
+
+func (s *Server) findTeam(handle string) *team {
+	for _, t := range s.teams {
+		if t.Handle == handle {
+			return t
+		}
+	}
+	return nil
+}
+
+// member returns the member or invitation with the given email, or nil
+func (t *team) member(email string) *client.TeamMember {
+	for _, m := range t.members {
+		if m.Email == email {
+			return m
+		}
+	}
+	return nil
+}
+
+func (t *team) hourlyRate() int64 {
+	var rate int64
+	for _, vm := range t.vms {
+		rate += vm.price
+	}
+	for _, server := range t.servers {
+		rate += server.price
+	}
+	return rate
+}
+
+func (t *team) balance(now time.Time) int64 {
+	balance := t.credits - t.spent
+	for _, vm := range t.vms {
+		balance -= vm.cost(now)
+	}
+	for _, server := range t.servers {
+		balance -= server.cost(now)
+	}
+	return balance
+}
+
+// canAfford reports whether the team can start paying an extra hourly price
+// without going under its minimum balance
+func (t *team) canAfford(now time.Time, price int64) bool {
+	return t.balance(now)-price >= t.minimumBalance
+}
+
+// AddTeam creates a team owned by the user with the given credits in cents
+func (s *Server) AddTeam(t client.Team, credits int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams = append(s.teams, &team{
+		Team:    t,
+		members: []*client.TeamMember{s.ownerMember()},
+		credits: credits,
+	})
+}
+
+// AddInvitation creates a team the user is invited to with the given roles
+func (s *Server) AddInvitation(t client.Team, roles ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams = append(s.teams, &team{
+		Team: t,
+		members: []*client.TeamMember{
+			{Name: s.user.Name, Email: s.user.Email, Created: s.now(), Roles: roles, Invitation: true},
+		},
+	})
+}
+
+// SetMinimumBalance sets the balance in cents a team must keep, below which
+// provisioning fails with 402 Payment Required
+func (s *Server) SetMinimumBalance(handle string, cents int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findTeam(handle)
+	if t == nil {
+		return fmt.Errorf("team %s not found", handle)
+	}
+	t.minimumBalance = cents
+	return nil
+}
+
+func (s *Server) ownerMember() *client.TeamMember {
+	return &client.TeamMember{Name: s.user.Name, Email: s.user.Email, Created: s.now(), Roles: []string{RoleOwner}}
+}
+
+// userTeam renders a team as seen by the user with the given key
+func (s *Server) userTeam(key *apiKey, t *team) client.UserTeam {
+	m := t.member(s.user.Email)
+	return client.UserTeam{
+		Team:           t.Team,
+		Roles:          m.Roles,
+		EffectiveRoles: s.effectiveRoles(key, t),
+		Invitation:     m.Invitation,
+	}
+}
+
+func (s *Server) teamWithMembers(key *apiKey, t *team) client.UserTeamWithMembers {
+	members := make([]client.TeamMember, len(t.members))
+	for i, m := range t.members {
+		members[i] = *m
+	}
+	return client.UserTeamWithMembers{UserTeam: s.userTeam(key, t), Members: members}
+}
+
+func (s *Server) listTeams(c *call) {
+	teams := []client.UserTeam{}
+	for _, t := range s.teams {
+		if len(s.effectiveRoles(c.key, t)) > 0 {
+			teams = append(teams, s.userTeam(c.key, t))
+		}
+	}
+	writeJSON(c.w, http.StatusOK, teams)
+}
+
+func (s *Server) createTeam(c *call) {
+	var req client.Team
+	if !decode(c, &req) {
+		return
+	}
+	if req.Handle == "" || req.Name == "" {
+		writeError(c.w, http.StatusUnprocessableEntity, "handle and name are required")
+		return
+	}
+	if s.findTeam(req.Handle) != nil {
+		writeError(c.w, http.StatusConflict, "team %s already exists", req.Handle)
+		return
+	}
+	t := &team{
+		Team:    client.Team{Handle: req.Handle, Name: req.Name, Description: req.Description},
+		members: []*client.TeamMember{s.ownerMember()},
+	}
+	s.teams = append(s.teams, t)
+	writeJSON(c.w, http.StatusOK, s.teamWithMembers(c.key, t))
+}
+
+func (s *Server) listInvitations(c *call) {
+	teams := []client.UserTeam{}
+	for _, t := range s.teams {
+		if m := t.member(s.user.Email); m != nil && m.Invitation {
+			teams = append(teams, s.userTeam(c.key, t))
+		}
+	}
+	writeJSON(c.w, http.StatusOK, teams)
+}
+
+func (s *Server) getTeam(c *call) {
+	details := client.UserTeamDetails{UserTeamWithMembers: s.teamWithMembers(c.key, c.team)}
+	for _, vm := range c.team.vms {
+		details.VirtualMachines = append(details.VirtualMachines, vm.VirtualMachine)
+	}
+	for _, server := range c.team.servers {
+		details.BareMetalServers = append(details.BareMetalServers, server.BareMetalServer)
+	}
+	writeJSON(c.w, http.StatusOK, details)
+}
+
+func (s *Server) updateTeam(c *call) {
+	var update client.TeamUpdate
+	if !decode(c, &update) {
+		return
+	}
+	if update.Handle != "" && update.Handle != c.team.Handle {
+		if s.findTeam(update.Handle) != nil {
+			writeError(c.w, http.StatusConflict, "team %s already exists", update.Handle)
+			return
+		}
+		for _, k := range s.keys {
+			if roles, ok := k.teams[c.team.Handle]; ok {
+				delete(k.teams, c.team.Handle)
+				k.teams[update.Handle] = roles
+			}
+		}
+		c.team.Handle = update.Handle
+	}
+	if update.Name != "" {
+		c.team.Name = update.Name
+	}
+	if update.Description != "" {
+		c.team.Description = update.Description
+	}
+	writeJSON(c.w, http.StatusOK, s.teamWithMembers(c.key, c.team))
+}
+
+func (s *Server) acceptInvitation(c *call) {
+	handle := c.r.PathValue("team")
+	t := s.findTeam(handle)
+	var m *client.TeamMember
+	if t != nil {
+		m = t.member(s.user.Email)
+	}
+	if m == nil || !m.Invitation {
+		writeError(c.w, http.StatusNotFound, "no invitation to team %s", handle)
+		return
+	}
+	m.Invitation = false
+	writeJSON(c.w, http.StatusOK, s.teamWithMembers(c.key, t))
+}
+
+func (s *Server) getBalance(c *call) {
+	now := s.now()
+	info := client.BalanceInfo{
+		AvailableBalance:     c.team.balance(now),
+		HourlyRate:           c.team.hourlyRate(),
+		VirtualMachineCount:  int64(len(c.team.vms)),
+		BareMetalServerCount: int64(len(c.team.servers)),
+		MinimumBalance:       c.team.minimumBalance,
+	}
+	if info.HourlyRate > 0 && info.AvailableBalance > 0 {
+		runout := now.Add(time.Duration(info.AvailableBalance * int64(time.Hour) / info.HourlyRate)).Truncate(time.Second)
+		info.EstimatedRunoutTime = &runout
+	}
+	writeJSON(c.w, http.StatusOK, info)
+}
+
+// purchaseCredits adds the credits right away, as if the checkout was completed
+func (s *Server) purchaseCredits(c *call) {
+	var req client.PurchaseTeamCreditsRequest
+	if !decode(c, &req) {
+		return
+	}
+	if req.Cents <= 0 {
+		writeError(c.w, http.StatusBadRequest, "cents must be positive")
+		return
+	}
+	c.team.credits += req.Cents
+	writeJSON(c.w, http.StatusOK, client.PurchaseTeamCreditsResponse{
+		CheckoutURL: fmt.Sprintf("https://checkout.fake.invalid/%s/%d", c.team.Handle, s.nextID()),
+		ExpiresAt:   s.now().Add(30 * time.Minute),
+	})
+}
+
+func (s *Server) listTeamInvitations(c *call) {
+	invitations := []client.TeamMember{}
+	for _, m := range c.team.members {
+		if m.Invitation {
+			invitations = append(invitations, *m)
+		}
+	}
+	writeJSON(c.w, http.StatusOK, invitations)
+}
+
+func (s *Server) inviteMember(c *call) {
+	var req client.TeamInvitationRequest
+	if !decode(c, &req) {
+		return
+	}
+	if req.Email == "" || req.Name == "" {
+		writeError(c.w, http.StatusUnprocessableEntity, "name and email are required")
+		return
+	}
+	if !validRoles(c, req.Roles) {
+		return
+	}
+	if c.team.member(req.Email) != nil {
+		writeError(c.w, http.StatusConflict, "%s is already a member of team %s", req.Email, c.team.Handle)
+		return
+	}
+	c.team.members = append(c.team.members, &client.TeamMember{
+		Name: req.Name, Email: req.Email, Created: s.now(), Roles: req.Roles, Invitation: true,
+	})
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+// validRoles checks that roles are team roles, writing a 422 response if not
+func validRoles(c *call, roles []string) bool {
+	if len(roles) == 0 {
+		writeError(c.w, http.StatusUnprocessableEntity, "at least one role is required")
+		return false
+	}
+	for _, role := range roles {
+		if !slices.Contains(teamRoles, role) {
+			writeError(c.w, http.StatusUnprocessableEntity, "invalid team role %q", role)
+			return false
+		}
+	}
+	return true
+}
+
+// memberFromPath looks up the member named by the email in the path, writing a 404 if it does not exist
+func memberFromPath(c *call) *client.TeamMember {
+	email := c.r.PathValue("email")
+	m := c.team.member(email)
+	if m == nil {
+		writeError(c.w, http.StatusNotFound, "%s is not a member of team %s", email, c.team.Handle)
+	}
+	return m
+}
+
+func (s *Server) updateMember(c *call) {
+	m := memberFromPath(c)
+	if m == nil {
+		return
+	}
+	var update client.TeamMemberUpdate
+	if !decode(c, &update) || !validRoles(c, update.Roles) {
+		return
+	}
+	m.Roles = update.Roles
+	writeJSON(c.w, http.StatusOK, m)
+}
+
+// removeMember needs team role owner to remove someone else, or user role owner to leave the team
+func (s *Server) removeMember(c *call) {
+	m := memberFromPath(c)
+	if m == nil {
+		return
+	}
+	if m.Email == s.user.Email {
+		if c.key.UserRole != RoleOwner {
+			writeError(c.w, http.StatusForbidden, "this API key needs user role owner")
+			return
+		}
+	} else if !slices.Contains(s.effectiveRoles(c.key, c.team), RoleOwner) {
+		writeError(c.w, http.StatusForbidden, "this API key needs team role owner on team %s", c.team.Handle)
+		return
+	}
+	c.team.members = slices.DeleteFunc(c.team.members, func(other *client.TeamMember) bool { return other == m })
+	c.w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,254 @@
+package fakeapi
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+
+	"hotaisle-cli/client"
+)
+
+// apiKey is an API key of the user. Keys without team restrictions have all
+// the roles of the user on every team.
+type apiKey struct {
+	client.UserAPIKey
+	token string
+	// teams restricts the key to these roles per team handle, or nil for no restriction
+	teams map[string][]string
+}
+
+// tokenPrefix is the part of a token that identifies its key
+func tokenPrefix(token string) string {
+	return token[:min(len(token), 24)]
+}
+
+func (s *Server) findKey(match func(*apiKey) bool) *apiKey {
+	for _, k := range s.keys {
+		if match(k) {
+			return k
+		}
+	}
+	return nil
+}
+
+// effectiveRoles returns the roles a key has on a team
+func (s *Server) effectiveRoles(key *apiKey, t *team) []string {
+	m := t.member(s.user.Email)
+	if m == nil || m.Invitation {
+		return nil
+	}
+	if key.teams == nil {
+		return m.Roles
+	}
+	// Owners can do everything, so they can hand out any role to a key
+	granted := m.Roles
+	if slices.Contains(granted, RoleOwner) {
+		granted = teamRoles
+	}
+	var roles []string
+	for _, role := range key.teams[t.Handle] {
+		if slices.Contains(granted, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// apiKey renders a key as the API returns it
+func (s *Server) apiKey(k *apiKey) client.UserAPIKey {
+	key := k.UserAPIKey
+	key.Teams = nil
+	for _, t := range s.teams {
+		if roles, ok := k.teams[t.Handle]; ok {
+			key.Teams = append(key.Teams, client.APIKeyTeam{Team: t.Team, Roles: roles})
+		}
+	}
+	return key
+}
+
+func (s *Server) getUser(c *call) {
+	resp := client.GetUserResponse{User: s.user, Teams: []client.UserTeam{}}
+	for _, t := range s.teams {
+		if m := t.member(s.user.Email); m != nil {
+			resp.Teams = append(resp.Teams, s.userTeam(c.key, t))
+		}
+	}
+	writeJSON(c.w, http.StatusOK, resp)
+}
+
+func (s *Server) updateUser(c *call) {
+	var update client.UserUpdate
+	if !decode(c, &update) {
+		return
+	}
+	if update.Name == "" {
+		writeError(c.w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+	s.user.Name = update.Name
+	for _, t := range s.teams {
+		if m := t.member(s.user.Email); m != nil {
+			m.Name = update.Name
+		}
+	}
+	writeJSON(c.w, http.StatusOK, s.user)
+}
+
+func (s *Server) listSSHKeys(c *call) {
+	writeJSON(c.w, http.StatusOK, append([]client.SSHKey{}, s.sshKeys...))
+}
+
+func (s *Server) addSSHKey(c *call) {
+	var req client.SSHKeyRequest
+	if !decode(c, &req) {
+		return
+	}
+	key, ok := parseAuthorizedKey(req.AuthorizedKey)
+	if !ok {
+		writeError(c.w, http.StatusUnprocessableEntity, "invalid authorized key")
+		return
+	}
+	if slices.ContainsFunc(s.sshKeys, func(k client.SSHKey) bool { return k.Fingerprint == key.Fingerprint }) {
+		writeError(c.w, http.StatusConflict, "SSH key %s already exists", key.Fingerprint)
+		return
+	}
+	s.sshKeys = append(s.sshKeys, key)
+	writeJSON(c.w, http.StatusOK, key)
+}
+
+// parseAuthorizedKey parses a line of an authorized_keys file, computing the
+// fingerprint the way ssh-keygen -l does
+func parseAuthorizedKey(line string) (client.SSHKey, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ssh-") && !strings.HasPrefix(fields[0], "ecdsa-") {
+		return client.SSHKey{}, false
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil || len(blob) == 0 {
+		return client.SSHKey{}, false
+	}
+	sum := sha256.Sum256(blob)
+	return client.SSHKey{
+		Type:        fields[0],
+		PublicKey:   fields[1],
+		Fingerprint: "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+		Comment:     strings.Join(fields[2:], " "),
+	}, true
+}
+
+func (s *Server) deleteSSHKey(c *call) {
+	fingerprint := c.r.PathValue("fingerprint")
+	i := slices.IndexFunc(s.sshKeys, func(k client.SSHKey) bool { return k.Fingerprint == fingerprint })
+	if i < 0 {
+		writeError(c.w, http.StatusNotFound, "SSH key %s not found", fingerprint)
+		return
+	}
+	s.sshKeys = slices.Delete(s.sshKeys, i, i+1)
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listAPIKeys(c *call) {
+	keys := []client.UserAPIKey{}
+	for _, k := range s.keys {
+		keys = append(keys, s.apiKey(k))
+	}
+	writeJSON(c.w, http.StatusOK, keys)
+}
+
+// keyFromPath looks up the key named by the prefix in the path, writing a 404 if it does not exist
+func (s *Server) keyFromPath(c *call) *apiKey {
+	prefix := c.r.PathValue("prefix")
+	k := s.findKey(func(k *apiKey) bool { return k.Prefix == prefix })
+	if k == nil {
+		writeError(c.w, http.StatusNotFound, "API key %s not found", prefix)
+	}
+	return k
+}
+
+func (s *Server) getAPIKey(c *call) {
+	if k := s.keyFromPath(c); k != nil {
+		writeJSON(c.w, http.StatusOK, s.apiKey(k))
+	}
+}
+
+func (s *Server) createAPIKey(c *call) {
+	var req client.UserAPIKeyRequest
+	if !decode(c, &req) {
+		return
+	}
+	k := &apiKey{UserAPIKey: client.UserAPIKey{Label: req.Label, UserRole: RoleUser}}
+	if !s.applyKeyRequest(c, k, req) {
+		return
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	k.token = hex.EncodeToString(secret)
+	k.Prefix = tokenPrefix(k.token)
+	s.keys = append(s.keys, k)
+	writeJSON(c.w, http.StatusOK, client.UserAPIKeyWithToken{UserAPIKey: s.apiKey(k), Token: k.token})
+}
+
+func (s *Server) updateAPIKey(c *call) {
+	k := s.keyFromPath(c)
+	if k == nil {
+		return
+	}
+	var req client.UserAPIKeyRequest
+	if !decode(c, &req) {
+		return
+	}
+	if s.applyKeyRequest(c, k, req) {
+		writeJSON(c.w, http.StatusOK, s.apiKey(k))
+	}
+}
+
+// applyKeyRequest validates a key request and sets the fields it has on k,
+// writing a 422 response and returning false if it is invalid
+func (s *Server) applyKeyRequest(c *call, k *apiKey, req client.UserAPIKeyRequest) bool {
+	if req.UserRole != "" && req.UserRole != RoleOwner && req.UserRole != RoleUser {
+		writeError(c.w, http.StatusUnprocessableEntity, "invalid user role %q", req.UserRole)
+		return false
+	}
+	var teams map[string][]string
+	for _, t := range req.Teams {
+		if s.findTeam(t.Team) == nil {
+			writeError(c.w, http.StatusUnprocessableEntity, "team %s not found", t.Team)
+			return false
+		}
+		for _, role := range t.Roles {
+			if !slices.Contains(teamRoles, role) {
+				writeError(c.w, http.StatusUnprocessableEntity, "invalid team role %q", role)
+				return false
+			}
+		}
+		if teams == nil {
+			teams = map[string][]string{}
+		}
+		teams[t.Team] = t.Roles
+	}
+
+	if req.Label != "" {
+		k.Label = req.Label
+	}
+	if req.UserRole != "" {
+		k.UserRole = req.UserRole
+	}
+	if teams != nil {
+		k.teams = teams
+	}
+	return true
+}
+
+func (s *Server) deleteAPIKey(c *call) {
+	k := s.keyFromPath(c)
+	if k == nil {
+		return
+	}
+	s.keys = slices.DeleteFunc(s.keys, func(other *apiKey) bool { return other == k })
+	c.w.WriteHeader(http.StatusNoContent)
+}
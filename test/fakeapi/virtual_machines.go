@@ -0,0 +1,303 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"hotaisle-cli/client"
+
+	"github.com/coder/websocket"
+)
+
+// Transitional VM states, reported while a state change is in progress
+const (
+	vmStateProvisioning = "provisioning"
+	vmStateStarting     = "starting"
+	vmStateShuttingDown = "shutting down"
+	vmStateRebooting    = "rebooting"
+	vmStateRebuilding   = "rebuilding"
+)
+
+// vm is a virtual machine. Its state moves to target at settleAt.
+type vm struct {
+	client.VirtualMachineDetails
+	resource
+	vmType   int
+	host     string
+	state    string
+	target   string
+	settleAt time.Time
+}
+
+// settle completes the state change in progress once its time has come
+func (v *vm) settle(now time.Time) {
+	if !v.settleAt.IsZero() && !now.Before(v.settleAt) {
+		v.state = v.target
+		v.settleAt = time.Time{}
+	}
+}
+
+// vmChange is a VM action: the states it is allowed from, the state reported
+// while it is in progress, or "" if it is immediate, and the resulting state
+type vmChange struct {
+	from []string
+	via  string
+	to   string
+	// rebuild actions take a client.VMResetRequest
+	rebuild bool
+}
+
+var vmActions = map[string]vmChange{
+	"start":      {from: []string{client.VMStateShutOff}, via: vmStateStarting, to: client.VMStateRunning},
+	"stop":       {from: []string{client.VMStateRunning, client.VMStatePaused}, to: client.VMStateShutOff},
+	"shutdown":   {from: []string{client.VMStateRunning}, via: vmStateShuttingDown, to: client.VMStateShutOff},
+	"reboot":     {from: []string{client.VMStateRunning}, via: vmStateRebooting, to: client.VMStateRunning},
+	"hard-reset": {from: []string{client.VMStateRunning, client.VMStatePaused}, to: client.VMStateRunning},
+	"rebuild": {
+		from:    []string{client.VMStateRunning, client.VMStateShutOff, client.VMStatePaused},
+		via:     vmStateRebuilding,
+		to:      client.VMStateRunning,
+		rebuild: true,
+	},
+}
+
+func defaultVMTypes() []client.AvailableVirtualMachineTypes {
+	return []client.AvailableVirtualMachineTypes{
+		{
+			Quantity:                  8,
+			MinimumReservationMinutes: 30,
+			OnDemandPrice:             199,
+			Specs: client.VirtualMachineSpecs{
+				CPUCores:     ptr[uint64](13),
+				RAMCapacity:  ptr[uint64](224 << 30),
+				DiskCapacity: ptr[uint64](12 << 40),
+				GPUs:         []client.GPUs{{Count: 1, Manufacturer: "AMD", Model: "MI300X"}},
+			},
+		},
+		{
+			Quantity:                  16,
+			MinimumReservationMinutes: 30,
+			OnDemandPrice:             10,
+			Specs: client.VirtualMachineSpecs{
+				CPUCores:     ptr[uint64](2),
+				RAMCapacity:  ptr[uint64](8 << 30),
+				DiskCapacity: ptr[uint64](100 << 30),
+			},
+		},
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// gpusMatch reports whether GPUs fulfill the requested ones, which may leave
+// out the model
+func gpusMatch(want, have []client.GPUs) bool {
+	if len(want) == 0 {
+		return true
+	}
+	var wantCount, haveCount uint64
+	for _, gpu := range want {
+		wantCount += gpu.Count
+		if gpu.Model != "" && !slices.ContainsFunc(have, func(h client.GPUs) bool { return strings.EqualFold(h.Model, gpu.Model) }) {
+			return false
+		}
+	}
+	for _, gpu := range have {
+		haveCount += gpu.Count
+	}
+	return wantCount == haveCount
+}
+
+// matchVMType returns the first type with capacity matching the requested CPU
+// cores and GPUs, or -1. RAM and disk come with the type. soldOut reports
+// that a type matched but has none left.
+func (s *Server) matchVMType(req client.VMProvisionRequest) (index int, soldOut bool) {
+	for i, t := range s.vmTypes {
+		if req.CPUCores != nil && (t.Specs.CPUCores == nil || *req.CPUCores != *t.Specs.CPUCores) {
+			continue
+		}
+		if !gpusMatch(req.GPUs, t.Specs.GPUs) {
+			continue
+		}
+		if t.Quantity <= 0 {
+			soldOut = true
+			continue
+		}
+		return i, false
+	}
+	return -1, soldOut
+}
+
+// vmFromPath looks up the VM named in the path, writing a 404 if it does not exist
+func (s *Server) vmFromPath(c *call) *vm {
+	name := c.r.PathValue("vm")
+	for _, v := range c.team.vms {
+		if v.Name == name {
+			v.settle(s.now())
+			return v
+		}
+	}
+	writeError(c.w, http.StatusNotFound, "virtual machine %s not found", name)
+	return nil
+}
+
+func (s *Server) listVMs(c *call) {
+	vms := []client.VirtualMachineDetails{}
+	for _, v := range c.team.vms {
+		vms = append(vms, v.VirtualMachineDetails)
+	}
+	writeJSON(c.w, http.StatusOK, vms)
+}
+
+func (s *Server) availableVMs(c *call) {
+	writeJSON(c.w, http.StatusOK, s.vmTypes)
+}
+
+func (s *Server) provisionVM(c *call) {
+	var req client.VMProvisionRequest
+	if !decode(c, &req) {
+		return
+	}
+	if limit := c.team.MaximumVirtualMachines; limit > 0 && int64(len(c.team.vms)) >= limit {
+		writeError(c.w, http.StatusUnprocessableEntity, "team %s has reached its limit of %d virtual machines", c.team.Handle, limit)
+		return
+	}
+	index, soldOut := s.matchVMType(req)
+	if index < 0 {
+		if soldOut {
+			writeError(c.w, http.StatusConflict, "no capacity left for the requested virtual machine")
+		} else {
+			writeError(c.w, http.StatusUnprocessableEntity, "no virtual machine type matches the requested specs")
+		}
+		return
+	}
+	t := &s.vmTypes[index]
+	now := s.now()
+	if !c.team.canAfford(now, t.OnDemandPrice) {
+		writeError(c.w, http.StatusPaymentRequired, "insufficient balance on team %s", c.team.Handle)
+		return
+	}
+
+	id := s.nextID()
+	name := fmt.Sprintf("vm-%04d", id)
+	settle := s.transitionTime
+	if req.UserDataURL != "" {
+		// Custom user-data rebuilds the VM after provisioning
+		settle *= 2
+	}
+	v := &vm{
+		VirtualMachineDetails: client.VirtualMachineDetails{
+			VirtualMachine: client.VirtualMachine{
+				Name:      name,
+				IPAddress: fmt.Sprintf("10.0.%d.%d", id/250, id%250+2),
+				SSHAccess: &client.ExternalService{IPAddress: "203.0.113.10", Port: int64(22000 + id), DNSName: name + ".fake.invalid"},
+			},
+			VirtualMachineSpecs: t.Specs,
+		},
+		resource: resource{price: t.OnDemandPrice, created: now},
+		vmType:   index,
+		host:     fmt.Sprintf("fake-host-%d", id%4+1),
+		state:    vmStateProvisioning,
+		target:   client.VMStateRunning,
+		settleAt: now.Add(settle),
+	}
+	t.Quantity--
+	c.team.vms = append(c.team.vms, v)
+	writeJSON(c.w, http.StatusOK, v.VirtualMachineDetails)
+}
+
+func (s *Server) getVM(c *call) {
+	if v := s.vmFromPath(c); v != nil {
+		writeJSON(c.w, http.StatusOK, v.VirtualMachineDetails)
+	}
+}
+
+func (s *Server) updateVM(c *call) {
+	v := s.vmFromPath(c)
+	if v == nil {
+		return
+	}
+	var update client.VirtualMachineUpdate
+	if !decode(c, &update) {
+		return
+	}
+	v.Description = update.Description
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteVM(c *call) {
+	v := s.vmFromPath(c)
+	if v == nil {
+		return
+	}
+	c.team.spent += v.cost(s.now())
+	c.team.vms = slices.DeleteFunc(c.team.vms, func(other *vm) bool { return other == v })
+	s.vmTypes[v.vmType].Quantity++
+	c.w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getVMState(c *call) {
+	if v := s.vmFromPath(c); v != nil {
+		writeJSON(c.w, http.StatusOK, client.VirtualMachineState{State: v.state, Host: v.host})
+	}
+}
+
+func (s *Server) vmAction(change vmChange) handlerFunc {
+	return func(c *call) {
+		v := s.vmFromPath(c)
+		if v == nil {
+			return
+		}
+		if change.rebuild {
+			var req client.VMResetRequest
+			if c.r.ContentLength != 0 && !decode(c, &req) {
+				return
+			}
+		}
+		if !slices.Contains(change.from, v.state) {
+			writeError(c.w, http.StatusConflict, "virtual machine %s is %s", v.Name, v.state)
+			return
+		}
+		if change.via == "" {
+			v.state = change.to
+		} else {
+			v.state, v.target, v.settleAt = change.via, change.to, s.now().Add(s.transitionTime)
+		}
+		c.w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// vmConsole serves a serial console that echoes what is typed
+func (s *Server) vmConsole(c *call) {
+	v := s.vmFromPath(c)
+	if v == nil {
+		return
+	}
+	name, w, r := v.Name, c.w, c.r
+	c.stream = func() {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+
+		ctx := r.Context()
+		if err := conn.Write(ctx, websocket.MessageText, []byte("Connected to the serial console of "+name+"\r\n")); err != nil {
+			return
+		}
+		for {
+			typ, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if err := conn.Write(ctx, typ, data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -77,13 +77,23 @@ func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
 
+// CaptureStdout returns what fn writes to stdout
 func CaptureStdout(t *testing.T, fn func() error) string {
-	old := os.Stdout
+	return capture(t, &os.Stdout, fn)
+}
+
+// CaptureStderr returns what fn writes to stderr
+func CaptureStderr(t *testing.T, fn func() error) string {
+	return capture(t, &os.Stderr, fn)
+}
+
+func capture(t *testing.T, file **os.File, fn func() error) string {
+	old := *file
 	r, w, err := os.Pipe()
 	if err != nil {
 		t.Fatal(err)
 	}
-	os.Stdout = w
+	*file = w
 
 	err = fn()
 	if err != nil {
@@ -94,7 +104,7 @@ func CaptureStdout(t *testing.T, fn func() error) string {
 	if err != nil {
 		t.Fatal(err)
 	}
-	os.Stdout = old
+	*file = old
 
 	var buf bytes.Buffer
 	_, err = buf.ReadFrom(r)